@@ -0,0 +1,74 @@
+package codegen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/cpekyaman/devtools/migration"
+	"github.com/spf13/cobra"
+)
+
+var migrationName *string
+var migrationDir *string
+
+func createMigrationCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "migration",
+		Short: "generate db migration of entities",
+		Long:  "generate the db migration that creates the tables and columns of module entities missing in existing migrations",
+		Run: func(cmd *cobra.Command, args []string) {
+			generateMigration()
+		},
+	}
+
+	moduleName = m.Flags().String("module", "", "name of module (required)")
+	m.MarkFlagRequired("module")
+
+	migrationName = m.Flags().String("name", "", "name of the migration, defaults to update_<module>_tables")
+	migrationDir = m.Flags().String("dir", filepath.Join("scripts", "db", "postgres", "migrations"), "migrations directory relative to GOITS_HOME")
+
+	return m
+}
+
+func generateMigration() {
+	home := os.Getenv("GOITS_HOME")
+	src := migration.DefaultSources(home)
+
+	entities, err := migration.LoadEntities(src, *moduleName)
+	if err != nil {
+		log.Fatal("could not read entities: ", err)
+	}
+	tables, err := migration.LoadTables(src)
+	if err != nil {
+		log.Fatal("could not read orm metadata: ", err)
+	}
+
+	dir := filepath.Join(home, *migrationDir)
+	schema, version, err := migration.ReadSchema(dir)
+	if err != nil {
+		log.Fatal("could not read existing migrations: ", err)
+	}
+
+	m, err := migration.Generate(entities, tables, schema)
+	if err != nil {
+		log.Fatal("could not generate migration: ", err)
+	}
+	if m.Empty() {
+		log.Printf("tables of %s are up to date", *moduleName)
+		return
+	}
+
+	name := *migrationName
+	if name == "" {
+		name = fmt.Sprintf("update_%s_tables", *moduleName)
+	}
+	outFile := fmt.Sprintf("%s_%s.sql", version.Next(), name)
+
+	log.Printf("generating %s under %s", outFile, dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, outFile), []byte(m.Render()), 0644); err != nil {
+		log.Fatal("could not create output file", err)
+	}
+}
@@ -26,6 +26,7 @@ func init() {
 
 	CodegenCommand.AddCommand(createDomainCommand())
 	CodegenCommand.AddCommand(createServiceCommand())
+	CodegenCommand.AddCommand(createMigrationCommand())
 }
 
 func initTemplates() {
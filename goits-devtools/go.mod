@@ -2,4 +2,8 @@ module github.com/cpekyaman/devtools
 
 go 1.14
 
-require github.com/spf13/cobra v1.1.1
+require (
+	github.com/spf13/cobra v1.1.1
+	github.com/stretchr/testify v1.6.1
+	gopkg.in/yaml.v2 v2.3.0
+)
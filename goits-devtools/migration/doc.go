@@ -0,0 +1,4 @@
+// Package migration generates db migrations from the entity definitions of the goits server.
+// Entities are read from the orm metadata files and the go sources of modules,
+// and compared with the state left by the existing migrations to find the tables and columns to create.
+package migration
@@ -0,0 +1,399 @@
+package migration
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Entity is the table definition of an entity, built from its orm metadata and its go struct.
+type Entity struct {
+	Name       string
+	Schema     string
+	Table      string
+	PKColumn   string
+	SoftDelete bool
+	UniqueKey  []string
+	Relations  []Relation
+	Columns    []Column
+}
+
+// FullTableName returns the schema qualified table name.
+func (this Entity) FullTableName() string {
+	return fmt.Sprintf("%s.%s", this.Schema, this.Table)
+}
+
+// SequenceName returns the name of the sequence generating the primary key of the entity.
+func (this Entity) SequenceName() string {
+	return fmt.Sprintf("%s.%s_seq", this.Schema, this.Table)
+}
+
+// Column finds the column by name.
+func (this Entity) Column(name string) (Column, bool) {
+	for _, c := range this.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// Relation is a relation of the entity as declared in orm metadata.
+type Relation struct {
+	Name       string `yaml:"name"`
+	Kind       string `yaml:"kind"`
+	Target     string `yaml:"target"`
+	ForeignKey string `yaml:"foreignkey"`
+	Field      string `yaml:"field"`
+}
+
+// Column is a column of the table of an entity.
+type Column struct {
+	Name     string
+	Field    string
+	Type     string
+	Nullable bool
+	Default  string
+}
+
+// ormEntity is an entry of the orm metadata files, whose keys are matched ignoring case as the server does.
+type ormEntity struct {
+	Name       string     `yaml:"name"`
+	Schema     string     `yaml:"schema"`
+	Table      string     `yaml:"table"`
+	PKColumn   string     `yaml:"pkcolumn"`
+	SoftDelete bool       `yaml:"softdelete"`
+	UniqueKey  []string   `yaml:"uniquekey"`
+	Relations  []Relation `yaml:"relations"`
+}
+
+// generatedDefaults are the defaults of the columns of fields that are generated by db for the default entity types.
+var generatedDefaults = map[string]string{
+	"Version":          "1",
+	"CreateTime":       "now()",
+	"LastModifiedTime": "now()",
+}
+
+// Sources are the locations entity definitions are read from.
+type Sources struct {
+	// OrmDir is the directory of the orm metadata files of all modules.
+	OrmDir string
+
+	// ApplicationDir is the directory of the application modules, each one in a sub directory with the module name.
+	ApplicationDir string
+
+	// DomainDir is the directory of the framework package defining the default entity types.
+	DomainDir string
+}
+
+// DefaultSources returns the locations of entity definitions under the given goits home.
+func DefaultSources(home string) Sources {
+	return Sources{
+		OrmDir:         filepath.Join(home, "etc", "orm"),
+		ApplicationDir: filepath.Join(home, "goits-server", "application"),
+		DomainDir:      filepath.Join(home, "goits-server", "framework", "orm", "domain"),
+	}
+}
+
+// LoadEntities reads the entities of the module from its orm metadata and go sources, ordered by name.
+func LoadEntities(src Sources, module string) ([]Entity, error) {
+	defs, err := readOrmFile(filepath.Join(src.OrmDir, module+".orm.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	structs, err := parseStructs(filepath.Join(src.ApplicationDir, module))
+	if err != nil {
+		return nil, err
+	}
+	baseStructs, err := parseStructs(src.DomainDir)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]Entity, 0, len(defs))
+	for _, def := range defs {
+		typeName := def.Name[strings.LastIndex(def.Name, ".")+1:]
+		st, found := structs[typeName]
+		if !found {
+			return nil, fmt.Errorf("could not find struct %s of entity %s in module %s", typeName, def.Name, module)
+		}
+
+		e := Entity{
+			Name:       def.Name,
+			Schema:     def.Schema,
+			Table:      def.Table,
+			PKColumn:   def.PKColumn,
+			SoftDelete: def.SoftDelete,
+			UniqueKey:  def.UniqueKey,
+			Relations:  def.Relations,
+		}
+		r := structResolver{structs: structs, baseStructs: baseStructs, seen: make(map[string]bool)}
+		if err := r.collectColumns(&e, st); err != nil {
+			return nil, fmt.Errorf("could not map entity %s: %v", def.Name, err)
+		}
+		entities = append(entities, e)
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].Name < entities[j].Name
+	})
+	return entities, nil
+}
+
+// LoadTables reads the table names of all entities in all orm metadata files by entity name,
+// which are needed to resolve the targets of relations to other modules.
+func LoadTables(src Sources) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(src.OrmDir, "*.orm.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]string)
+	for _, f := range files {
+		defs, err := readOrmFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, def := range defs {
+			tables[def.Name] = fmt.Sprintf("%s.%s", def.Schema, def.Table)
+		}
+	}
+	return tables, nil
+}
+
+func readOrmFile(path string) ([]ormEntity, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+
+	// keys are lower cased and decoded again, since the server reads metadata ignoring the case of keys
+	normalized, err := yaml.Marshal(lowerKeys(raw))
+	if err != nil {
+		return nil, err
+	}
+	var defs map[string]ormEntity
+	if err := yaml.Unmarshal(normalized, &defs); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+
+	list := make([]ormEntity, 0, len(defs))
+	for _, def := range defs {
+		list = append(list, def)
+	}
+	return list, nil
+}
+
+func lowerKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[strings.ToLower(k)] = lowerKeys(e)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[strings.ToLower(fmt.Sprint(k))] = lowerKeys(e)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, e := range t {
+			l[i] = lowerKeys(e)
+		}
+		return l
+	}
+	return v
+}
+
+// parseStructs parses the non test go files in the directory and returns the struct types by name.
+func parseStructs(dir string) (map[string]*ast.StructType, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	structs := make(map[string]*ast.StructType)
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				if ts, ok := n.(*ast.TypeSpec); ok {
+					if st, ok := ts.Type.(*ast.StructType); ok {
+						structs[ts.Name.Name] = st
+					}
+				}
+				return true
+			})
+		}
+	}
+	return structs, nil
+}
+
+// structResolver maps the fields of entity structs to columns in the same way the server introspects them.
+type structResolver struct {
+	structs     map[string]*ast.StructType
+	baseStructs map[string]*ast.StructType
+	seen        map[string]bool
+}
+
+func (this structResolver) collectColumns(e *Entity, st *ast.StructType) error {
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			unquoted, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(unquoted)
+		}
+		if tag.Get("db") == "-" {
+			continue
+		}
+
+		// embedded structs contribute their fields as fields of the entity
+		if len(f.Names) == 0 {
+			embedded, err := this.embeddedStruct(f.Type)
+			if err != nil {
+				return err
+			}
+			if err := this.collectColumns(e, embedded); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+
+			col := Column{Name: name.Name, Field: name.Name, Default: generatedDefaults[name.Name]}
+			if dbName, found := tag.Lookup("db"); found {
+				col.Name = dbName
+			}
+			if this.seen[col.Name] {
+				continue
+			}
+
+			var err error
+			if col.Type, col.Nullable, err = sqlType(f.Type); err != nil {
+				return fmt.Errorf("field %s: %v", name.Name, err)
+			}
+			if col.Name == e.PKColumn && col.Default == "" && isIntegerType(col.Type) {
+				col.Default = fmt.Sprintf("nextval('%s')", e.SequenceName())
+			}
+
+			this.seen[col.Name] = true
+			e.Columns = append(e.Columns, col)
+		}
+	}
+	return nil
+}
+
+func (this structResolver) embeddedStruct(expr ast.Expr) (*ast.StructType, error) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return this.embeddedStruct(t.X)
+	case *ast.Ident:
+		if st, found := this.structs[t.Name]; found {
+			return st, nil
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "domain" {
+			if st, found := this.baseStructs[t.Sel.Name]; found {
+				return st, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("could not resolve embedded type %s", exprString(expr))
+}
+
+// sqlType returns the postgres type of the column a field of the given go type is mapped to,
+// and whether the field can hold null.
+func sqlType(expr ast.Expr) (string, bool, error) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		st, _, err := sqlType(t.X)
+		return st, true, err
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return "text", false, nil
+		case "int64", "uint64":
+			return "bigint", false, nil
+		case "int", "uint", "int32", "uint32":
+			return "integer", false, nil
+		case "int16", "uint16", "int8", "uint8":
+			return "smallint", false, nil
+		case "bool":
+			return "boolean", false, nil
+		case "float64":
+			return "double precision", false, nil
+		case "float32":
+			return "real", false, nil
+		}
+	case *ast.ArrayType:
+		if elt, ok := t.Elt.(*ast.Ident); ok && t.Len == nil && elt.Name == "byte" {
+			return "bytea", true, nil
+		}
+	case *ast.SelectorExpr:
+		switch exprString(t) {
+		case "time.Time":
+			return "timestamp with time zone", false, nil
+		case "sql.NullTime":
+			return "timestamp with time zone", true, nil
+		case "sql.NullString":
+			return "text", true, nil
+		case "sql.NullInt64":
+			return "bigint", true, nil
+		case "sql.NullInt32":
+			return "integer", true, nil
+		case "sql.NullFloat64":
+			return "double precision", true, nil
+		case "sql.NullBool":
+			return "boolean", true, nil
+		}
+	}
+	return "", false, fmt.Errorf("type %s is not supported", exprString(expr))
+}
+
+func isIntegerType(t string) bool {
+	return t == "bigint" || t == "integer" || t == "smallint"
+}
+
+func exprString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return exprString(t.X) + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + exprString(t.X)
+	case *ast.ArrayType:
+		return "[]" + exprString(t.Elt)
+	case *ast.MapType:
+		return "map[" + exprString(t.Key) + "]" + exprString(t.Value)
+	}
+	return fmt.Sprintf("%T", expr)
+}
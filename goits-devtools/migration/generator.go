@@ -0,0 +1,265 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// softDeleteColumns are the columns the server uses to mark the rows of entities using soft delete.
+var softDeleteColumns = []Column{
+	{Name: "deleted", Type: "boolean", Default: "false"},
+	{Name: "deleted_time", Type: "timestamp with time zone", Nullable: true},
+	{Name: "deleted_by", Type: "text", Nullable: true},
+}
+
+// Migration is a generated migration with the statements of its up and down sections.
+type Migration struct {
+	Up   []string
+	Down []string
+}
+
+// Empty checks if the migration has no changes.
+func (this Migration) Empty() bool {
+	return len(this.Up) == 0
+}
+
+// Render writes the migration in the format of sql-migrate.
+func (this Migration) Render() string {
+	var sb strings.Builder
+	sb.WriteString("-- +migrate Up\n")
+	for _, stmt := range this.Up {
+		sb.WriteString("\n" + stmt + "\n")
+	}
+	sb.WriteString("\n-- +migrate Down\n")
+	for _, stmt := range this.Down {
+		sb.WriteString("\n" + stmt + "\n")
+	}
+	return sb.String()
+}
+
+// Generate creates the migration that brings the schema to the state of the entities.
+// Tables that do not exist are created with their sequences, indexes and keys,
+// and the missing columns of existing tables are added. Columns are never dropped,
+// the ones that are not mapped anymore are only noted in the migration.
+// The targets of relations are resolved by tables, which has the table names by entity name.
+func Generate(entities []Entity, tables map[string]string, schema Schema) (Migration, error) {
+	var m Migration
+
+	ordered, err := orderByDependency(entities)
+	if err != nil {
+		return m, err
+	}
+
+	var down [][]string
+	for _, e := range ordered {
+		var up []string
+		var rollback []string
+		if schema.HasTable(e.FullTableName()) {
+			up, rollback = alterTable(e, schema[e.FullTableName()])
+		} else {
+			if up, err = createTable(e, tables); err != nil {
+				return m, err
+			}
+			rollback = dropTable(e)
+		}
+
+		m.Up = append(m.Up, up...)
+		down = append(down, rollback)
+	}
+
+	// changes are rolled back in reverse order so that referencing tables are dropped first
+	for i := len(down) - 1; i >= 0; i-- {
+		m.Down = append(m.Down, down[i]...)
+	}
+	return m, nil
+}
+
+// allColumns returns the columns of the table of the entity, including the soft delete ones.
+func allColumns(e Entity) []Column {
+	if !e.SoftDelete {
+		return e.Columns
+	}
+	return append(append([]Column{}, e.Columns...), softDeleteColumns...)
+}
+
+func createTable(e Entity, tables map[string]string) ([]string, error) {
+	pk, found := e.Column(e.PKColumn)
+	if !found {
+		return nil, fmt.Errorf("primary key %s of %s is not mapped", e.PKColumn, e.Name)
+	}
+
+	var stmt []string
+	if strings.HasPrefix(pk.Default, "nextval(") {
+		stmt = append(stmt, fmt.Sprintf("create sequence %s;", e.SequenceName()))
+	}
+
+	columns := allColumns(e)
+	defs := make([]string, len(columns))
+	for i, c := range columns {
+		defs[i] = "    " + columnDefinition(c)
+	}
+	stmt = append(stmt, fmt.Sprintf("create table %s (\n%s\n);", e.FullTableName(), strings.Join(defs, ",\n")))
+
+	if len(e.UniqueKey) > 0 {
+		stmt = append(stmt, fmt.Sprintf("create unique index %s_%s_unq on %s(%s);",
+			e.Table, strings.Join(e.UniqueKey, "_"), e.FullTableName(), strings.Join(e.UniqueKey, ", ")))
+	}
+
+	pkIndex := fmt.Sprintf("%s_%s_pk", e.Table, e.PKColumn)
+	stmt = append(stmt, fmt.Sprintf("create unique index %s on %s(%s);", pkIndex, e.FullTableName(), e.PKColumn))
+
+	constraints := []string{fmt.Sprintf("add constraint %s_pk primary key using index %s", e.Table, pkIndex)}
+	for _, r := range e.Relations {
+		if r.Kind != "manyToOne" {
+			continue
+		}
+
+		fk, err := foreignKey(e, r, tables)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, fk)
+	}
+	stmt = append(stmt, fmt.Sprintf("alter table %s\n    %s\n;", e.FullTableName(), strings.Join(constraints, ",\n    ")))
+
+	return []string{fmt.Sprintf("-- %s\n%s", e.Name, strings.Join(stmt, "\n"))}, nil
+}
+
+// foreignKey creates the constraint of a many to one relation, which references the primary key of the target.
+// The target is assumed to use id as its primary key, which is the case for the default entity types.
+func foreignKey(e Entity, r Relation, tables map[string]string) (string, error) {
+	target, found := tables[r.Target]
+	if !found {
+		return "", fmt.Errorf("target %s of relation %s of %s is not an entity", r.Target, r.Name, e.Name)
+	}
+
+	var col Column
+	for _, c := range e.Columns {
+		if c.Field == r.ForeignKey {
+			col = c
+		}
+	}
+	if col.Name == "" {
+		return "", fmt.Errorf("foreign key %s of relation %s of %s is not mapped", r.ForeignKey, r.Name, e.Name)
+	}
+
+	return fmt.Sprintf("add constraint %s_%s_fk foreign key (%s) references %s(id)", e.Table, col.Name, col.Name, target), nil
+}
+
+func dropTable(e Entity) []string {
+	stmt := []string{fmt.Sprintf("drop table %s;", e.FullTableName())}
+	if pk, found := e.Column(e.PKColumn); found && strings.HasPrefix(pk.Default, "nextval(") {
+		stmt = append(stmt, fmt.Sprintf("drop sequence %s;", e.SequenceName()))
+	}
+	return []string{strings.Join(stmt, "\n")}
+}
+
+// alterTable adds the columns of the entity that are missing in the existing table.
+// A not null column without a default is added as nullable, since the table may have rows,
+// and is set to not null by a separate statement after the place its existing rows are to be backfilled.
+func alterTable(e Entity, existing map[string]bool) ([]string, []string) {
+	var added []string
+	var dropped []string
+	var notNull []string
+	mapped := make(map[string]bool)
+	for _, c := range allColumns(e) {
+		mapped[c.Name] = true
+		if !existing[c.Name] {
+			if !c.Nullable && c.Default == "" {
+				notNull = append(notNull, c.Name)
+				c.Nullable = true
+			}
+			added = append(added, "add column "+columnDefinition(c))
+			dropped = append(dropped, "drop column "+c.Name)
+		}
+	}
+
+	var notes []string
+	for c := range existing {
+		if !mapped[c] {
+			notes = append(notes, fmt.Sprintf("-- column %s is not mapped by %s anymore, it is not dropped", c, e.Name))
+		}
+	}
+	sort.Strings(notes)
+
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	up := fmt.Sprintf("-- %s\n%salter table %s\n    %s\n;", e.Name, joinLines(notes), e.FullTableName(), strings.Join(added, ",\n    "))
+	if len(notNull) > 0 {
+		set := make([]string, 0, len(notNull))
+		for _, c := range notNull {
+			set = append(set, fmt.Sprintf("alter column %s set not null", c))
+		}
+		up += fmt.Sprintf("\n-- backfill %s of the existing rows here\nalter table %s\n    %s\n;",
+			strings.Join(notNull, ", "), e.FullTableName(), strings.Join(set, ",\n    "))
+	}
+	down := fmt.Sprintf("alter table %s\n    %s\n;", e.FullTableName(), strings.Join(dropped, ",\n    "))
+	return []string{up}, []string{down}
+}
+
+func columnDefinition(c Column) string {
+	def := fmt.Sprintf("%-20s%s", c.Name, c.Type)
+	if c.Nullable {
+		def += " null"
+	} else {
+		def += " not null"
+	}
+	if c.Default != "" {
+		def += " default " + c.Default
+	}
+	return def
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// orderByDependency orders the entities so that the targets of many to one relations come before the entities referencing them.
+func orderByDependency(entities []Entity) ([]Entity, error) {
+	byName := make(map[string]Entity, len(entities))
+	for _, e := range entities {
+		byName[e.Name] = e
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	ordered := make([]Entity, 0, len(entities))
+
+	var visit func(e Entity) error
+	visit = func(e Entity) error {
+		switch state[e.Name] {
+		case visiting:
+			return fmt.Errorf("relations of %s are circular", e.Name)
+		case visited:
+			return nil
+		}
+
+		state[e.Name] = visiting
+		for _, r := range e.Relations {
+			if target, found := byName[r.Target]; found && r.Kind == "manyToOne" && r.Target != e.Name {
+				if err := visit(target); err != nil {
+					return err
+				}
+			}
+		}
+		state[e.Name] = visited
+		ordered = append(ordered, e)
+		return nil
+	}
+
+	for _, e := range entities {
+		if err := visit(e); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,119 @@
+package migration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var itemEntity = Entity{
+	Name:       "test.Item",
+	Schema:     "data",
+	Table:      "item",
+	PKColumn:   "id",
+	SoftDelete: true,
+	UniqueKey:  []string{"name"},
+	Relations:  []Relation{{Name: "Kind", Kind: "manyToOne", Target: "test.Kind", ForeignKey: "Kind", Field: "KindRef"}},
+	Columns: []Column{
+		{Name: "id", Field: "Id", Type: "bigint", Default: "nextval('data.item_seq')"},
+		{Name: "name", Field: "Name", Type: "text"},
+		{Name: "kind", Field: "Kind", Type: "bigint"},
+	},
+}
+
+var itemTables = map[string]string{"test.Item": "data.item", "test.Kind": "config.kind"}
+
+func TestGenerate_CreateTable(t *testing.T) {
+	// when
+	m, err := Generate([]Entity{itemEntity}, itemTables, Schema{})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, []string{`-- test.Item
+create sequence data.item_seq;
+create table data.item (
+    id                  bigint not null default nextval('data.item_seq'),
+    name                text not null,
+    kind                bigint not null,
+    deleted             boolean not null default false,
+    deleted_time        timestamp with time zone null,
+    deleted_by          text null
+);
+create unique index item_name_unq on data.item(name);
+create unique index item_id_pk on data.item(id);
+alter table data.item
+    add constraint item_pk primary key using index item_id_pk,
+    add constraint item_kind_fk foreign key (kind) references config.kind(id)
+;`}, m.Up, "create table is not correct")
+	assert.Equal(t, []string{"drop table data.item;\ndrop sequence data.item_seq;"}, m.Down, "drop table is not correct")
+}
+
+func TestGenerate_AddColumns(t *testing.T) {
+	// given
+	schema := Schema{"data.item": {"id": true, "name": true, "deleted": true, "deleted_time": true, "deleted_by": true, "notes": true}}
+
+	// when
+	m, err := Generate([]Entity{itemEntity}, itemTables, schema)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, []string{`-- test.Item
+-- column notes is not mapped by test.Item anymore, it is not dropped
+alter table data.item
+    add column kind                bigint null
+;
+-- backfill kind of the existing rows here
+alter table data.item
+    alter column kind set not null
+;`}, m.Up, "add column is not correct")
+	assert.Equal(t, []string{"alter table data.item\n    drop column kind\n;"}, m.Down, "drop column is not correct")
+}
+
+func TestGenerate_UpToDate(t *testing.T) {
+	// given
+	schema := Schema{"data.item": {"id": true, "name": true, "kind": true, "deleted": true, "deleted_time": true, "deleted_by": true}}
+
+	// when
+	m, err := Generate([]Entity{itemEntity}, itemTables, schema)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.True(t, m.Empty(), "no change expected")
+}
+
+func TestReadSchema(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "migrations")
+	assert.Nil(t, err, "could not create migrations dir")
+	defer os.RemoveAll(dir)
+
+	writeMigration(t, dir, "V100_0001_create.sql", `-- +migrate Up
+create table data.item (
+    id      bigint not null default nextval('data.item_seq'),
+    name    varchar(25) not null, -- unique name
+    constraint item_name_chk check (length(name) > 0)
+);
+create table data.old (id bigint not null);
+-- +migrate Down
+drop table data.item;`)
+	writeMigration(t, dir, "V100_0010_alter.sql", `-- +migrate Up
+ALTER TABLE data.item ADD COLUMN kind bigint null, DROP COLUMN name;
+drop table data.old;`)
+	writeMigration(t, dir, "V100_0002_other.sql", "-- +migrate Up\n")
+
+	// when
+	schema, version, err := ReadSchema(dir)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, Schema{"data.item": {"id": true, "kind": true}}, schema, "schema is not correct")
+	assert.Equal(t, "V100_0011", version.Next().String(), "next version is not correct")
+}
+
+func writeMigration(t *testing.T, dir string, name string, content string) {
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	assert.Nil(t, err, "could not write migration")
+}
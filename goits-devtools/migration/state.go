@@ -0,0 +1,161 @@
+package migration
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const downMarker = "-- +migrate down"
+
+var (
+	migrationFilePattern = regexp.MustCompile(`^V(\d+)_(\d+)_.*\.sql$`)
+
+	createTablePattern = regexp.MustCompile(`(?is)create\s+table\s+(?:if\s+not\s+exists\s+)?([\w.]+)\s*\((.*?)\)\s*;`)
+	dropTablePattern   = regexp.MustCompile(`(?i)drop\s+table\s+(?:if\s+exists\s+)?([\w.]+)`)
+	alterTablePattern  = regexp.MustCompile(`(?is)alter\s+table\s+(?:if\s+exists\s+)?([\w.]+)\s+(.*?);`)
+	addColumnPattern   = regexp.MustCompile(`(?i)add\s+column\s+(?:if\s+not\s+exists\s+)?(\w+)`)
+	dropColumnPattern  = regexp.MustCompile(`(?i)drop\s+column\s+(?:if\s+exists\s+)?(\w+)`)
+	columnNamePattern  = regexp.MustCompile(`^\s*(\w+)\s+\w`)
+	constraintPattern  = regexp.MustCompile(`(?i)^\s*(constraint|primary|unique|foreign|check)\b`)
+)
+
+// Schema is the state of the tables left by the applied migrations, which are the column names by table name.
+type Schema map[string]map[string]bool
+
+// HasTable checks if the table is created by the migrations.
+func (this Schema) HasTable(table string) bool {
+	_, found := this[table]
+	return found
+}
+
+// Version is the version of a migration file, which is ordered by its major and minor parts.
+type Version struct {
+	Major int
+	Minor int
+}
+
+// Next returns the version following this one within the same major version.
+func (this Version) Next() Version {
+	if this.Major == 0 {
+		return Version{100, 1}
+	}
+	return Version{this.Major, this.Minor + 1}
+}
+
+func (this Version) String() string {
+	return fmt.Sprintf("V%d_%04d", this.Major, this.Minor)
+}
+
+// ReadSchema replays the up sections of the migration files in the directory in version order,
+// and returns the resulting tables with their columns along with the latest version.
+// Only the statements that create, alter and drop tables are taken into account.
+func ReadSchema(dir string) (Schema, Version, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, Version{}, err
+	}
+
+	type migrationFile struct {
+		name    string
+		version Version
+	}
+	var migrations []migrationFile
+	for _, f := range files {
+		m := migrationFilePattern.FindStringSubmatch(f.Name())
+		if m == nil {
+			continue
+		}
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		migrations = append(migrations, migrationFile{f.Name(), Version{major, minor}})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		vi, vj := migrations[i].version, migrations[j].version
+		return vi.Major < vj.Major || (vi.Major == vj.Major && vi.Minor < vj.Minor)
+	})
+
+	schema := make(Schema)
+	var latest Version
+	for _, m := range migrations {
+		content, err := ioutil.ReadFile(filepath.Join(dir, m.name))
+		if err != nil {
+			return nil, Version{}, err
+		}
+		schema.apply(upSection(string(content)))
+		latest = m.version
+	}
+	return schema, latest, nil
+}
+
+// upSection returns the part of a migration that is applied on upgrade, without comments.
+func upSection(content string) string {
+	if i := strings.Index(strings.ToLower(content), downMarker); i >= 0 {
+		content = content[:i]
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if j := strings.Index(line, "--"); j >= 0 {
+			lines[i] = line[:j]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// apply changes the schema by the statements of a migration.
+func (this Schema) apply(sql string) {
+	for _, m := range createTablePattern.FindAllStringSubmatch(sql, -1) {
+		columns := make(map[string]bool)
+		for _, def := range splitDefinitions(m[2]) {
+			if constraintPattern.MatchString(def) {
+				continue
+			}
+			if c := columnNamePattern.FindStringSubmatch(def); c != nil {
+				columns[strings.ToLower(c[1])] = true
+			}
+		}
+		this[strings.ToLower(m[1])] = columns
+	}
+
+	for _, m := range alterTablePattern.FindAllStringSubmatch(sql, -1) {
+		columns, found := this[strings.ToLower(m[1])]
+		if !found {
+			continue
+		}
+		for _, c := range addColumnPattern.FindAllStringSubmatch(m[2], -1) {
+			columns[strings.ToLower(c[1])] = true
+		}
+		for _, c := range dropColumnPattern.FindAllStringSubmatch(m[2], -1) {
+			delete(columns, strings.ToLower(c[1]))
+		}
+	}
+
+	for _, m := range dropTablePattern.FindAllStringSubmatch(sql, -1) {
+		delete(this, strings.ToLower(m[1]))
+	}
+}
+
+// splitDefinitions splits the body of a create table statement by the commas that are not in parentheses.
+func splitDefinitions(body string) []string {
+	var defs []string
+	depth, start := 0, 0
+	for i, r := range body {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, body[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(defs, body[start:])
+}
@@ -0,0 +1,276 @@
+package comment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cpekyaman/goits/framework/caching"
+	"github.com/cpekyaman/goits/framework/commons"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/patching"
+	"github.com/cpekyaman/goits/framework/services"
+	"github.com/cpekyaman/goits/framework/validation"
+)
+
+// fixedFields guards the fields that are assigned on creation or by removal,
+// which can not be changed by updates.
+var fixedFields = services.FixedFields{
+	Entity: "a comment",
+	Fields: []string{"issue", "replyTo", "author", "removed"},
+	Values: fixedValues,
+}
+
+type CommentService interface {
+	services.CRUDService
+
+	// IssueIdOf finds the id of the issue the comments are listed under, by its id or its key.
+	IssueIdOf(ctx context.Context, ref string) (uint64, error)
+
+	// History returns the previous bodies of the comment, oldest first.
+	History(ctx context.Context, ref string) (interface{}, error)
+}
+
+type commentServiceImpl struct {
+	repo      CommentRepository
+	revisions CommentRevisionRepository
+	svcImpl   services.CRUDServiceImpl
+}
+
+func newDefaultCommentService() CommentService {
+	return newCommentService(newCommentRepository(), caching.NamedCache("comment"), validation.Provider())
+}
+
+func newCommentService(cr CommentRepository, c caching.Cache, vp validation.ValidationProvider) CommentService {
+	return commentServiceImpl{cr, newCommentRevisionRepository(), services.NewCRUDService(cr, c, vp)}
+}
+
+func (this commentServiceImpl) GetAll(ctx context.Context, opts ...query.Option) (interface{}, error) {
+	var resultList []Comment
+	err := this.repo.FindAll(ctx, &resultList, opts...)
+	return resultList, err
+}
+
+func (this commentServiceImpl) GetAllPaged(ctx context.Context, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
+	var resultList []Comment
+	return this.svcImpl.GetAllPaged(ctx, &resultList, limit, offset, opts...)
+}
+
+func (this commentServiceImpl) GetById(ctx context.Context, id uint64, opts ...query.Option) (interface{}, error) {
+	return this.svcImpl.GetById(ctx, &Comment{}, id, opts...)
+}
+
+func (this commentServiceImpl) FindOne(ctx context.Context, attr string, attrValue interface{}) (interface{}, error) {
+	var result Comment
+	err := this.repo.FindOneByAttribute(ctx, &result, attr, attrValue)
+	return &result, err
+}
+
+func (this commentServiceImpl) FindAll(ctx context.Context, attrs map[string]interface{}) (interface{}, error) {
+	var resultList []Comment
+	err := this.repo.FindAllByAttributes(ctx, &resultList, attrs)
+	return resultList, err
+}
+
+func (this commentServiceImpl) FindAllPaged(ctx context.Context, attrs map[string]interface{}, limit uint, offset uint64) (services.Page, error) {
+	var resultList []Comment
+	return this.svcImpl.FindAllPaged(ctx, &resultList, attrs, limit, offset)
+}
+
+func (this commentServiceImpl) FindAllByCriteria(ctx context.Context, c query.Criteria, opts ...query.Option) (interface{}, error) {
+	var resultList []Comment
+	err := this.repo.FindAllByCriteria(ctx, &resultList, c, opts...)
+	return resultList, err
+}
+
+func (this commentServiceImpl) FindAllByCriteriaPaged(ctx context.Context, c query.Criteria, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
+	var resultList []Comment
+	return this.svcImpl.FindAllByCriteriaPaged(ctx, &resultList, c, limit, offset, opts...)
+}
+
+func (this commentServiceImpl) FindAllByCursor(ctx context.Context, c query.Criteria, after query.Cursor, limit uint, opts ...query.Option) (services.CursorPage, error) {
+	var resultList []Comment
+	return this.svcImpl.FindAllByCursor(ctx, &resultList, c, after, limit, opts...)
+}
+
+// Create saves the comment with the actor of the context as its author, unless there is no actor.
+// A reply must be on the same issue as the comment it replies to.
+func (this commentServiceImpl) Create(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
+	return this.svcImpl.Create(ctx, this.authorBinder(ctx, binding), commentTypeName, NewComment())
+}
+
+// authorBinder binds the input with the given binding, then assigns the author and checks the comment it replies to.
+func (this commentServiceImpl) authorBinder(ctx context.Context, binding services.ObjectBinder) services.ObjectBinder {
+	return services.ObjectBinderFunc(func(target interface{}) error {
+		if err := binding.BindTo(target); err != nil {
+			return err
+		}
+
+		comment, ok := target.(*Comment)
+		if !ok {
+			return nil
+		}
+		if actor, found := commons.ActorFromContext(ctx); found {
+			comment.Author = actor
+		}
+		comment.Removed = false
+
+		if comment.ReplyTo == nil {
+			return nil
+		}
+		var replied Comment
+		if err := this.repo.FindOneById(ctx, &replied, *comment.ReplyTo); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("validation: comment %d to reply does not exist", *comment.ReplyTo)
+			}
+			return err
+		}
+		if replied.Issue != comment.Issue {
+			return fmt.Errorf("validation: comment %d to reply is not on the same issue", replied.Id)
+		}
+		return nil
+	})
+}
+
+// Update changes the comment of the actor, keeping its previous body in its history.
+func (this commentServiceImpl) Update(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
+	fixed := fixedFields.Binder(binding)
+	return this.edit(ctx, id, func(ctx context.Context) (domain.Entity, error) {
+		return this.svcImpl.Update(ctx, id, fixed, commentTypeName, NewComment())
+	})
+}
+
+// Patch changes the comment of the actor, keeping its previous body in its history.
+func (this commentServiceImpl) Patch(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+	return this.edit(ctx, id, func(ctx context.Context) (domain.Entity, error) {
+		return this.svcImpl.Patch(ctx, id, fixedFields.Patch(patch), commentTypeName, NewComment())
+	})
+}
+
+// edit runs the change of the comment in a transaction, recording a revision if the change replaces its body.
+func (this commentServiceImpl) edit(ctx context.Context, id uint64, change func(ctx context.Context) (domain.Entity, error)) (interface{}, error) {
+	var edited domain.Entity
+	err := this.svcImpl.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+		current, err := this.editable(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if edited, err = change(ctx); err != nil {
+			return err
+		}
+		if after, ok := edited.(*Comment); ok && after.Body != current.Body {
+			return this.recordRevision(ctx, current)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return edited, nil
+}
+
+// Delete removes the comment of the actor by replacing its body with a placeholder,
+// so that the replies to it still have their place in the discussion.
+func (this commentServiceImpl) Delete(ctx context.Context, id uint64) error {
+	return this.remove(ctx, id, nil)
+}
+
+// DeleteVersion removes the comment of the actor in the same way as Delete, if it still has the given version.
+func (this commentServiceImpl) DeleteVersion(ctx context.Context, id uint64, version uint32) error {
+	return this.remove(ctx, id, &version)
+}
+
+// remove replaces the body of the comment with the placeholder, checking its version first if one is given.
+// The change is saved conditioned on the version the comment is loaded with, so the check holds until it is saved.
+func (this commentServiceImpl) remove(ctx context.Context, id uint64, version *uint32) error {
+	err := this.svcImpl.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+		current, err := this.editable(ctx, id)
+		if err != nil {
+			return err
+		}
+		if version != nil && current.Version != *version {
+			return repository.OptimisticLockError{Entity: commentTypeName, Id: id, Version: *version, CurrentVersion: current.Version}
+		}
+		snapshot := domain.Snapshot(current)
+
+		current.Body = RemovedBody
+		current.Removed = true
+		return this.repo.SaveChanges(ctx, current, snapshot)
+	})
+	if err == nil && this.svcImpl.Cache() != nil {
+		this.svcImpl.Cache().Invalidate(caching.IdToKey(id))
+	}
+	return err
+}
+
+// Restore is not supported, since the body of a removed comment is not kept.
+func (this commentServiceImpl) Restore(ctx context.Context, id uint64) error {
+	return fmt.Errorf("conflict: removed comment %d can not be restored", id)
+}
+
+func (this commentServiceImpl) IssueIdOf(ctx context.Context, ref string) (uint64, error) {
+	return this.repo.IssueIdOf(ctx, ref)
+}
+
+func (this commentServiceImpl) History(ctx context.Context, ref string) (interface{}, error) {
+	id, err := strconv.ParseUint(ref, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("binding: %s is not a comment id", ref)
+	}
+
+	var comment Comment
+	if err := this.repo.FindOneById(ctx, &comment, id); err != nil {
+		return nil, err
+	}
+	if comment.Removed {
+		return nil, fmt.Errorf("notfound: comment %d is removed", id)
+	}
+
+	var resultList []CommentRevision
+	err = this.revisions.FindAllByAttributes(ctx, &resultList, map[string]interface{}{"Comment": id})
+	return resultList, err
+}
+
+// editable loads the comment that can be changed by the actor, which is only its author.
+func (this commentServiceImpl) editable(ctx context.Context, id uint64) (*Comment, error) {
+	comment := NewComment()
+	if err := this.repo.FindOneById(ctx, comment, id); err != nil {
+		return nil, err
+	}
+
+	if actor, found := commons.ActorFromContext(ctx); !found || actor != comment.Author {
+		return nil, fmt.Errorf("forbidden: comment %d can only be changed by its author", id)
+	}
+	if comment.Removed {
+		return nil, fmt.Errorf("conflict: comment %d is removed", id)
+	}
+	return comment, nil
+}
+
+// recordRevision keeps the body the comment had before it is edited by the actor.
+func (this commentServiceImpl) recordRevision(ctx context.Context, before *Comment) error {
+	rev := CommentRevision{Comment: before.Id, Body: before.Body}
+	if actor, found := commons.ActorFromContext(ctx); found {
+		rev.Editor = &actor
+	}
+	return this.revisions.Save(ctx, &rev)
+}
+
+// fixedValues gets the values of the fixed fields of the comment in their json form.
+func fixedValues(target interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	if comment, ok := target.(*Comment); ok {
+		values["issue"] = float64(comment.Issue)
+		if comment.ReplyTo != nil {
+			values["replyTo"] = float64(*comment.ReplyTo)
+		}
+		values["author"] = comment.Author
+		values["removed"] = comment.Removed
+	}
+	return values
+}
@@ -0,0 +1,261 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cpekyaman/goits/framework/caching"
+	"github.com/cpekyaman/goits/framework/commons"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/patching"
+	"github.com/cpekyaman/goits/framework/services"
+	"github.com/cpekyaman/goits/framework/testlib"
+	"github.com/cpekyaman/goits/framework/testlib/mocking"
+	"github.com/cpekyaman/goits/framework/validation"
+)
+
+var st *testlib.ServiceTest
+
+var commentColumns = []string{"id", "issue", "author", "body", "removed"}
+
+func init() {
+	st = testlib.NewServiceTest(commentED).
+		WithDbMetaData(testlib.DBMetaData{Columns: commentColumns}).
+		WithFactory(func(c caching.Cache, vp validation.ValidationProvider) interface{} {
+			return newCommentService(newCommentRepository(), c, vp)
+		})
+}
+
+func TestSVC_Comment_GetAll_Success(t *testing.T) {
+	context := testlib.NewTestContext().
+		WithRowMock(func(r *sqlmock.Rows) {
+			r.AddRow(1, 1, "ann", "first", false)
+			r.AddRow(2, 1, "bob", RemovedBody, true)
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			comments, ok := result.RawResult.([]Comment)
+
+			assert.True(t, ok, "not a comment slice")
+			assert.Equal(t, 2, len(comments), "removed comments should be listed too")
+			assert.Equal(t, "first", comments[0].Body, "first object does not have correct body")
+			assert.True(t, comments[1].Removed, "second object should be removed")
+		})
+
+	st.GetAll_Success(t, context)
+}
+
+func TestSVC_Comment_Create_Reply_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	vp := mocking.NewMockValidationProvider(ctrl)
+	svc := newCommentService(newCommentRepository(), mocking.NewMockCache(ctrl), vp)
+
+	expectComment(mock, 1, 1, "bob", false)
+	vp.EXPECT().ValidateStruct(gomock.Eq(commentTypeName), gomock.Any()).Return(nil)
+	mock.ExpectBegin()
+	mocking.NewQueryMocker(commentED).ExpectInsert(mock, 2)
+	mock.ExpectCommit()
+
+	ctx := commons.WithActor(context.Background(), "ann")
+
+	// when
+	result, err := svc.Create(ctx, services.ObjectBinderFunc(replyBinder(1)))
+
+	// then
+	assert.Nil(t, err, "create should be successfull")
+	comment, ok := result.(*Comment)
+	assert.True(t, ok, "created comment should be returned")
+	assert.Equal(t, uint64(2), comment.Id, "generated id should be set")
+	assert.Equal(t, "ann", comment.Author, "actor should be the author")
+	assert.Nil(t, mock.ExpectationsWereMet(), "all expected queries should be run")
+}
+
+func TestSVC_Comment_Create_ReplyOnOtherIssue_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newCommentService(newCommentRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	expectComment(mock, 1, 2, "bob", false)
+
+	// when
+	_, err := svc.Create(commons.WithActor(context.Background(), "ann"), services.ObjectBinderFunc(replyBinder(1)))
+
+	// then
+	assert.NotNil(t, err, "create should fail")
+	assert.True(t, strings.HasPrefix(err.Error(), "validation:"), "should be a validation error")
+}
+
+func TestSVC_Comment_Update_RecordsRevision(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	c := mocking.NewMockCache(ctrl)
+	vp := mocking.NewMockValidationProvider(ctrl)
+	svc := newCommentService(newCommentRepository(), c, vp)
+
+	updated := NewComment()
+	updated.SetId(1)
+
+	mock.ExpectBegin()
+	expectComment(mock, 1, 1, "ann", false)
+	expectComment(mock, 1, 1, "ann", false)
+	vp.EXPECT().ValidateStruct(gomock.Eq(commentTypeName), gomock.Any()).Return(nil)
+	mocking.NewQueryMocker(commentED).ExpectUpdate(mock, updated).
+		WithArgs("changed", 1, 0)
+	mocking.NewQueryMocker(commentRevisionED).ExpectInsert(mock, 5).
+		WithArgs("body of 1", 1, "ann")
+	mock.ExpectCommit()
+
+	c.EXPECT().Invalidate(gomock.Eq(caching.IdToKey(1)))
+
+	ctx := commons.WithActor(context.Background(), "ann")
+
+	// when
+	result, err := svc.Update(ctx, 1, services.ObjectBinderFunc(func(target interface{}) error {
+		target.(*Comment).Body = "changed"
+		return nil
+	}))
+
+	// then
+	assert.Nil(t, err, "update should be successfull")
+	comment, ok := result.(*Comment)
+	assert.True(t, ok, "updated comment should be returned")
+	assert.Equal(t, "changed", comment.Body, "body should be changed")
+	assert.Nil(t, mock.ExpectationsWereMet(), "previous body should be kept in the same transaction")
+}
+
+func TestSVC_Comment_Patch_NotAuthor_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newCommentService(newCommentRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	patch, err := patching.NewMergePatch([]byte(`{"body":"patched"}`))
+	assert.Nil(t, err, "could not create patch")
+
+	mock.ExpectBegin()
+	expectComment(mock, 1, 1, "ann", false)
+	mock.ExpectRollback()
+
+	// when
+	_, err = svc.Patch(commons.WithActor(context.Background(), "bob"), 1, patch)
+
+	// then
+	assert.NotNil(t, err, "patch should fail")
+	assert.True(t, strings.HasPrefix(err.Error(), "forbidden:"), "should be a forbidden error")
+	assert.Nil(t, mock.ExpectationsWereMet(), "transaction should be rolled back")
+}
+
+func TestSVC_Comment_Patch_Removed_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newCommentService(newCommentRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	patch, err := patching.NewMergePatch([]byte(`{"body":"patched"}`))
+	assert.Nil(t, err, "could not create patch")
+
+	mock.ExpectBegin()
+	expectComment(mock, 1, 1, "ann", true)
+	mock.ExpectRollback()
+
+	// when
+	_, err = svc.Patch(commons.WithActor(context.Background(), "ann"), 1, patch)
+
+	// then
+	assert.NotNil(t, err, "patch should fail")
+	assert.True(t, strings.HasPrefix(err.Error(), "conflict:"), "should be a conflict error")
+}
+
+func TestSVC_Comment_Delete_LeavesPlaceholder(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	c := mocking.NewMockCache(ctrl)
+	svc := newCommentService(newCommentRepository(), c, mocking.NewMockValidationProvider(ctrl))
+
+	removed := NewComment()
+	removed.SetId(1)
+
+	mock.ExpectBegin()
+	expectComment(mock, 1, 1, "ann", false)
+	mocking.NewQueryMocker(commentED).ExpectUpdate(mock, removed).
+		WithArgs(RemovedBody, true, 1, 0)
+	mock.ExpectCommit()
+
+	c.EXPECT().Invalidate(gomock.Eq(caching.IdToKey(1)))
+
+	// when
+	err := svc.Delete(commons.WithActor(context.Background(), "ann"), 1)
+
+	// then
+	assert.Nil(t, err, "delete should be successfull")
+	assert.Nil(t, mock.ExpectationsWereMet(), "comment should be updated instead of deleted")
+}
+
+func TestSVC_Comment_DeleteVersion_Stale_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newCommentService(newCommentRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	mock.ExpectBegin()
+	expectComment(mock, 1, 1, "ann", false)
+	mock.ExpectRollback()
+
+	// when
+	err := svc.DeleteVersion(commons.WithActor(context.Background(), "ann"), 1, 3)
+
+	// then
+	assert.True(t, errors.Is(err, repository.ErrOptimisticLock), "stale version should not be removed")
+	assert.Nil(t, mock.ExpectationsWereMet(), "comment should not be updated")
+}
+
+func TestSVC_Comment_History_Removed_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newCommentService(newCommentRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	expectComment(mock, 1, 1, "ann", true)
+
+	// when
+	_, err := svc.History(context.Background(), "1")
+
+	// then
+	assert.NotNil(t, err, "history of removed comment should not be returned")
+	assert.True(t, strings.HasPrefix(err.Error(), "notfound:"), "should be a not found error")
+}
+
+func expectComment(mock sqlmock.Sqlmock, id uint64, issue uint64, author string, removed bool) {
+	body := "body of 1"
+	if removed {
+		body = RemovedBody
+	}
+	mocking.NewQueryMocker(commentED).ExpectFindOne(mock).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(commentColumns).AddRow(id, issue, author, body, removed))
+}
+
+func replyBinder(replyTo uint64) func(target interface{}) error {
+	return func(target interface{}) error {
+		comment, ok := target.(*Comment)
+		if !ok {
+			return nil
+		}
+		comment.Issue = 1
+		comment.ReplyTo = &replyTo
+		comment.Author = "someone else"
+		comment.Body = "a *markdown* reply"
+
+		return nil
+	}
+}
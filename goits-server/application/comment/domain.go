@@ -0,0 +1,56 @@
+package comment
+
+import (
+	"github.com/cpekyaman/goits/application/issue"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/validation"
+)
+
+const (
+	commentTypeName         = "comment.Comment"
+	commentRevisionTypeName = "comment.CommentRevision"
+
+	// entity of the issue module that the comment repository reads
+	issueTypeName = "issue.Issue"
+
+	// RemovedBody is the placeholder a removed comment shows instead of its body, keeping its place in the discussion.
+	RemovedBody = "comment removed"
+)
+
+func initDomain() {
+	registerCommentValidations()
+}
+
+// Comment is a markdown message on an issue, which may reply to another comment of the same issue.
+// Only its author can edit or remove it, and a removed comment stays in the discussion as a placeholder.
+type Comment struct {
+	domain.VersionedTimeStampedEntity
+	Issue   uint64  `json:"issue" db:"issue"`
+	ReplyTo *uint64 `json:"replyTo,omitempty" db:"reply_to"`
+	Author  string  `json:"author" db:"author"`
+	Body    string  `json:"body" db:"body"`
+	Removed bool    `json:"removed" db:"removed"`
+
+	// relations which are only loaded when included
+	IssueRef *issue.Issue `json:"issueRef,omitempty" db:"-"`
+}
+
+// CommentRevision keeps a previous body of an edited comment, along with the one who changed it.
+type CommentRevision struct {
+	domain.TimestampedEntity
+	Comment uint64  `json:"comment" db:"comment"`
+	Body    string  `json:"body" db:"body"`
+	Editor  *string `json:"editor,omitempty" db:"editor"`
+}
+
+func registerCommentValidations() {
+	sv := validation.Struct(commentTypeName)
+
+	sv.Field("Issue").With(validation.ValidId()).
+		Field("Author").With(validation.NotBlank()).
+		Field("Body").With(validation.NotBlank(), validation.StrLen(1, 20000))
+}
+
+func NewComment() *Comment {
+	return &Comment{VersionedTimeStampedEntity: domain.VersionedTimeStampedEntity{}}
+}
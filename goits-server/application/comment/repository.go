@@ -0,0 +1,78 @@
+//go:generate mockgen -source=repository.go -destination=repository_mock.go -package=comment
+package comment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+var commentED metadata.EntityDef
+var commentRevisionED metadata.EntityDef
+var issueED metadata.EntityDef
+
+func init() {
+	domain.RegisterEntityConfig("comment")
+
+	commentED = domain.EntityDefByName(commentTypeName)
+	commentRevisionED = domain.EntityDefByName(commentRevisionTypeName)
+	issueED = domain.EntityDefByName(issueTypeName)
+
+	domain.RegisterEntityType(commentTypeName, &Comment{})
+	domain.RegisterEntityType(commentRevisionTypeName, &CommentRevision{})
+}
+
+type CommentRepository interface {
+	repository.Repository
+
+	// IssueIdOf finds the id of the issue that is referred to by its id or its key, failing if there is no such issue.
+	IssueIdOf(ctx context.Context, ref string) (uint64, error)
+}
+
+type commentSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newCommentRepository() CommentRepository {
+	return commentSqlRepository{repository.NewRepository(commentED, &Comment{})}
+}
+
+func (this commentSqlRepository) IssueIdOf(ctx context.Context, ref string) (uint64, error) {
+	d := dialect.Current()
+
+	where, arg := "issue_key", interface{}(ref)
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		where, arg = "id", id
+	}
+
+	var id uint64
+	q := query.WithNotDeleted(issueED, fmt.Sprintf("select id from %s where %s = %s", d.Table(issueED.Schema(), issueED.Table()), where, d.Placeholder(1)))
+	if err := sqlx.GetContext(ctx, this.Executor(ctx), &id, q, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("notfound: issue %s does not exist", ref)
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+type CommentRevisionRepository interface {
+	repository.Repository
+}
+
+type commentRevisionSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newCommentRevisionRepository() CommentRevisionRepository {
+	return commentRevisionSqlRepository{repository.NewRepository(commentRevisionED, &CommentRevision{})}
+}
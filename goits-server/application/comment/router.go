@@ -0,0 +1,43 @@
+package comment
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cpekyaman/goits/framework/routing"
+)
+
+type commentResource struct {
+	svc CommentService
+	routing.ApiResource
+}
+
+var commentAPI commentResource
+
+func InitComment() {
+	initDomain()
+
+	commentAPI = newCommentResource(newDefaultCommentService())
+	commentAPI.Register()
+}
+
+// newCommentResource creates the resource of comments, which are listed and created under their issues as /issue/{key}/comments
+// and changed as /comments/{id}. The previous bodies of a comment are listed by GET /comments/{id}/history.
+// Comments can be read anonymously, while writing them requires an authenticated user who becomes the author.
+func newCommentResource(svc CommentService) commentResource {
+	res := commentResource{
+		svc,
+		routing.NewApiResource("Comment", "comments", svc).
+			WithEntity(commentED).
+			WithParent("issue", "Issue").
+			WithParentResolver(svc.IssueIdOf).
+			WithAction(http.MethodGet, "/history", "getHistory",
+				func(ctx context.Context, ref string, param func(string) string) (interface{}, error) {
+					return svc.History(ctx, ref)
+				}).
+			WithAuthenticated().
+			WithPublic("getAll", "getById", "getAllOfParent", "getHistory"),
+	}
+
+	return res
+}
@@ -0,0 +1,22 @@
+package event
+
+import (
+	"github.com/cpekyaman/goits/framework/orm/domain"
+)
+
+const (
+	auditEventTypeTypeName  = "event.AuditEventType"
+	domainEventTypeTypeName = "event.DomainEventType"
+)
+
+// AuditEventType is the kind of an audit event, which records an operation done on an entity by an actor.
+type AuditEventType struct {
+	domain.DomainEntity
+	Name string `json:"name" db:"name"`
+}
+
+// DomainEventType is the kind of a domain event, which records a change in the state of an entity.
+type DomainEventType struct {
+	domain.DomainEntity
+	Name string `json:"name" db:"name"`
+}
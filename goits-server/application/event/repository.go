@@ -0,0 +1,48 @@
+package event
+
+import (
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/seeding"
+)
+
+var auditEventTypeED metadata.EntityDef
+var domainEventTypeED metadata.EntityDef
+
+func init() {
+	domain.RegisterEntityConfig("event")
+
+	auditEventTypeED = domain.EntityDefByName(auditEventTypeTypeName)
+	domainEventTypeED = domain.EntityDefByName(domainEventTypeTypeName)
+
+	domain.RegisterEntityType(auditEventTypeTypeName, &AuditEventType{})
+	domain.RegisterEntityType(domainEventTypeTypeName, &DomainEventType{})
+
+	seeding.Register(auditEventTypeTypeName, func() seeding.Upserter { return newAuditEventTypeRepository() })
+	seeding.Register(domainEventTypeTypeName, func() seeding.Upserter { return newDomainEventTypeRepository() })
+}
+
+type AuditEventTypeRepository interface {
+	repository.Repository
+}
+
+type auditEventTypeSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newAuditEventTypeRepository() AuditEventTypeRepository {
+	return auditEventTypeSqlRepository{repository.NewRepository(auditEventTypeED, &AuditEventType{})}
+}
+
+type DomainEventTypeRepository interface {
+	repository.Repository
+}
+
+type domainEventTypeSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newDomainEventTypeRepository() DomainEventTypeRepository {
+	return domainEventTypeSqlRepository{repository.NewRepository(domainEventTypeED, &DomainEventType{})}
+}
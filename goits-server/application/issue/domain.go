@@ -0,0 +1,82 @@
+package issue
+
+import (
+	"time"
+
+	"github.com/cpekyaman/goits/application/project"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/validation"
+)
+
+const (
+	issueTypeName         = "issue.Issue"
+	issueTypeTypeName     = "issue.IssueType"
+	issuePriorityTypeName = "issue.IssuePriority"
+	issueStatusTypeName   = "issue.IssueStatus"
+
+	// entities of the project module that the issue repository reads
+	projectTypeName     = "project.Project"
+	projectTypeTypeName = "project.ProjectType"
+)
+
+func initDomain() {
+	registerIssueValidations()
+}
+
+type IssueType struct {
+	domain.VersionedEntity
+	Name        string `json:"name" db:"name"`
+	Description string `json:"desc" db:"description"`
+}
+
+type IssuePriority struct {
+	domain.VersionedEntity
+	Name        string `json:"name" db:"name"`
+	Description string `json:"desc" db:"description"`
+}
+
+type IssueStatus struct {
+	domain.VersionedEntity
+	Name        string `json:"name" db:"name"`
+	Description string `json:"desc" db:"description"`
+}
+
+// Issue is a single work item of a project, which is identified by its key like GOITS-123 in its project.
+// Key and number are assigned on creation and do not change afterwards.
+// Status and resolution are only changed by the transitions of the workflow of the project type.
+type Issue struct {
+	domain.VersionedTimeStampedEntity
+	Key         string     `json:"key" db:"issue_key"`
+	Project     uint64     `json:"project" db:"project"`
+	Number      uint64     `json:"number" db:"number"`
+	Summary     string     `json:"summary" db:"summary"`
+	Description string     `json:"desc" db:"description"`
+	Type        uint64     `json:"type" db:"type"`
+	Priority    uint64     `json:"priority" db:"priority"`
+	Status      uint64     `json:"status" db:"status"`
+	Reporter    string     `json:"reporter" db:"reporter"`
+	Assignee    *string    `json:"assignee,omitempty" db:"assignee"`
+	DueDate     *time.Time `json:"dueDate,omitempty" db:"due_date"`
+	Resolution  *string    `json:"resolution,omitempty" db:"resolution"`
+
+	// relations which are only loaded when included
+	ProjectRef  *project.Project `json:"projectRef,omitempty" db:"-"`
+	TypeRef     *IssueType       `json:"typeRef,omitempty" db:"-"`
+	PriorityRef *IssuePriority   `json:"priorityRef,omitempty" db:"-"`
+	StatusRef   *IssueStatus     `json:"statusRef,omitempty" db:"-"`
+}
+
+func registerIssueValidations() {
+	sv := validation.Struct(issueTypeName)
+
+	sv.Field("Project").With(validation.ValidId()).
+		Field("Summary").With(validation.NotBlank(), validation.StrLen(1, 250)).
+		Field("Type").With(validation.ValidId()).
+		Field("Priority").With(validation.ValidId()).
+		Field("Status").With(validation.ValidId()).
+		Field("Reporter").With(validation.NotBlank())
+}
+
+func NewIssue() *Issue {
+	return &Issue{VersionedTimeStampedEntity: domain.VersionedTimeStampedEntity{}}
+}
@@ -0,0 +1,283 @@
+package issue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cpekyaman/goits/framework/caching"
+	"github.com/cpekyaman/goits/framework/commons"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/cpekyaman/goits/framework/patching"
+	"github.com/cpekyaman/goits/framework/services"
+	"github.com/cpekyaman/goits/framework/validation"
+	"github.com/cpekyaman/goits/framework/workflow"
+)
+
+// fixedFields guards the fields that are assigned on creation or by workflow transitions,
+// which can not be changed by updates.
+var fixedFields = services.FixedFields{
+	Entity: "an issue",
+	Fields: []string{"project", "number", "key", "status", "resolution"},
+	Values: fixedValues,
+}
+
+type IssueService interface {
+	services.CRUDService
+
+	// Transitions returns the transitions of the workflow available from the current status of the issue.
+	Transitions(ctx context.Context, ref string) (interface{}, error)
+
+	// Transition moves the issue to another status by the transition with the given name.
+	Transition(ctx context.Context, ref string, name string) (interface{}, error)
+}
+
+type issueServiceImpl struct {
+	repo     IssueRepository
+	statuses IssueStatusRepository
+	svcImpl  services.CRUDServiceImpl
+}
+
+func newDefaultIssueService() IssueService {
+	return newIssueService(newIssueRepository(), caching.NamedCache("issue"), validation.Provider())
+}
+
+func newIssueService(ir IssueRepository, c caching.Cache, vp validation.ValidationProvider) IssueService {
+	return issueServiceImpl{ir, newIssueStatusRepository(), services.NewCRUDService(ir, c, vp)}
+}
+
+func (this issueServiceImpl) GetAll(ctx context.Context, opts ...query.Option) (interface{}, error) {
+	var resultList []Issue
+	err := this.repo.FindAll(ctx, &resultList, opts...)
+	return resultList, err
+}
+
+func (this issueServiceImpl) GetAllPaged(ctx context.Context, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
+	var resultList []Issue
+	return this.svcImpl.GetAllPaged(ctx, &resultList, limit, offset, opts...)
+}
+
+func (this issueServiceImpl) GetById(ctx context.Context, id uint64, opts ...query.Option) (interface{}, error) {
+	return this.svcImpl.GetById(ctx, &Issue{}, id, opts...)
+}
+
+func (this issueServiceImpl) FindOne(ctx context.Context, attr string, attrValue interface{}) (interface{}, error) {
+	var result Issue
+	err := this.repo.FindOneByAttribute(ctx, &result, attr, attrValue)
+	return &result, err
+}
+
+func (this issueServiceImpl) FindAll(ctx context.Context, attrs map[string]interface{}) (interface{}, error) {
+	var resultList []Issue
+	err := this.repo.FindAllByAttributes(ctx, &resultList, attrs)
+	return resultList, err
+}
+
+func (this issueServiceImpl) FindAllPaged(ctx context.Context, attrs map[string]interface{}, limit uint, offset uint64) (services.Page, error) {
+	var resultList []Issue
+	return this.svcImpl.FindAllPaged(ctx, &resultList, attrs, limit, offset)
+}
+
+func (this issueServiceImpl) FindAllByCriteria(ctx context.Context, c query.Criteria, opts ...query.Option) (interface{}, error) {
+	var resultList []Issue
+	err := this.repo.FindAllByCriteria(ctx, &resultList, c, opts...)
+	return resultList, err
+}
+
+func (this issueServiceImpl) FindAllByCriteriaPaged(ctx context.Context, c query.Criteria, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
+	var resultList []Issue
+	return this.svcImpl.FindAllByCriteriaPaged(ctx, &resultList, c, limit, offset, opts...)
+}
+
+func (this issueServiceImpl) FindAllByCursor(ctx context.Context, c query.Criteria, after query.Cursor, limit uint, opts ...query.Option) (services.CursorPage, error) {
+	var resultList []Issue
+	return this.svcImpl.FindAllByCursor(ctx, &resultList, c, after, limit, opts...)
+}
+
+// Create assigns the next key of the project to the new issue, in the same transaction the issue is saved.
+// The issue starts in the initial status of the workflow of the project, and the reporter is the actor of the context unless it is given.
+func (this issueServiceImpl) Create(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
+	var created interface{}
+	err := this.svcImpl.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = this.svcImpl.Create(ctx, this.keyBinder(ctx, binding), issueTypeName, NewIssue())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// keyBinder binds the input with the given binding, then fills the fields that are not given by the input.
+func (this issueServiceImpl) keyBinder(ctx context.Context, binding services.ObjectBinder) services.ObjectBinder {
+	return services.ObjectBinderFunc(func(target interface{}) error {
+		if err := binding.BindTo(target); err != nil {
+			return err
+		}
+
+		issue, ok := target.(*Issue)
+		if !ok {
+			return nil
+		}
+		if actor, found := commons.ActorFromContext(ctx); found && issue.Reporter == "" {
+			issue.Reporter = actor
+		}
+		// an issue without project is left to validation
+		if issue.Project == 0 {
+			return nil
+		}
+
+		number, key, err := this.repo.NextKey(ctx, issue.Project)
+		if err != nil {
+			return err
+		}
+		issue.Number = number
+		issue.Key = key
+
+		wf, err := this.workflowOf(ctx, issue.Project)
+		if err != nil {
+			return err
+		}
+		initial, err := this.statusByName(ctx, wf.Initial)
+		if err != nil {
+			return err
+		}
+		if issue.Status != 0 && issue.Status != initial.Id {
+			return fmt.Errorf("validation: status of a new issue must be %s", wf.Initial)
+		}
+		issue.Status = initial.Id
+		return nil
+	})
+}
+
+func (this issueServiceImpl) Update(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
+	return this.svcImpl.Update(ctx, id, fixedFields.Binder(binding), issueTypeName, &Issue{})
+}
+
+func (this issueServiceImpl) Patch(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+	return this.svcImpl.Patch(ctx, id, fixedFields.Patch(patch), issueTypeName, &Issue{})
+}
+
+func (this issueServiceImpl) Delete(ctx context.Context, id uint64) error {
+	return this.svcImpl.Delete(ctx, id)
+}
+
+func (this issueServiceImpl) DeleteVersion(ctx context.Context, id uint64, version uint32) error {
+	return this.svcImpl.DeleteVersion(ctx, id, version)
+}
+
+func (this issueServiceImpl) Restore(ctx context.Context, id uint64) error {
+	return this.svcImpl.Restore(ctx, id)
+}
+
+func (this issueServiceImpl) Transitions(ctx context.Context, ref string) (interface{}, error) {
+	var issue Issue
+	if err := this.findByRef(ctx, &issue, ref); err != nil {
+		return nil, err
+	}
+
+	wf, status, err := this.workflowState(ctx, &issue)
+	if err != nil {
+		return nil, err
+	}
+	return wf.Available(status.Name), nil
+}
+
+// Transition moves the issue in a transaction, after the guards of the transition pass.
+// The actions of the transition are applied to the issue before it is saved.
+func (this issueServiceImpl) Transition(ctx context.Context, ref string, name string) (interface{}, error) {
+	var issue Issue
+	err := this.svcImpl.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+		if err := this.findByRef(ctx, &issue, ref); err != nil {
+			return err
+		}
+		snapshot := domain.Snapshot(&issue)
+
+		wf, status, err := this.workflowState(ctx, &issue)
+		if err != nil {
+			return err
+		}
+		t, err := wf.Transition(status.Name, name)
+		if err != nil {
+			return err
+		}
+		if err := t.Check(&issue); err != nil {
+			return err
+		}
+
+		target, err := this.statusByName(ctx, t.To)
+		if err != nil {
+			return err
+		}
+		issue.Status = target.Id
+		if err := t.Apply(&issue); err != nil {
+			return err
+		}
+
+		return this.repo.SaveChanges(ctx, &issue, snapshot)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cache := this.svcImpl.Cache(); cache != nil {
+		cache.Invalidate(caching.IdToKey(issue.Id))
+	}
+	return &issue, nil
+}
+
+// findByRef loads the issue by its id or by its key.
+func (this issueServiceImpl) findByRef(ctx context.Context, dest *Issue, ref string) error {
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return this.repo.FindOneById(ctx, dest, id)
+	}
+	return this.repo.FindOneByAttribute(ctx, dest, "Key", ref)
+}
+
+// workflowState returns the workflow of the issue with its current status.
+func (this issueServiceImpl) workflowState(ctx context.Context, issue *Issue) (*workflow.Workflow, IssueStatus, error) {
+	var status IssueStatus
+	wf, err := this.workflowOf(ctx, issue.Project)
+	if err != nil {
+		return nil, status, err
+	}
+	err = this.statuses.FindOneById(ctx, &status, issue.Status)
+	return wf, status, err
+}
+
+func (this issueServiceImpl) workflowOf(ctx context.Context, projectId uint64) (*workflow.Workflow, error) {
+	name, err := this.repo.WorkflowOf(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	return workflow.ByName(name)
+}
+
+// statusByName finds the status a state of a workflow refers to.
+func (this issueServiceImpl) statusByName(ctx context.Context, name string) (IssueStatus, error) {
+	var status IssueStatus
+	err := this.statuses.FindOneByAttribute(ctx, &status, "Name", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status, fmt.Errorf("issue status %s of the workflow does not exist", name)
+	}
+	return status, err
+}
+
+// fixedValues gets the values of the fixed fields of the issue in their json form.
+func fixedValues(target interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	if issue, ok := target.(*Issue); ok {
+		values["project"] = float64(issue.Project)
+		values["number"] = float64(issue.Number)
+		values["key"] = issue.Key
+		values["status"] = float64(issue.Status)
+		if issue.Resolution != nil {
+			values["resolution"] = *issue.Resolution
+		}
+	}
+	return values
+}
@@ -0,0 +1,338 @@
+package issue
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cpekyaman/goits/framework/caching"
+	"github.com/cpekyaman/goits/framework/commons"
+	"github.com/cpekyaman/goits/framework/patching"
+	"github.com/cpekyaman/goits/framework/services"
+	"github.com/cpekyaman/goits/framework/testlib"
+	"github.com/cpekyaman/goits/framework/testlib/mocking"
+	"github.com/cpekyaman/goits/framework/validation"
+	"github.com/cpekyaman/goits/framework/workflow"
+)
+
+var st *testlib.ServiceTest
+
+func init() {
+	st = testlib.NewServiceTest(issueED).
+		WithDbMetaData(testlib.DBMetaData{Columns: []string{"id", "issue_key", "project", "number", "summary"}}).
+		WithFactory(func(c caching.Cache, vp validation.ValidationProvider) interface{} {
+			return newIssueService(newIssueRepository(), c, vp)
+		})
+}
+
+func TestSVC_Issue_GetAll_Success(t *testing.T) {
+	context := testlib.NewTestContext().
+		WithRowMock(func(r *sqlmock.Rows) {
+			r.AddRow(1, "GOITS-1", 1, 1, "First issue")
+			r.AddRow(2, "GOITS-2", 1, 2, "Second issue")
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			issues, ok := result.RawResult.([]Issue)
+
+			assert.True(t, ok, "not an issue slice")
+			assert.Equal(t, 2, len(issues), "number of elements is not correct")
+			assert.Equal(t, "GOITS-1", issues[0].Key, "first object does not have correct key")
+			assert.Equal(t, "GOITS-2", issues[1].Key, "second object does not have correct key")
+		})
+
+	st.GetAll_Success(t, context)
+}
+
+func TestSVC_Issue_GetById_Error(t *testing.T) {
+	st.GetById_Cached_Error(t)
+}
+
+func TestSVC_Issue_Create_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	vp := mocking.NewMockValidationProvider(ctrl)
+	svc := newIssueService(newIssueRepository(), mocking.NewMockCache(ctrl), vp)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("select project_key from data.project where id = .*").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"project_key"}).AddRow("GOITS"))
+	mock.ExpectQuery("insert into data.issue_counter(.*) on conflict (.*) returning last_number").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"last_number"}).AddRow(7))
+	expectWorkflow(mock, 3)
+	expectStatusByName(mock, 3, "Open")
+	vp.EXPECT().ValidateStruct(gomock.Eq(issueTypeName), gomock.Any()).Return(nil)
+	mocking.NewQueryMocker(issueED).ExpectInsert(mock, 11)
+	mock.ExpectCommit()
+
+	ctx := commons.WithActor(context.Background(), "reporter")
+
+	// when
+	result, err := svc.Create(ctx, services.ObjectBinderFunc(defaultBinder))
+
+	// then
+	assert.Nil(t, err, "create should be successfull")
+	issue, ok := result.(*Issue)
+	assert.True(t, ok, "created issue should be returned")
+	assert.Equal(t, uint64(11), issue.Id, "generated id should be set")
+	assert.Equal(t, uint64(7), issue.Number, "next number of the project should be assigned")
+	assert.Equal(t, "GOITS-7", issue.Key, "key should be built from project key and number")
+	assert.Equal(t, "reporter", issue.Reporter, "actor should be the reporter")
+	assert.Equal(t, uint64(3), issue.Status, "initial status of the workflow should be assigned")
+	assert.Nil(t, mock.ExpectationsWereMet(), "key should be assigned in the same transaction")
+}
+
+func TestSVC_Issue_Create_UnknownProject_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newIssueService(newIssueRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("select project_key from data.project where id = .*").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"project_key"}))
+	mock.ExpectRollback()
+
+	// when
+	_, err := svc.Create(context.Background(), services.ObjectBinderFunc(defaultBinder))
+
+	// then
+	assert.NotNil(t, err, "create should fail")
+	assert.True(t, strings.HasPrefix(err.Error(), "validation:"), "should be a validation error")
+	assert.Nil(t, mock.ExpectationsWereMet(), "transaction should be rolled back")
+}
+
+func TestSVC_Issue_Update_Success(t *testing.T) {
+	id := uint64(1)
+
+	tc := testlib.NewTestContext().
+		WithValue(&Issue{}).
+		WithBinder(func(target interface{}) error {
+			issue, ok := target.(*Issue)
+			if !ok {
+				return nil
+			}
+			issue.Summary = "changed"
+			return nil
+		}).
+		WithRowMock(func(r *sqlmock.Rows) {
+			r.AddRow(id, "GOITS-1", 1, 1, "First issue")
+		}).
+		WithExecMock(func(exec *sqlmock.ExpectedQuery) {
+			args := []driver.Value{"changed", 1, 0}
+			exec.WithArgs(args...)
+		})
+
+	st.Update_Success(t, tc)
+}
+
+func TestSVC_Issue_Update_Key_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newIssueService(newIssueRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	id := uint64(1)
+	mock.ExpectBegin()
+	_, rows := st.MockFindOneWithRows(id, mock)
+	rows.AddRow(id, "GOITS-1", 1, 1, "First issue")
+	mock.ExpectRollback()
+
+	// when
+	_, err := svc.Update(context.Background(), id, services.ObjectBinderFunc(defaultBinder))
+
+	// then
+	assert.NotNil(t, err, "update should fail")
+	assert.True(t, strings.HasPrefix(err.Error(), "validation:"), "should be a validation error")
+}
+
+func TestSVC_Issue_Patch_Success(t *testing.T) {
+	id := uint64(1)
+	patch, err := patching.NewMergePatch([]byte(`{"summary":"patched"}`))
+	assert.Nil(t, err, "could not create patch")
+
+	tc := testlib.NewTestContext().
+		WithValue(&Issue{}).
+		WithRowMock(func(r *sqlmock.Rows) {
+			r.AddRow(id, "GOITS-1", 1, 1, "First issue")
+		}).
+		WithExecMock(func(exec *sqlmock.ExpectedQuery) {
+			args := []driver.Value{"patched", 1, 0}
+			exec.WithArgs(args...)
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			issue, ok := result.RawResult.(*Issue)
+
+			assert.True(t, ok, "not an issue entity")
+			assert.Equal(t, "patched", issue.Summary, "summary is not patched")
+			assert.Equal(t, "GOITS-1", issue.Key, "key should not change")
+		})
+
+	st.Patch_Success(t, tc, patch)
+}
+
+func TestSVC_Issue_Patch_Project_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newIssueService(newIssueRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	patch, err := patching.NewMergePatch([]byte(`{"project":2}`))
+	assert.Nil(t, err, "could not create patch")
+
+	id := uint64(1)
+	mock.ExpectBegin()
+	_, rows := st.MockFindOneWithRows(id, mock)
+	rows.AddRow(id, "GOITS-1", 1, 1, "First issue")
+	mock.ExpectRollback()
+
+	// when
+	_, err = svc.Patch(context.Background(), id, patch)
+
+	// then
+	assert.NotNil(t, err, "patch should fail")
+	assert.True(t, strings.HasPrefix(err.Error(), "validation:"), "should be a validation error")
+}
+
+func TestSVC_Issue_Patch_Status_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newIssueService(newIssueRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	patch, err := patching.NewMergePatch([]byte(`{"status":4}`))
+	assert.Nil(t, err, "could not create patch")
+
+	id := uint64(1)
+	mock.ExpectBegin()
+	_, rows := st.MockFindOneWithRows(id, mock)
+	rows.AddRow(id, "GOITS-1", 1, 1, "First issue")
+	mock.ExpectRollback()
+
+	// when
+	_, err = svc.Patch(context.Background(), id, patch)
+
+	// then
+	assert.NotNil(t, err, "status should only be changed by transitions")
+	assert.True(t, strings.HasPrefix(err.Error(), "validation:"), "should be a validation error")
+}
+
+func TestSVC_Issue_Transition_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	c := mocking.NewMockCache(ctrl)
+	svc := newIssueService(newIssueRepository(), c, mocking.NewMockValidationProvider(ctrl))
+
+	updated := NewIssue()
+	updated.SetId(1)
+
+	mock.ExpectBegin()
+	expectIssueByKey(mock, "GOITS-1", 1)
+	expectWorkflow(mock, 1)
+	expectStatusById(mock, 1, "Open")
+	expectStatusByName(mock, 3, "Resolved")
+	mocking.NewQueryMocker(issueED).ExpectUpdate(mock, updated).
+		WithArgs("Fixed", 3, 1, 0)
+	mock.ExpectCommit()
+
+	c.EXPECT().Invalidate(gomock.Eq(caching.IdToKey(1)))
+
+	// when
+	result, err := svc.Transition(context.Background(), "GOITS-1", "resolve")
+
+	// then
+	assert.Nil(t, err, "transition should be successfull")
+	issue, ok := result.(*Issue)
+	assert.True(t, ok, "moved issue should be returned")
+	assert.Equal(t, uint64(3), issue.Status, "issue should be moved to target status")
+	assert.Equal(t, "Fixed", *issue.Resolution, "actions of transition should be applied")
+}
+
+func TestSVC_Issue_Transition_Guard_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newIssueService(newIssueRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	mock.ExpectBegin()
+	expectIssueByKey(mock, "GOITS-1", 1)
+	expectWorkflow(mock, 1)
+	expectStatusById(mock, 1, "Open")
+	mock.ExpectRollback()
+
+	// when
+	_, err := svc.Transition(context.Background(), "GOITS-1", "start")
+
+	// then
+	assert.NotNil(t, err, "transition should fail without assignee")
+	assert.True(t, strings.HasPrefix(err.Error(), "validation:"), "should be a validation error")
+	assert.Nil(t, mock.ExpectationsWereMet(), "transaction should be rolled back")
+}
+
+func TestSVC_Issue_Transitions_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	mock := st.NewMockDB(t)
+	svc := newIssueService(newIssueRepository(), mocking.NewMockCache(ctrl), mocking.NewMockValidationProvider(ctrl))
+
+	expectIssueByKey(mock, "GOITS-1", 3)
+	expectWorkflow(mock, 1)
+	expectStatusById(mock, 3, "Resolved")
+
+	// when
+	result, err := svc.Transitions(context.Background(), "GOITS-1")
+
+	// then
+	assert.Nil(t, err, "transitions should be returned")
+	transitions, ok := result.([]workflow.Transition)
+	assert.True(t, ok, "not a transition slice")
+	assert.Equal(t, 2, len(transitions), "close and reopen should be available")
+}
+
+func expectIssueByKey(mock sqlmock.Sqlmock, key string, status uint64) {
+	mocking.NewQueryMocker(issueED).ExpectFindOneByAttr(mock, "Key").
+		WithArgs(key).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "issue_key", "project", "status", "version"}).AddRow(1, key, 1, status, 0))
+}
+
+func expectWorkflow(mock sqlmock.Sqlmock, projectId uint64) {
+	mock.ExpectQuery("select pt.workflow from data.project p join config.project_type pt .*").
+		WithArgs(projectId).
+		WillReturnRows(sqlmock.NewRows([]string{"workflow"}).AddRow("default"))
+}
+
+func expectStatusById(mock sqlmock.Sqlmock, id uint64, name string) {
+	mocking.NewQueryMocker(issueStatusED).ExpectFindOne(mock).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(id, name))
+}
+
+func expectStatusByName(mock sqlmock.Sqlmock, id uint64, name string) {
+	mocking.NewQueryMocker(issueStatusED).ExpectFindOneByAttr(mock, "Name").
+		WithArgs(name).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(id, name))
+}
+
+func defaultBinder(target interface{}) error {
+	issue, ok := target.(*Issue)
+	if !ok {
+		return nil
+	}
+	issue.Project = 3
+	issue.Key = "OTHER-1"
+	issue.Summary = "demo issue"
+	issue.Type = 1
+	issue.Priority = 2
+	issue.Status = 3
+
+	return nil
+}
@@ -0,0 +1,138 @@
+//go:generate mockgen -source=repository.go -destination=repository_mock.go -package=issue
+package issue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/seeding"
+	"github.com/jmoiron/sqlx"
+)
+
+var issueTypeED metadata.EntityDef
+var issuePriorityED metadata.EntityDef
+var issueStatusED metadata.EntityDef
+var issueED metadata.EntityDef
+var projectED metadata.EntityDef
+var projectTypeED metadata.EntityDef
+
+func init() {
+	domain.RegisterEntityConfig("issue")
+
+	issueTypeED = domain.EntityDefByName(issueTypeTypeName)
+	issuePriorityED = domain.EntityDefByName(issuePriorityTypeName)
+	issueStatusED = domain.EntityDefByName(issueStatusTypeName)
+	issueED = domain.EntityDefByName(issueTypeName)
+	projectED = domain.EntityDefByName(projectTypeName)
+	projectTypeED = domain.EntityDefByName(projectTypeTypeName)
+
+	domain.RegisterEntityType(issueTypeTypeName, &IssueType{})
+	domain.RegisterEntityType(issuePriorityTypeName, &IssuePriority{})
+	domain.RegisterEntityType(issueStatusTypeName, &IssueStatus{})
+	domain.RegisterEntityType(issueTypeName, &Issue{})
+
+	seeding.Register(issueTypeTypeName, func() seeding.Upserter { return newIssueTypeRepository() })
+	seeding.Register(issuePriorityTypeName, func() seeding.Upserter { return newIssuePriorityRepository() })
+	seeding.Register(issueStatusTypeName, func() seeding.Upserter { return newIssueStatusRepository() })
+	seeding.Register(issueTypeName, func() seeding.Upserter { return newIssueRepository() })
+}
+
+type IssueRepository interface {
+	repository.Repository
+
+	// NextKey reserves the next number of the issues of the project and returns it with the issue key built from it.
+	// The number is only reserved if the transaction of ctx, if there is any, is committed.
+	NextKey(ctx context.Context, projectId uint64) (uint64, string, error)
+
+	// WorkflowOf returns the name of the workflow of the type of the project.
+	WorkflowOf(ctx context.Context, projectId uint64) (string, error)
+}
+
+type issueSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newIssueRepository() IssueRepository {
+	return issueSqlRepository{repository.NewRepository(issueED, &Issue{})}
+}
+
+func (this issueSqlRepository) NextKey(ctx context.Context, projectId uint64) (uint64, string, error) {
+	d := dialect.Current()
+	ext := this.Executor(ctx)
+
+	var projectKey string
+	q := fmt.Sprintf("select project_key from %s where id = %s", d.Table(projectED.Schema(), projectED.Table()), d.Placeholder(1))
+	if err := sqlx.GetContext(ctx, ext, &projectKey, q, projectId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", fmt.Errorf("validation: project %d does not exist", projectId)
+		}
+		return 0, "", err
+	}
+
+	// the counter row is locked by the update until the transaction ends, so concurrent issues of a project get distinct numbers
+	var number uint64
+	q = fmt.Sprintf("insert into %s(project, last_number) values(%s, 1) on conflict (project) do update set last_number = issue_counter.last_number + 1 %s",
+		d.Table("data", "issue_counter"), d.Placeholder(1), d.Returning([]string{"last_number"}))
+	if err := sqlx.GetContext(ctx, ext, &number, q, projectId); err != nil {
+		return 0, "", err
+	}
+
+	return number, fmt.Sprintf("%s-%d", projectKey, number), nil
+}
+
+func (this issueSqlRepository) WorkflowOf(ctx context.Context, projectId uint64) (string, error) {
+	d := dialect.Current()
+
+	var workflow string
+	q := fmt.Sprintf("select pt.workflow from %s p join %s pt on pt.id = p.type where p.id = %s",
+		d.Table(projectED.Schema(), projectED.Table()), d.Table(projectTypeED.Schema(), projectTypeED.Table()), d.Placeholder(1))
+	if err := sqlx.GetContext(ctx, this.Executor(ctx), &workflow, q, projectId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("validation: project %d does not exist", projectId)
+		}
+		return "", err
+	}
+	return workflow, nil
+}
+
+type IssueTypeRepository interface {
+	repository.Repository
+}
+
+type issueTypeSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newIssueTypeRepository() IssueTypeRepository {
+	return issueTypeSqlRepository{repository.NewRepository(issueTypeED, &IssueType{})}
+}
+
+type IssuePriorityRepository interface {
+	repository.Repository
+}
+
+type issuePrioritySqlRepository struct {
+	repository.SqlRepository
+}
+
+func newIssuePriorityRepository() IssuePriorityRepository {
+	return issuePrioritySqlRepository{repository.NewRepository(issuePriorityED, &IssuePriority{})}
+}
+
+type IssueStatusRepository interface {
+	repository.Repository
+}
+
+type issueStatusSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newIssueStatusRepository() IssueStatusRepository {
+	return issueStatusSqlRepository{repository.NewRepository(issueStatusED, &IssueStatus{})}
+}
@@ -0,0 +1,47 @@
+package issue
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cpekyaman/goits/framework/routing"
+)
+
+type issueResource struct {
+	svc IssueService
+	routing.ApiResource
+}
+
+var issueAPI issueResource
+
+func InitIssue() {
+	initDomain()
+
+	issueAPI = newIssueResource(newDefaultIssueService())
+	issueAPI.Register()
+}
+
+// newIssueResource creates the resource of issues, which are reached by their keys as /issue/{key}
+// and under their projects as /project/{id}/issue. Status of an issue is changed by POST /issue/{key}/transitions/{name}.
+// Issues can be read anonymously but only changed by authenticated users.
+func newIssueResource(svc IssueService) issueResource {
+	res := issueResource{
+		svc,
+		routing.NewApiResource("Issue", "issue", svc).
+			WithEntity(issueED).
+			WithKey("Key").
+			WithParent("project", "Project").
+			WithAction(http.MethodGet, "/transitions", "getTransitions",
+				func(ctx context.Context, ref string, param func(string) string) (interface{}, error) {
+					return svc.Transitions(ctx, ref)
+				}).
+			WithAction(http.MethodPost, "/transitions/{name}", "transition",
+				func(ctx context.Context, ref string, param func(string) string) (interface{}, error) {
+					return svc.Transition(ctx, ref, param("name"))
+				}).
+			WithAuthenticated().
+			WithPublic("getAll", "getById", "getAllOfParent", "getTransitions"),
+	}
+
+	return res
+}
@@ -0,0 +1,35 @@
+package issue
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cpekyaman/goits/framework/workflow"
+)
+
+// guards and actions that issue workflows can use in their transitions
+func init() {
+	workflow.RegisterGuard("assigneeRequired", func(subject interface{}, params map[string]string) bool {
+		issue, ok := subject.(*Issue)
+		return ok && issue.Assignee != nil && strings.TrimSpace(*issue.Assignee) != ""
+	})
+
+	workflow.RegisterAction("setResolution", func(subject interface{}, params map[string]string) error {
+		issue, ok := subject.(*Issue)
+		if !ok {
+			return fmt.Errorf("setResolution can not be applied to %T", subject)
+		}
+		resolution := params["resolution"]
+		issue.Resolution = &resolution
+		return nil
+	})
+
+	workflow.RegisterAction("clearResolution", func(subject interface{}, params map[string]string) error {
+		issue, ok := subject.(*Issue)
+		if !ok {
+			return fmt.Errorf("clearResolution can not be applied to %T", subject)
+		}
+		issue.Resolution = nil
+		return nil
+	})
+}
@@ -19,6 +19,8 @@ type ProjectType struct {
 	domain.VersionedEntity
 	Name        string `json:"name" db:"name"`
 	Description string `json:"desc" db:"description"`
+	// Workflow is the name of the workflow the issues of the projects of this type follow
+	Workflow string `json:"workflow" db:"workflow"`
 }
 
 type ProjectStatus struct {
@@ -29,10 +31,15 @@ type ProjectStatus struct {
 
 type Project struct {
 	domain.VersionedTimeStampedEntity
+	Key         string `json:"key" db:"project_key"`
 	Name        string `json:"name" db:"name"`
 	Description string `json:"desc" db:"description"`
 	Type        uint64 `json:"type" db:"type"`
 	Status      uint64 `json:"status" db:"status"`
+
+	// relations which are only loaded when included
+	TypeRef   *ProjectType   `json:"typeRef,omitempty" db:"-"`
+	StatusRef *ProjectStatus `json:"statusRef,omitempty" db:"-"`
 }
 
 func registerProjectValidations() {
@@ -40,10 +47,11 @@ func registerProjectValidations() {
 
 	sv.WithMandatoryName().
 		WithMandatoryDesc().
+		Field("key").With(validation.Pattern(validation.PatternAlNum), validation.StrLen(2, 10)).
 		Field("type").With(validation.ValidId()).
 		Field("status").With(validation.ValidId())
 }
 
 func NewProject() *Project {
-	return &Project{domain.VersionedTimeStampedEntity{}, "", "", 0, 0}
+	return &Project{VersionedTimeStampedEntity: domain.VersionedTimeStampedEntity{}}
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cpekyaman/goits/framework/orm/query"
 	"github.com/cpekyaman/goits/framework/orm/repository"
 	"github.com/cpekyaman/goits/framework/testlib"
 	"github.com/stretchr/testify/assert"
@@ -70,6 +71,43 @@ func TestRepo_Project_FindById_Error(t *testing.T) {
 	readerTest.FindById_Error(t, context)
 }
 
+func TestRepo_Project_FindById_IncludeType(t *testing.T) {
+	// the related project types are queried through their own repository metadata
+	newProjectTypeRepository()
+
+	includeTest := testlib.NewReaderRepositoryTest(projectED).
+		WithDbMetaData(testlib.DBMetaData{Columns: []string{"id", "name", "type"}}).
+		WithInstanceFactory(func() repository.ReaderRepository { return newProjectRepository() })
+
+	context := testlib.NewTestContext().
+		WithValue(&Project{}).
+		WithRowMock(func(r *sqlmock.Rows) {
+			r.AddRow(1, "test project", 3)
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			prj, ok := result.RawResult.(*Project)
+			assert.True(t, ok, "not a project")
+
+			assert.NotNil(t, prj.TypeRef, "type is not loaded")
+			assert.Equal(t, uint64(3), prj.TypeRef.Id, "type id is not correct")
+			assert.Equal(t, "Internal", prj.TypeRef.Name, "type name is not correct")
+			assert.Nil(t, prj.StatusRef, "status is not included")
+		})
+
+	includeTest.FindById_Include_DataFound(t, context, "type", testlib.RelationMock{
+		Target:   projectTypeED,
+		Criteria: query.In("Id", uint64(3)),
+		Columns:  []string{"id", "name"},
+		RowMocker: func(rows *sqlmock.Rows) {
+			rows.AddRow(3, "Internal")
+		},
+	})
+}
+
+func TestRepo_Project_FindById_IncludeUnknown(t *testing.T) {
+	readerTest.FindById_Include_UnknownRelation(t, &Project{}, "Owner")
+}
+
 func TestRepo_Project_FindByAttribute_DataFound(t *testing.T) {
 	id := uint64(2)
 	name := "Demo"
@@ -157,6 +195,90 @@ func TestRepo_Project_FindAll_Error(t *testing.T) {
 	readerTest.FindAll_Error(t, context)
 }
 
+func TestRepo_Project_FindAllByAttributes_DataFound(t *testing.T) {
+	context := testlib.NewTestContext().
+		WithValue(&[]Project{}).
+		WithRowMock(func(rows *sqlmock.Rows) {
+			rows.AddRow(1, "first project")
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			pp, ok := result.RawResult.(*[]Project)
+			assert.True(t, ok, "not a project slice")
+			assert.Equal(t, 1, len(*pp), "not all rows are returned")
+		})
+
+	readerTest.FindAllByAttributes_DataFound(t, context, map[string]interface{}{"Status": 1, "Type": 2})
+}
+
+func TestRepo_Project_FindAllByCriteria_DataFound(t *testing.T) {
+	context := testlib.NewTestContext().
+		WithValue(&[]Project{}).
+		WithRowMock(func(rows *sqlmock.Rows) {
+			rows.AddRow(1, "first project")
+			rows.AddRow(2, "second project")
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			pp, ok := result.RawResult.(*[]Project)
+			assert.True(t, ok, "not a project slice")
+			assert.Equal(t, 2, len(*pp), "not all rows are returned")
+		})
+
+	c := query.And(query.In("Status", 1, 2), query.Or(query.ILike("Name", "%project"), query.IsNull("Description")))
+	readerTest.FindAllByCriteria_DataFound(t, context, c)
+}
+
+func TestRepo_Project_FindAllByCriteria_UnknownField(t *testing.T) {
+	readerTest.FindAllByCriteria_UnknownField(t, &[]Project{}, query.Eq("Owner", "someone"))
+}
+
+func TestRepo_Project_FindAllByCursor_HasNext(t *testing.T) {
+	context := testlib.NewTestContext().
+		WithValue(&[]Project{}).
+		WithRowMock(func(rows *sqlmock.Rows) {
+			rows.AddRow(1, "a")
+			rows.AddRow(2, "b")
+			rows.AddRow(3, "c")
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			pp, ok := result.RawResult.(*[]Project)
+			assert.True(t, ok, "not a project slice")
+			assert.Equal(t, 2, len(*pp), "extra row should be dropped")
+		})
+
+	next := query.Cursor{
+		Sort:   []query.SortField{query.Asc("Name"), query.Asc("Id")},
+		Values: []interface{}{"b", uint64(2)},
+	}
+	readerTest.FindAllByCursor_DataFound(t, context, query.Cursor{}, 2, next)
+}
+
+func TestRepo_Project_FindAllByCursor_LastPage(t *testing.T) {
+	context := testlib.NewTestContext().
+		WithValue(&[]Project{}).
+		WithRowMock(func(rows *sqlmock.Rows) {
+			rows.AddRow(3, "c")
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			pp, ok := result.RawResult.(*[]Project)
+			assert.True(t, ok, "not a project slice")
+			assert.Equal(t, 1, len(*pp), "not all rows are returned")
+		})
+
+	after := query.Cursor{
+		Sort:   []query.SortField{query.Asc("Name"), query.Asc("Id")},
+		Values: []interface{}{"b", uint64(2)},
+	}
+	readerTest.FindAllByCursor_DataFound(t, context, after, 2, query.Cursor{})
+}
+
+func TestRepo_Project_CountByCriteria_Success(t *testing.T) {
+	readerTest.CountByCriteria_Success(t, query.Eq("Status", 1), 42)
+}
+
+func TestRepo_Project_Count_All_Success(t *testing.T) {
+	readerTest.CountByCriteria_Success(t, nil, 7)
+}
+
 func TestRepo_Project_Create_Error(t *testing.T) {
 	context := testlib.NewTestContext().
 		WithValue(newDummyProject())
@@ -189,6 +311,53 @@ func TestRepo_Project_Update_Success(t *testing.T) {
 	writerTest.Update_Success(t, context)
 }
 
+func TestRepo_Project_Update_StaleVersion_Conflict(t *testing.T) {
+	prj := newDummyProject()
+	prj.Id = uint64(100)
+	prj.Version = 2
+
+	context := testlib.NewTestContext().WithValue(prj)
+
+	writerTest.Update_StaleVersion_Conflict(t, context, 3)
+}
+
+func TestRepo_Project_Update_Missing_NotFound(t *testing.T) {
+	prj := newDummyProject()
+	prj.Id = uint64(100)
+
+	context := testlib.NewTestContext().WithValue(prj)
+
+	writerTest.Update_Missing_NotFound(t, context)
+}
+
+func TestRepo_Project_UpdateChanges_Success(t *testing.T) {
+	prj := newDummyProject()
+	prj.Id = uint64(100)
+	prj.Version = 2
+	snapshot := *prj
+
+	prj.Description = "Changed Dummy Project"
+	prj.Status = 3
+
+	context := testlib.NewTestContext().
+		WithValue(prj).
+		WithExecMock(func(exec *sqlmock.ExpectedQuery) {
+			exec.WithArgs("Changed Dummy Project", 3, 100, 2)
+		})
+
+	writerTest.UpdateChanges_Success(t, context, snapshot)
+}
+
+func TestRepo_Project_UpdateChanges_NoChange_Conflict(t *testing.T) {
+	prj := newDummyProject()
+	prj.Id = uint64(100)
+	prj.Version = 2
+
+	context := testlib.NewTestContext().WithValue(prj)
+
+	writerTest.UpdateChanges_NoChange_Conflict(t, context, 3)
+}
+
 func newDummyProject() *Project {
 	prj := NewProject()
 	prj.Name = "Dummy"
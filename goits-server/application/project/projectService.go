@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/cpekyaman/goits/framework/caching"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/cpekyaman/goits/framework/patching"
 	"github.com/cpekyaman/goits/framework/services"
 	"github.com/cpekyaman/goits/framework/validation"
 )
@@ -25,26 +27,19 @@ func newProjectService(pr ProjectRepository, c caching.Cache, vp validation.Vali
 	return projectServiceImpl{pr, services.NewCRUDService(pr, c, vp)}
 }
 
-func (this projectServiceImpl) GetAll(ctx context.Context) (interface{}, error) {
+func (this projectServiceImpl) GetAll(ctx context.Context, opts ...query.Option) (interface{}, error) {
 	var resultList []Project
-	err := this.repo.FindAll(ctx, &resultList)
+	err := this.repo.FindAll(ctx, &resultList, opts...)
 	return resultList, err
 }
 
-func (this projectServiceImpl) GetAllPaged(ctx context.Context, limit uint, offset uint64) (interface{}, error) {
+func (this projectServiceImpl) GetAllPaged(ctx context.Context, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
 	var resultList []Project
-	err := this.repo.FindAllPaged(ctx, &resultList, limit, offset)
-	return resultList, err
+	return this.svcImpl.GetAllPaged(ctx, &resultList, limit, offset, opts...)
 }
 
-func (this projectServiceImpl) GetById(ctx context.Context, id uint64) (interface{}, error) {
-	result, err := this.svcImpl.Cache().GetOrCompute(caching.IdToKey(id), func() (interface{}, error) {
-		var result Project
-		err := this.repo.FindOneById(ctx, &result, id)
-		return &result, err
-	})
-
-	return result, err
+func (this projectServiceImpl) GetById(ctx context.Context, id uint64, opts ...query.Option) (interface{}, error) {
+	return this.svcImpl.GetById(ctx, &Project{}, id, opts...)
 }
 
 func (this projectServiceImpl) FindOne(ctx context.Context, attr string, attrValue interface{}) (interface{}, error) {
@@ -59,20 +54,47 @@ func (this projectServiceImpl) FindAll(ctx context.Context, attrs map[string]int
 	return resultList, err
 }
 
-func (this projectServiceImpl) FindAllPaged(ctx context.Context, attrs map[string]interface{}, limit uint, offset uint64) (interface{}, error) {
+func (this projectServiceImpl) FindAllPaged(ctx context.Context, attrs map[string]interface{}, limit uint, offset uint64) (services.Page, error) {
 	var resultList []Project
-	err := this.repo.FindAllByAttributesPaged(ctx, &resultList, attrs, limit, offset)
+	return this.svcImpl.FindAllPaged(ctx, &resultList, attrs, limit, offset)
+}
+
+func (this projectServiceImpl) FindAllByCriteria(ctx context.Context, c query.Criteria, opts ...query.Option) (interface{}, error) {
+	var resultList []Project
+	err := this.repo.FindAllByCriteria(ctx, &resultList, c, opts...)
 	return resultList, err
 }
 
-func (this projectServiceImpl) Create(ctx context.Context, binding services.ObjectBinder) error {
+func (this projectServiceImpl) FindAllByCriteriaPaged(ctx context.Context, c query.Criteria, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
+	var resultList []Project
+	return this.svcImpl.FindAllByCriteriaPaged(ctx, &resultList, c, limit, offset, opts...)
+}
+
+func (this projectServiceImpl) FindAllByCursor(ctx context.Context, c query.Criteria, after query.Cursor, limit uint, opts ...query.Option) (services.CursorPage, error) {
+	var resultList []Project
+	return this.svcImpl.FindAllByCursor(ctx, &resultList, c, after, limit, opts...)
+}
+
+func (this projectServiceImpl) Create(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
 	return this.svcImpl.Create(ctx, binding, projectTypeName, &Project{})
 }
 
-func (this projectServiceImpl) Update(ctx context.Context, id uint64, binding services.ObjectBinder) error {
+func (this projectServiceImpl) Update(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
 	return this.svcImpl.Update(ctx, id, binding, projectTypeName, &Project{})
 }
 
+func (this projectServiceImpl) Patch(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+	return this.svcImpl.Patch(ctx, id, patch, projectTypeName, &Project{})
+}
+
 func (this projectServiceImpl) Delete(ctx context.Context, id uint64) error {
 	return this.svcImpl.Delete(ctx, id)
 }
+
+func (this projectServiceImpl) DeleteVersion(ctx context.Context, id uint64, version uint32) error {
+	return this.svcImpl.DeleteVersion(ctx, id, version)
+}
+
+func (this projectServiceImpl) Restore(ctx context.Context, id uint64) error {
+	return this.svcImpl.Restore(ctx, id)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/cpekyaman/goits/framework/caching"
+	"github.com/cpekyaman/goits/framework/patching"
 	"github.com/cpekyaman/goits/framework/testlib"
 	"github.com/cpekyaman/goits/framework/validation"
 )
@@ -44,6 +45,22 @@ func TestSVC_Project_GetAll_Success(t *testing.T) {
 	st.GetAll_Success(t, context)
 }
 
+func TestSVC_Project_GetAllPaged_Success(t *testing.T) {
+	context := testlib.NewTestContext().
+		WithRowMock(func(r *sqlmock.Rows) {
+			r.AddRow(11, "Demo 11", "Demo Project Eleven")
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			projects, ok := result.RawResult.([]Project)
+
+			assert.True(t, ok, "not a project slice")
+			assert.Equal(t, 1, len(projects), "number of elements is not correct")
+			assert.Equal(t, uint64(11), projects[0].Id, "object does not have correct id")
+		})
+
+	st.GetAllPaged_Success(t, context, 10, 10, 11)
+}
+
 func TestSVC_Project_GetAll_Error(t *testing.T) {
 	st.GetAll_Error(t)
 }
@@ -88,8 +105,8 @@ func TestSVC_Project_Create_Validation_Error(t *testing.T) {
 func TestSVC_Project_Create_Success(t *testing.T) {
 	tc := testlib.NewTestContext().
 		WithBinder(defaultBinder).
-		WithExecMock(func(exec *sqlmock.ExpectedExec) {
-			args := []driver.Value{"demo project", "demo", 1, 2}
+		WithExecMock(func(exec *sqlmock.ExpectedQuery) {
+			args := []driver.Value{"demo project", "DEMO", "demo", 1, 2}
 			exec.WithArgs(args...)
 		})
 
@@ -133,6 +150,7 @@ func TestSVC_Project_Update_Db_Error(t *testing.T) {
 
 	tc := testlib.NewTestContext().
 		WithValue(&Project{}).
+		WithBinder(defaultBinder).
 		WithRowMock(func(r *sqlmock.Rows) {
 			r.AddRow(id, "test", "test project")
 		})
@@ -140,6 +158,18 @@ func TestSVC_Project_Update_Db_Error(t *testing.T) {
 	st.Update_Db_Error(t, tc)
 }
 
+func TestSVC_Project_Update_NoChange_Success(t *testing.T) {
+	id := uint64(1)
+
+	tc := testlib.NewTestContext().
+		WithValue(&Project{}).
+		WithRowMock(func(r *sqlmock.Rows) {
+			r.AddRow(id, "test", "test project")
+		})
+
+	st.Update_NoChange_Success(t, tc)
+}
+
 func TestSVC_Project_Update_Success(t *testing.T) {
 	id := uint64(1)
 
@@ -149,19 +179,60 @@ func TestSVC_Project_Update_Success(t *testing.T) {
 		WithRowMock(func(r *sqlmock.Rows) {
 			r.AddRow(id, "test", "test project")
 		}).
-		WithExecMock(func(exec *sqlmock.ExpectedExec) {
-			args := []driver.Value{"demo project", "demo", 1, 2, 1, 2}
+		WithExecMock(func(exec *sqlmock.ExpectedQuery) {
+			args := []driver.Value{"demo project", "DEMO", "demo", 1, 2, 1, 2}
 			exec.WithArgs(args...)
 		})
 
 	st.Update_Success(t, tc)
 }
 
+func TestSVC_Project_Patch_Success(t *testing.T) {
+	id := uint64(1)
+	patch, err := patching.NewMergePatch([]byte(`{"name":"patched","type":3}`))
+	assert.Nil(t, err, "could not create patch")
+
+	tc := testlib.NewTestContext().
+		WithValue(&Project{}).
+		WithRowMock(func(r *sqlmock.Rows) {
+			r.AddRow(id, "test", "test project")
+		}).
+		WithExecMock(func(exec *sqlmock.ExpectedQuery) {
+			// only changed columns are set, followed by id and version in where clause
+			args := []driver.Value{"patched", 3, 1, 0}
+			exec.WithArgs(args...)
+		}).
+		WithAsserter(func(t *testing.T, result testlib.TestResult) {
+			p, ok := result.RawResult.(*Project)
+
+			assert.True(t, ok, "not a project entity")
+			assert.Equal(t, "patched", p.Name, "name is not patched")
+			assert.Equal(t, "test project", p.Description, "description should not change")
+			assert.Equal(t, uint64(3), p.Type, "type is not patched")
+		})
+
+	st.Patch_Success(t, tc, patch)
+}
+
+func TestSVC_Project_Patch_Conflict_Error(t *testing.T) {
+	id := uint64(1)
+	patch, err := patching.NewJSONPatch([]byte(`[{"op":"test","path":"/name","value":"other"},{"op":"replace","path":"/name","value":"patched"}]`))
+	assert.Nil(t, err, "could not create patch")
+
+	tc := testlib.NewTestContext().
+		WithRowMock(func(r *sqlmock.Rows) {
+			r.AddRow(id, "test", "test project")
+		})
+
+	st.Patch_Conflict_Error(t, tc, patch)
+}
+
 func defaultBinder(target interface{}) error {
 	prj, ok := target.(*Project)
 	if !ok {
 		return nil
 	}
+	prj.Key = "DEMO"
 	prj.Name = "demo"
 	prj.Description = "demo project"
 	prj.Status = 1
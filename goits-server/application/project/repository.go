@@ -5,6 +5,7 @@ import (
 	"github.com/cpekyaman/goits/framework/orm/domain"
 	"github.com/cpekyaman/goits/framework/orm/metadata"
 	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/seeding"
 )
 
 var projectStatusED metadata.EntityDef
@@ -17,6 +18,14 @@ func init() {
 	projectStatusED = domain.EntityDefByName(projectStatusTypeName)
 	projectTypeED = domain.EntityDefByName(projectTypeTypeName)
 	projectED = domain.EntityDefByName(projectTypeName)
+
+	domain.RegisterEntityType(projectStatusTypeName, &ProjectStatus{})
+	domain.RegisterEntityType(projectTypeTypeName, &ProjectType{})
+	domain.RegisterEntityType(projectTypeName, &Project{})
+
+	seeding.Register(projectStatusTypeName, func() seeding.Upserter { return newProjectStatusRepository() })
+	seeding.Register(projectTypeTypeName, func() seeding.Upserter { return newProjectTypeRepository() })
+	seeding.Register(projectTypeName, func() seeding.Upserter { return newProjectRepository() })
 }
 
 type ProjectRepository interface {
@@ -16,10 +16,14 @@ func InitProject() {
 	projectAPI.Register()
 }
 
+// newProjectResource creates the resource of projects, which can be read anonymously but only changed by authenticated users.
 func newProjectResource(svc ProjectService) projectResource {
 	res := projectResource{
 		svc,
-		routing.NewApiResource("Project", "project", svc),
+		routing.NewApiResource("Project", "project", svc).
+			WithEntity(projectED).
+			WithAuthenticated().
+			WithPublic("getAll", "getById"),
 	}
 
 	return res
@@ -0,0 +1,221 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cpekyaman/goits/framework/caching"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/security"
+	"github.com/cpekyaman/goits/framework/services"
+	"github.com/cpekyaman/goits/framework/validation"
+)
+
+// ApiTokenPrefix starts every api token, which tells them apart from the session tokens.
+const ApiTokenPrefix = "goits_"
+
+// lastUsedInterval is how often the last use of a token is recorded, so that every request does not update the token.
+const lastUsedInterval = time.Hour
+
+var errNotAuthenticated = errors.New("unauthorized: not logged in")
+
+// ApiTokenRequest is the input of a new api token.
+// The token has the scopes of its creator if no scope is given, and it does not expire if no expiry is given.
+type ApiTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays uint     `json:"expiresInDays"`
+}
+
+// ApiTokenResult is a created api token, whose token is only given once.
+type ApiTokenResult struct {
+	Token    string    `json:"token"`
+	ApiToken *ApiToken `json:"apiToken"`
+}
+
+type ApiTokenService interface {
+	// Create creates an api token for the current user, which can not grant more scopes than the current principal has.
+	Create(ctx context.Context, req ApiTokenRequest) (ApiTokenResult, error)
+
+	// List returns the api tokens of the current user, including the revoked and the expired ones.
+	List(ctx context.Context) ([]ApiToken, error)
+
+	// Revoke makes an api token of the current user unusable.
+	Revoke(ctx context.Context, id uint64) error
+
+	// Authenticate returns the principal of the api token, with the scopes of the token.
+	Authenticate(ctx context.Context, token string) (security.Principal, error)
+
+	// Verify checks that the api token a jwt token of the principal is issued from is still usable.
+	Verify(ctx context.Context, p security.Principal) error
+}
+
+type apiTokenServiceImpl struct {
+	users   UserRepository
+	repo    ApiTokenRepository
+	svcImpl services.CRUDServiceImpl
+	now     func() time.Time
+}
+
+// NewApiTokenService creates the api token service with the default repositories.
+func NewApiTokenService() ApiTokenService {
+	initDomain()
+	return newApiTokenService(newUserRepository(), newApiTokenRepository(), validation.Provider())
+}
+
+func newApiTokenService(ur UserRepository, tr ApiTokenRepository, vp validation.ValidationProvider) apiTokenServiceImpl {
+	return apiTokenServiceImpl{ur, tr, services.NewCRUDService(tr, caching.NoOpCache(), vp), time.Now}
+}
+
+func (this apiTokenServiceImpl) Create(ctx context.Context, req ApiTokenRequest) (ApiTokenResult, error) {
+	var result ApiTokenResult
+
+	p, ok := security.PrincipalFromContext(ctx)
+	if !ok {
+		return result, errNotAuthenticated
+	}
+
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = p.Scopes
+	}
+	for _, scope := range scopes {
+		if scope != security.ScopeRead && scope != security.ScopeWrite {
+			return result, fmt.Errorf("validation: unknown scope %s", scope)
+		}
+		if !p.HasScope(scope) {
+			return result, fmt.Errorf("forbidden: can not grant %s scope", scope)
+		}
+	}
+
+	random, err := newToken()
+	if err != nil {
+		return result, err
+	}
+	token := ApiTokenPrefix + random
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := this.now().AddDate(0, 0, int(req.ExpiresInDays))
+		expiresAt = &t
+	}
+
+	created, err := this.svcImpl.Create(ctx, services.ObjectBinderFunc(func(target interface{}) error {
+		t, ok := target.(*ApiToken)
+		if !ok {
+			return nil
+		}
+		*t = ApiToken{UserId: p.UserId, Name: req.Name, TokenHash: hashToken(token), Scopes: strings.Join(scopes, ","), ExpiresAt: expiresAt}
+		return nil
+	}), tokenTypeName, &ApiToken{})
+	if err != nil {
+		return result, err
+	}
+
+	return ApiTokenResult{Token: token, ApiToken: created.(*ApiToken)}, nil
+}
+
+func (this apiTokenServiceImpl) List(ctx context.Context) ([]ApiToken, error) {
+	p, ok := security.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, errNotAuthenticated
+	}
+
+	tokens := make([]ApiToken, 0)
+	err := this.repo.FindAllByAttributes(ctx, &tokens, map[string]interface{}{"UserId": p.UserId})
+	return tokens, err
+}
+
+func (this apiTokenServiceImpl) Revoke(ctx context.Context, id uint64) error {
+	p, ok := security.PrincipalFromContext(ctx)
+	if !ok {
+		return errNotAuthenticated
+	}
+
+	return this.svcImpl.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+		var token ApiToken
+		err := this.repo.FindOneById(ctx, &token, id)
+		// tokens of the other users are not revealed
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && token.UserId != p.UserId) {
+			return fmt.Errorf("notfound: api token %d", id)
+		} else if err != nil {
+			return err
+		}
+		if token.RevokedAt != nil {
+			return nil
+		}
+
+		snapshot := domain.Snapshot(&token)
+		now := this.now()
+		token.RevokedAt = &now
+		return this.repo.SaveChanges(ctx, &token, snapshot)
+	})
+}
+
+func (this apiTokenServiceImpl) Authenticate(ctx context.Context, token string) (security.Principal, error) {
+	var p security.Principal
+	now := this.now()
+
+	var t ApiToken
+	err := this.repo.FindOneByAttribute(ctx, &t, "TokenHash", hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, errors.New("unauthorized: api token is not valid")
+	} else if err != nil {
+		return p, err
+	}
+	user, err := this.activeUser(ctx, t, now)
+	if err != nil {
+		return p, err
+	}
+
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= lastUsedInterval {
+		if err := this.recordUse(ctx, &t, now); err != nil {
+			return p, err
+		}
+	}
+
+	return principalOf(user, MethodApiToken, t.Id, strings.Split(t.Scopes, ",")), nil
+}
+
+func (this apiTokenServiceImpl) Verify(ctx context.Context, p security.Principal) error {
+	var t ApiToken
+	err := this.repo.FindOneById(ctx, &t, p.CredentialId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && t.UserId != p.UserId) {
+		return errors.New("unauthorized: api token the token is issued from does not exist")
+	} else if err != nil {
+		return err
+	}
+
+	_, err = this.activeUser(ctx, t, this.now())
+	return err
+}
+
+// activeUser returns the user of the api token, if the token is usable and the user is active.
+func (this apiTokenServiceImpl) activeUser(ctx context.Context, t ApiToken, now time.Time) (*User, error) {
+	if !t.Usable(now) {
+		return nil, errors.New("unauthorized: api token is revoked or expired")
+	}
+
+	user := NewUser()
+	if err := this.users.FindOneById(ctx, user, t.UserId); err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, errors.New("unauthorized: user is not active")
+	}
+	return user, nil
+}
+
+// recordUse sets the last time the token is used.
+func (this apiTokenServiceImpl) recordUse(ctx context.Context, t *ApiToken, now time.Time) error {
+	snapshot := domain.Snapshot(t)
+	t.LastUsedAt = &now
+
+	return this.svcImpl.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+		return this.repo.SaveChanges(ctx, t, snapshot)
+	})
+}
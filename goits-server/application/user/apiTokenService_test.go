@@ -0,0 +1,120 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cpekyaman/goits/framework/security"
+	"github.com/cpekyaman/goits/framework/testlib/mocking"
+)
+
+var tokenColumns = []string{"id", "user_id", "name", "token_hash", "scopes", "expires_at", "revoked_at", "last_used_at"}
+
+func TestSVC_ApiToken_Authenticate_Success(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestTokenService()
+
+	lastUsed := now.Add(-time.Minute)
+	expectToken(mock, 7, "read", nil, &lastUsed)
+	mocking.NewQueryMocker(userED).ExpectFindOne(mock).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "active"}).AddRow(1, "ann", true))
+
+	// when
+	p, err := svc.Authenticate(context.Background(), "goits_token")
+
+	// then
+	assert.Nil(t, err, "token should be valid")
+	assert.Equal(t, "ann", p.Subject, "principal should be the owner of the token")
+	assert.Equal(t, []string{security.ScopeRead}, p.Scopes, "principal should have the scopes of the token")
+	assert.Equal(t, MethodApiToken, p.Method, "method is not correct")
+	assert.Nil(t, mock.ExpectationsWereMet(), "recently used token should not be updated")
+}
+
+func TestSVC_ApiToken_Authenticate_Revoked_Error(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestTokenService()
+
+	revokedAt := now.Add(-time.Minute)
+	expectToken(mock, 7, "read,write", &revokedAt, nil)
+
+	// when
+	_, err := svc.Authenticate(context.Background(), "goits_token")
+
+	// then
+	assert.NotNil(t, err, "revoked token should not be valid")
+	assert.True(t, strings.HasPrefix(err.Error(), "unauthorized:"), "should be an unauthorized error")
+}
+
+func TestSVC_ApiToken_Verify_Revoked_Error(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestTokenService()
+
+	revokedAt := now.Add(-time.Minute)
+	mocking.NewQueryMocker(tokenED).ExpectFindOne(mock).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(7, 1, "ci", hashToken("goits_token"), "read", nil, revokedAt, nil))
+
+	// when
+	err := svc.Verify(context.Background(), security.Principal{UserId: 1, Subject: "ann", Method: security.MethodJWT, CredentialId: 7, Source: MethodApiToken})
+
+	// then
+	assert.NotNil(t, err, "jwt token of a revoked api token should not be valid")
+	assert.True(t, strings.HasPrefix(err.Error(), "unauthorized:"), "should be an unauthorized error")
+}
+
+func TestSVC_ApiToken_Create_MoreScopes_Error(t *testing.T) {
+	// given
+	svc := newTestTokenService()
+	ctx := security.WithPrincipal(context.Background(),
+		security.Principal{UserId: 1, Subject: "ann", Scopes: []string{security.ScopeRead}, Method: MethodApiToken})
+
+	// when
+	_, err := svc.Create(ctx, ApiTokenRequest{Name: "ci", Scopes: security.AllScopes})
+
+	// then
+	assert.NotNil(t, err, "token should not grant scopes its creator does not have")
+	assert.True(t, strings.HasPrefix(err.Error(), "forbidden:"), "should be a forbidden error")
+}
+
+func TestSVC_ApiToken_Revoke_OtherUser_NotFound(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestTokenService()
+	ctx := security.WithPrincipal(context.Background(),
+		security.Principal{UserId: 2, Subject: "bob", Scopes: security.AllScopes, Method: MethodSession})
+
+	mock.ExpectBegin()
+	mocking.NewQueryMocker(tokenED).ExpectFindOne(mock).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(7, 1, "ci", hashToken("goits_token"), "read", nil, nil, nil))
+	mock.ExpectRollback()
+
+	// when
+	err := svc.Revoke(ctx, 7)
+
+	// then
+	assert.NotNil(t, err, "token of another user should not be revoked")
+	assert.True(t, strings.HasPrefix(err.Error(), "notfound:"), "should be a notfound error")
+	assert.Nil(t, mock.ExpectationsWereMet(), "nothing should be saved")
+}
+
+func newTestTokenService() apiTokenServiceImpl {
+	svc := newApiTokenService(newUserRepository(), newApiTokenRepository(), nil)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func expectToken(mock sqlmock.Sqlmock, id uint64, scopes string, revokedAt *time.Time, lastUsedAt *time.Time) {
+	mocking.NewQueryMocker(tokenED).ExpectFindOneByAttr(mock, "TokenHash").
+		WithArgs(hashToken("goits_token")).
+		WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(id, 1, "ci", hashToken("goits_token"), scopes, nil, revokedAt, lastUsedAt))
+}
@@ -0,0 +1,34 @@
+package user
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cpekyaman/goits/framework/security"
+)
+
+// apiTokenAuthenticator authenticates the api tokens, which are recognized by their prefix.
+type apiTokenAuthenticator struct {
+	svc ApiTokenService
+}
+
+func (this apiTokenAuthenticator) Accepts(credential string) bool {
+	return strings.HasPrefix(credential, ApiTokenPrefix)
+}
+
+func (this apiTokenAuthenticator) Authenticate(ctx context.Context, credential string) (security.Principal, error) {
+	return this.svc.Authenticate(ctx, credential)
+}
+
+// sessionAuthenticator authenticates the login sessions, it accepts any credential so it must be registered last.
+type sessionAuthenticator struct {
+	svc UserService
+}
+
+func (this sessionAuthenticator) Accepts(credential string) bool {
+	return true
+}
+
+func (this sessionAuthenticator) Authenticate(ctx context.Context, credential string) (security.Principal, error) {
+	return this.svc.Authenticate(ctx, credential)
+}
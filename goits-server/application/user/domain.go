@@ -0,0 +1,81 @@
+package user
+
+import (
+	"time"
+
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/validation"
+)
+
+const (
+	userTypeName    = "user.User"
+	sessionTypeName = "user.Session"
+	tokenTypeName   = "user.ApiToken"
+
+	// MinPasswordLength is the least number of characters a password can have.
+	MinPasswordLength = 10
+)
+
+func initDomain() {
+	registerUserValidations()
+}
+
+// User is an account that can log in with its username and password.
+// The password is only kept as its hash, and neither the hash nor the login failures are serialized.
+type User struct {
+	domain.VersionedTimeStampedEntity
+	Username     string     `json:"username" db:"username"`
+	Email        string     `json:"email" db:"email"`
+	FullName     string     `json:"fullName" db:"full_name"`
+	Active       bool       `json:"active" db:"active"`
+	Admin        bool       `json:"admin" db:"admin"`
+	PasswordHash string     `json:"-" db:"password_hash"`
+	FailedLogins int        `json:"-" db:"failed_logins"`
+	LockedUntil  *time.Time `json:"lockedUntil,omitempty" db:"locked_until"`
+}
+
+// Locked checks if the user can not log in at the given time because of too many failed logins.
+func (this User) Locked(now time.Time) bool {
+	return this.LockedUntil != nil && now.Before(*this.LockedUntil)
+}
+
+// Session is a login of a user, which is identified by the hash of the token given to the client.
+type Session struct {
+	domain.TimestampedEntity
+	UserId    uint64    `json:"user" db:"user_id"`
+	TokenHash string    `json:"-" db:"token_hash"`
+	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
+}
+
+// ApiToken is a personal token a user creates to call the api from scripts and tools, identified by the hash of the token.
+// The scopes it grants are kept comma separated, and it can not be used after it expires or is revoked.
+type ApiToken struct {
+	domain.TimestampedEntity
+	UserId     uint64     `json:"user" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	Scopes     string     `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+}
+
+// Usable checks if the token can authenticate at the given time.
+func (this ApiToken) Usable(now time.Time) bool {
+	return this.RevokedAt == nil && (this.ExpiresAt == nil || now.Before(*this.ExpiresAt))
+}
+
+func registerUserValidations() {
+	sv := validation.Struct(userTypeName)
+
+	sv.Field("Username").With(validation.Pattern(validation.PatternAlNum), validation.StrLen(2, 32)).
+		Field("Email").With(validation.NotBlank(), validation.StrLen(3, 250)).
+		Field("FullName").With(validation.NotBlank(), validation.StrLen(1, 250))
+
+	validation.Struct(tokenTypeName).
+		Field("Name").With(validation.NotBlank(), validation.StrLen(1, 100))
+}
+
+func NewUser() *User {
+	return &User{VersionedTimeStampedEntity: domain.VersionedTimeStampedEntity{}, Active: true}
+}
@@ -0,0 +1,92 @@
+//go:generate mockgen -source=repository.go -destination=repository_mock.go -package=user
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+)
+
+var userED metadata.EntityDef
+var sessionED metadata.EntityDef
+var tokenED metadata.EntityDef
+
+func init() {
+	domain.RegisterEntityConfig("user")
+
+	userED = domain.EntityDefByName(userTypeName)
+	sessionED = domain.EntityDefByName(sessionTypeName)
+	tokenED = domain.EntityDefByName(tokenTypeName)
+
+	domain.RegisterEntityType(userTypeName, &User{})
+	domain.RegisterEntityType(sessionTypeName, &Session{})
+	domain.RegisterEntityType(tokenTypeName, &ApiToken{})
+}
+
+type UserRepository interface {
+	repository.Repository
+
+	// CountFailedLogin counts a failed login of the user with a single statement, so that concurrent failures are all counted.
+	// When the failures reach max, the count is reset and the user is locked until the given time.
+	CountFailedLogin(ctx context.Context, id uint64, max int, lockedUntil time.Time) error
+}
+
+type userSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newUserRepository() UserRepository {
+	return userSqlRepository{repository.NewRepository(userED, &User{})}
+}
+
+func (this userSqlRepository) CountFailedLogin(ctx context.Context, id uint64, max int, lockedUntil time.Time) error {
+	d := dialect.Current()
+
+	// the count is incremented from the current value of the row, not from the one the user is loaded with
+	q := fmt.Sprintf("update %s set failed_logins = case when failed_logins + 1 >= %[3]s then 0 else failed_logins + 1 end, "+
+		"locked_until = case when failed_logins + 1 >= %[3]s then %[4]s else locked_until end, "+
+		"version = version + 1, last_modified_time = %[5]s where id = %[2]s",
+		d.Table(userED.Schema(), userED.Table()), d.Placeholder(1), d.Placeholder(2), d.Placeholder(3), d.Now())
+	res, err := this.Executor(ctx).ExecContext(ctx, q, id, max, lockedUntil)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+type SessionRepository interface {
+	repository.Repository
+}
+
+type sessionSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newSessionRepository() SessionRepository {
+	return sessionSqlRepository{repository.NewRepository(sessionED, &Session{})}
+}
+
+type ApiTokenRepository interface {
+	repository.Repository
+}
+
+type apiTokenSqlRepository struct {
+	repository.SqlRepository
+}
+
+func newApiTokenRepository() ApiTokenRepository {
+	return apiTokenSqlRepository{repository.NewRepository(tokenED, &ApiToken{})}
+}
@@ -0,0 +1,66 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+)
+
+const sqliteUserTable = `create table user_account (
+    id                  integer primary key,
+    version             integer not null default 1,
+    last_modified_time  timestamp not null default current_timestamp,
+    failed_logins       integer not null default 0,
+    locked_until        timestamp null
+)`
+
+func newSQLiteUserDB(t *testing.T) *sqlx.DB {
+	sqliteDB, err := sqlx.Open("sqlite3", ":memory:")
+	assert.Nil(t, err, "could not create sqlite db")
+	// every connection of an in memory db has its own database
+	sqliteDB.SetMaxOpenConns(1)
+	sqliteDB.MustExec(sqliteUserTable)
+
+	db.WithDB(sqliteDB.DB, "sqlite3")
+	dialect.Use(dialect.SQLite())
+	t.Cleanup(func() {
+		dialect.Use(dialect.Postgres())
+		sqliteDB.Close()
+	})
+	return sqliteDB
+}
+
+func TestRepository_CountFailedLogin(t *testing.T) {
+	// given
+	sqliteDB := newSQLiteUserDB(t)
+	sqliteDB.MustExec("insert into user_account(id, failed_logins) values(1, 1), (2, 4)")
+	repo := newUserRepository()
+
+	// when
+	countErr := repo.CountFailedLogin(context.Background(), 1, 5, now)
+	lockErr := repo.CountFailedLogin(context.Background(), 2, 5, now)
+	missingErr := repo.CountFailedLogin(context.Background(), 3, 5, now)
+
+	// then
+	assert.Nil(t, countErr, "no error expected on count")
+	assert.Nil(t, lockErr, "no error expected on lock")
+	assert.NotNil(t, missingErr, "missing user should not be counted")
+
+	var rows []struct {
+		FailedLogins int        `db:"failed_logins"`
+		LockedUntil  *time.Time `db:"locked_until"`
+		Version      uint32     `db:"version"`
+	}
+	assert.Nil(t, sqliteDB.Select(&rows, "select failed_logins, locked_until, version from user_account order by id"), "could not read users")
+	assert.Equal(t, 2, rows[0].FailedLogins, "failed login is not counted")
+	assert.Nil(t, rows[0].LockedUntil, "user should not be locked before the limit")
+	assert.Equal(t, 0, rows[1].FailedLogins, "count should be reset when the user is locked")
+	assert.NotNil(t, rows[1].LockedUntil, "user should be locked at the limit")
+	assert.Equal(t, uint32(2), rows[1].Version, "version is not increased")
+}
@@ -0,0 +1,157 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cpekyaman/goits/framework/routing"
+	"github.com/cpekyaman/goits/framework/security"
+	"github.com/cpekyaman/goits/framework/services"
+)
+
+type authEndpoint struct {
+	svc    UserService
+	tokens ApiTokenService
+	conf   authConfig
+	routing.ApiEndpoint
+}
+
+var authAPI authEndpoint
+
+// InitUser registers the auth endpoint and the authenticators of the api tokens and the login sessions,
+// which are also the credentials jwt tokens can be issued from.
+func InitUser() {
+	conf := readAuthConfig()
+	svc := NewUserService()
+	tokens := NewApiTokenService()
+
+	security.SetTokenKey(conf.TokenKey)
+	security.SetSessionCookie(conf.CookieName)
+	security.RegisterAuthenticator(apiTokenAuthenticator{tokens})
+	security.RegisterAuthenticator(sessionAuthenticator{svc})
+	// jwt tokens end with the session or the api token they are issued from
+	security.RegisterTokenSource(MethodSession, svc.VerifySession)
+	security.RegisterTokenSource(MethodApiToken, tokens.Verify)
+
+	authAPI = newAuthEndpoint(svc, tokens, conf)
+	authAPI.Register()
+}
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// jwtResult is an issued jwt token.
+type jwtResult struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// newAuthEndpoint creates the endpoint of the login session of the user, as POST /auth/login, POST /auth/logout and GET /auth/me.
+// The session token is given both in the response and as an http only cookie, and it is read from the cookie
+// or from the bearer authorization header.
+// An authenticated user can also get a short lived jwt token with POST /auth/token, and manage its api tokens under /auth/tokens.
+func newAuthEndpoint(svc UserService, tokens ApiTokenService, conf authConfig) authEndpoint {
+	// the operations read path params through the endpoint, so it is set before they are bound
+	res := authEndpoint{svc: svc, tokens: tokens, conf: conf, ApiEndpoint: routing.NewApiEndpoint("Auth", "auth")}
+	res.ApiEndpoint = res.ApiEndpoint.
+		With(http.MethodPost, "/login", "login", res.login).
+		With(http.MethodPost, "/logout", "logout", res.logout).
+		With(http.MethodGet, "/me", "getCurrentUser", res.me).
+		With(http.MethodPost, "/token", "issueToken", res.issueToken).
+		With(http.MethodGet, "/tokens", "getApiTokens", res.apiTokens).
+		With(http.MethodPost, "/tokens", "createApiToken", res.createApiToken).
+		With(http.MethodDelete, "/tokens/{id}", "revokeApiToken", res.revokeApiToken).
+		WithAuthenticated().
+		WithPublic("login", "logout")
+
+	return res
+}
+
+func (this authEndpoint) login(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error) {
+	var req loginRequest
+	if err := bind.BindTo(&req); err != nil {
+		return nil, err
+	}
+
+	result, err := this.svc.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     this.conf.CookieName,
+		Value:    result.Token,
+		Path:     "/",
+		Expires:  result.ExpiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return result, nil
+}
+
+func (this authEndpoint) logout(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error) {
+	if err := this.svc.Logout(r.Context(), this.sessionToken(r)); err != nil {
+		return nil, err
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: this.conf.CookieName, Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+	return nil, nil
+}
+
+func (this authEndpoint) me(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error) {
+	return this.svc.CurrentUser(r.Context())
+}
+
+// issueToken creates a jwt token for the principal, which can not be a jwt token itself so that the tokens are not renewed indefinitely.
+func (this authEndpoint) issueToken(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error) {
+	p, ok := security.PrincipalFromContext(r.Context())
+	if !ok {
+		return nil, errNotAuthenticated
+	}
+	if p.Method == security.MethodJWT {
+		return nil, errors.New("forbidden: a jwt token can not issue another one")
+	}
+
+	now := time.Now()
+	ttl := time.Duration(this.conf.TokenMinutes) * time.Minute
+	token, err := security.IssueToken(p, now, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return jwtResult{Token: token, ExpiresAt: now.Add(ttl)}, nil
+}
+
+func (this authEndpoint) apiTokens(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error) {
+	return this.tokens.List(r.Context())
+}
+
+func (this authEndpoint) createApiToken(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error) {
+	var req ApiTokenRequest
+	if err := bind.BindTo(&req); err != nil {
+		return nil, err
+	}
+	return this.tokens.Create(r.Context(), req)
+}
+
+func (this authEndpoint) revokeApiToken(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error) {
+	id, err := this.IdPathParam(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return nil, this.tokens.Revoke(r.Context(), id)
+}
+
+// sessionToken reads the session token from the bearer authorization header, or from the session cookie if there is no header.
+func (this authEndpoint) sessionToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	if c, err := r.Cookie(this.conf.CookieName); err == nil {
+		return c.Value
+	}
+	return ""
+}
@@ -0,0 +1,362 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/cpekyaman/goits/config"
+	"github.com/cpekyaman/goits/framework/caching"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/security"
+	"github.com/cpekyaman/goits/framework/services"
+	"github.com/cpekyaman/goits/framework/validation"
+)
+
+const (
+	// MethodSession is the authentication method of the principals of login sessions.
+	MethodSession = "session"
+	// MethodApiToken is the authentication method of the principals of api tokens.
+	MethodApiToken = "token"
+)
+
+// hashCost is the bcrypt cost of password hashes.
+var hashCost = bcrypt.DefaultCost
+
+// errInvalidLogin does not tell whether the username or the password is wrong.
+var errInvalidLogin = errors.New("unauthorized: invalid username or password")
+
+var dummy []byte
+var dummyOnce sync.Once
+
+type authConfig struct {
+	SessionHours    uint   `mapstructure:"sessionHours"`
+	MaxFailedLogins int    `mapstructure:"maxFailedLogins"`
+	LockoutMinutes  uint   `mapstructure:"lockoutMinutes"`
+	CookieName      string `mapstructure:"cookieName"`
+	TokenMinutes    uint   `mapstructure:"tokenMinutes"`
+	TokenKey        string `mapstructure:"tokenKey"`
+}
+
+// readAuthConfig reads the auth config, falling back to defaults for the values that are not configured.
+func readAuthConfig() authConfig {
+	conf := authConfig{SessionHours: 12, MaxFailedLogins: 5, LockoutMinutes: 15, CookieName: "goits_session", TokenMinutes: 60}
+	config.ReadInto("auth", &conf)
+	return conf
+}
+
+// LoginResult is the session created by a successful login, whose token is only known by the client.
+type LoginResult struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      *User     `json:"user"`
+}
+
+type UserService interface {
+	// Create saves a new user with the hash of the given password.
+	Create(ctx context.Context, user *User, password string) (*User, error)
+
+	// ResetPassword changes the password of the user, unlocks it and ends all of its sessions.
+	ResetPassword(ctx context.Context, username string, password string) error
+
+	// Login starts a session of the user if the password is correct.
+	// The user is locked for a while after too many consecutive failed logins.
+	Login(ctx context.Context, username string, password string) (LoginResult, error)
+
+	// Logout ends the session of the token, if there is any.
+	Logout(ctx context.Context, token string) error
+
+	// Authenticate returns the principal of the session of the token, whose user must be active.
+	Authenticate(ctx context.Context, token string) (security.Principal, error)
+
+	// VerifySession checks that the session a jwt token of the principal is issued from is still valid.
+	VerifySession(ctx context.Context, p security.Principal) error
+
+	// CurrentUser returns the user of the principal of the request.
+	CurrentUser(ctx context.Context) (*User, error)
+}
+
+type userServiceImpl struct {
+	repo     UserRepository
+	sessions SessionRepository
+	svcImpl  services.CRUDServiceImpl
+	conf     authConfig
+	now      func() time.Time
+}
+
+// NewUserService creates the user service with the default repositories and the auth config.
+// It also registers the validations of users, so that it can be used outside of the http server such as in cli commands.
+func NewUserService() UserService {
+	initDomain()
+	return newUserService(newUserRepository(), newSessionRepository(), validation.Provider(), readAuthConfig())
+}
+
+func newUserService(ur UserRepository, sr SessionRepository, vp validation.ValidationProvider, conf authConfig) userServiceImpl {
+	return userServiceImpl{ur, sr, services.NewCRUDService(ur, caching.NoOpCache(), vp), conf, time.Now}
+}
+
+func (this userServiceImpl) Create(ctx context.Context, user *User, password string) (*User, error) {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing User
+	err = this.repo.FindOneByAttribute(ctx, &existing, "Username", user.Username)
+	if err == nil {
+		return nil, fmt.Errorf("conflict: user %s already exists", user.Username)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	created, err := this.svcImpl.Create(ctx, services.ObjectBinderFunc(func(target interface{}) error {
+		u, ok := target.(*User)
+		if !ok {
+			return nil
+		}
+		*u = *user
+		u.PasswordHash = hash
+		u.FailedLogins = 0
+		u.LockedUntil = nil
+		return nil
+	}), userTypeName, NewUser())
+	if err != nil {
+		return nil, err
+	}
+	return created.(*User), nil
+}
+
+func (this userServiceImpl) ResetPassword(ctx context.Context, username string, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return this.svcImpl.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+		user, err := this.findByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		snapshot := domain.Snapshot(user)
+
+		user.PasswordHash = hash
+		user.FailedLogins = 0
+		user.LockedUntil = nil
+		if err := this.repo.SaveChanges(ctx, user, snapshot); err != nil {
+			return err
+		}
+		return this.endSessions(ctx, user.Id)
+	})
+}
+
+func (this userServiceImpl) Login(ctx context.Context, username string, password string) (LoginResult, error) {
+	var result LoginResult
+	now := this.now()
+
+	user, err := this.findByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// the password is still compared, so that unknown users take as long as the known ones
+			bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+			return result, errInvalidLogin
+		}
+		return result, err
+	}
+	// a locked user is not told apart from a wrong password, so that the lock does not reveal the user exists
+	if user.Locked(now) {
+		bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+		return result, errInvalidLogin
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		if err := this.loginFailed(ctx, user, now); err != nil {
+			return result, err
+		}
+		return result, errInvalidLogin
+	}
+	if !user.Active {
+		return result, errInvalidLogin
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return result, err
+	}
+	session := Session{UserId: user.Id, TokenHash: hashToken(token), ExpiresAt: now.Add(time.Duration(this.conf.SessionHours) * time.Hour)}
+
+	err = this.svcImpl.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+		if user.FailedLogins > 0 || user.LockedUntil != nil {
+			snapshot := domain.Snapshot(user)
+			user.FailedLogins = 0
+			user.LockedUntil = nil
+			if err := this.repo.SaveChanges(ctx, user, snapshot); err != nil {
+				return err
+			}
+		}
+		return this.sessions.Save(ctx, &session)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return LoginResult{Token: token, ExpiresAt: session.ExpiresAt, User: user}, nil
+}
+
+// loginFailed counts the failed login of the user, locking it when the failures reach the limit.
+func (this userServiceImpl) loginFailed(ctx context.Context, user *User, now time.Time) error {
+	lockedUntil := now.Add(time.Duration(this.conf.LockoutMinutes) * time.Minute)
+	return this.repo.CountFailedLogin(ctx, user.Id, this.conf.MaxFailedLogins, lockedUntil)
+}
+
+func (this userServiceImpl) Logout(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+
+	var session Session
+	err := this.sessions.FindOneByAttribute(ctx, &session, "TokenHash", hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return this.sessions.Delete(ctx, session.Id)
+}
+
+func (this userServiceImpl) Authenticate(ctx context.Context, token string) (security.Principal, error) {
+	if token == "" {
+		return security.Principal{}, errNotAuthenticated
+	}
+
+	var session Session
+	err := this.sessions.FindOneByAttribute(ctx, &session, "TokenHash", hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return security.Principal{}, errors.New("unauthorized: session is not valid")
+	} else if err != nil {
+		return security.Principal{}, err
+	}
+
+	user, err := this.activeUser(ctx, session)
+	if err != nil {
+		return security.Principal{}, err
+	}
+	return principalOf(user, MethodSession, session.Id, security.AllScopes), nil
+}
+
+func (this userServiceImpl) VerifySession(ctx context.Context, p security.Principal) error {
+	var session Session
+	err := this.sessions.FindOneById(ctx, &session, p.CredentialId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && session.UserId != p.UserId) {
+		return errors.New("unauthorized: session the token is issued from has ended")
+	} else if err != nil {
+		return err
+	}
+
+	_, err = this.activeUser(ctx, session)
+	return err
+}
+
+// activeUser returns the user of the session, if the session has not expired and the user is active.
+func (this userServiceImpl) activeUser(ctx context.Context, session Session) (*User, error) {
+	if !this.now().Before(session.ExpiresAt) {
+		return nil, errors.New("unauthorized: session has expired")
+	}
+
+	user := NewUser()
+	if err := this.repo.FindOneById(ctx, user, session.UserId); err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, errors.New("unauthorized: user is not active")
+	}
+	return user, nil
+}
+
+func (this userServiceImpl) CurrentUser(ctx context.Context) (*User, error) {
+	p, ok := security.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, errNotAuthenticated
+	}
+
+	user := NewUser()
+	if err := this.repo.FindOneById(ctx, user, p.UserId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("unauthorized: user does not exist")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (this userServiceImpl) findByUsername(ctx context.Context, username string) (*User, error) {
+	user := NewUser()
+	err := this.repo.FindOneByAttribute(ctx, user, "Username", username)
+	return user, err
+}
+
+// endSessions deletes all sessions of the user.
+func (this userServiceImpl) endSessions(ctx context.Context, userId uint64) error {
+	var sessions []Session
+	if err := this.sessions.FindAllByAttributes(ctx, &sessions, map[string]interface{}{"UserId": userId}); err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.Id
+	}
+	return this.sessions.DeleteAll(ctx, ids)
+}
+
+// principalOf creates the principal of the user authenticated with the method and the credential of the given id, granting the scopes.
+func principalOf(user *User, method string, credentialId uint64, scopes []string) security.Principal {
+	return security.Principal{UserId: user.Id, Subject: user.Username, Admin: user.Admin, Scopes: scopes, Method: method, CredentialId: credentialId}
+}
+
+// dummyHash returns the hash the passwords of unknown users are compared with, which has the cost of the real hashes.
+func dummyHash() []byte {
+	dummyOnce.Do(func() {
+		dummy, _ = bcrypt.GenerateFromPassword([]byte("goits dummy password"), hashCost)
+	})
+	return dummy
+}
+
+// hashPassword checks the password against the length limits and returns its bcrypt hash.
+func hashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", fmt.Errorf("validation: password must have at least %d characters", MinPasswordLength)
+	}
+	// bcrypt ignores the bytes after the first 72
+	if len(password) > 72 {
+		return "", errors.New("validation: password can not be longer than 72 bytes")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), hashCost)
+	return string(hash), err
+}
+
+// newToken creates a random session token.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken gets the hash a session token is kept as, tokens are random enough not to need a slow hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,186 @@
+package user
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/cpekyaman/goits/framework/security"
+	"github.com/cpekyaman/goits/framework/testlib"
+	"github.com/cpekyaman/goits/framework/testlib/mocking"
+	"github.com/cpekyaman/goits/framework/validation"
+)
+
+var st *testlib.ServiceTest
+
+var userColumns = []string{"id", "version", "username", "active", "password_hash", "failed_logins", "locked_until"}
+
+var now = time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+
+var testConf = authConfig{SessionHours: 12, MaxFailedLogins: 5, LockoutMinutes: 15, CookieName: "goits_session"}
+
+func init() {
+	hashCost = bcrypt.MinCost
+
+	st = testlib.NewServiceTest(userED)
+}
+
+func TestSVC_User_Login_Success(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestService(nil)
+
+	expectUser(mock, "correct password", 0, nil)
+	mock.ExpectBegin()
+	mocking.NewQueryMocker(sessionED).ExpectInsert(mock, 3)
+	mock.ExpectCommit()
+
+	// when
+	result, err := svc.Login(context.Background(), "ann", "correct password")
+
+	// then
+	assert.Nil(t, err, "login should be successfull")
+	assert.NotEmpty(t, result.Token, "session token should be returned")
+	assert.Equal(t, now.Add(12*time.Hour), result.ExpiresAt, "session should expire after configured hours")
+	assert.Equal(t, "ann", result.User.Username, "logged in user should be returned")
+	assert.Nil(t, mock.ExpectationsWereMet(), "session should be saved")
+}
+
+func TestSVC_User_Login_WrongPassword_Error(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestService(nil)
+
+	expectUser(mock, "correct password", 1, nil)
+	mock.ExpectExec(regexp.QuoteMeta("update data.user_account set failed_logins = case when failed_logins + 1 >= $2")).
+		WithArgs(1, 5, now.Add(15*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	_, err := svc.Login(context.Background(), "ann", "wrong password")
+
+	// then
+	assert.NotNil(t, err, "login should fail")
+	assert.True(t, strings.HasPrefix(err.Error(), "unauthorized:"), "should be an unauthorized error")
+	assert.Nil(t, mock.ExpectationsWereMet(), "failed login should be counted")
+}
+
+func TestSVC_User_Login_Locked_Error(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestService(nil)
+
+	lockedUntil := now.Add(time.Minute)
+	expectUser(mock, "correct password", 0, &lockedUntil)
+
+	// when
+	_, err := svc.Login(context.Background(), "ann", "correct password")
+
+	// then
+	assert.Equal(t, errInvalidLogin, err, "locked user should not log in even with correct password, nor be told apart")
+	assert.Nil(t, mock.ExpectationsWereMet(), "nothing should be saved")
+}
+
+func TestSVC_User_Login_UnknownUser_Error(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestService(nil)
+
+	mocking.NewQueryMocker(userED).ExpectFindOneByAttr(mock, "Username").
+		WithArgs("ann").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+
+	// when
+	_, err := svc.Login(context.Background(), "ann", "some password")
+
+	// then
+	assert.Equal(t, errInvalidLogin, err, "unknown user should not be told apart from a wrong password")
+	assert.Nil(t, mock.ExpectationsWereMet(), "nothing should be saved")
+}
+
+func TestSVC_User_Authenticate_Expired_Error(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestService(nil)
+
+	mocking.NewQueryMocker(sessionED).ExpectFindOneByAttr(mock, "TokenHash").
+		WithArgs(hashToken("token")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at"}).
+			AddRow(3, 1, hashToken("token"), now.Add(-time.Minute)))
+
+	// when
+	_, err := svc.Authenticate(context.Background(), "token")
+
+	// then
+	assert.NotNil(t, err, "expired session should not be valid")
+	assert.True(t, strings.HasPrefix(err.Error(), "unauthorized:"), "should be an unauthorized error")
+}
+
+func TestSVC_User_VerifySession_Ended_Error(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestService(nil)
+
+	mocking.NewQueryMocker(sessionED).ExpectFindOne(mock).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at"}))
+
+	// when
+	err := svc.VerifySession(context.Background(), security.Principal{UserId: 1, Subject: "ann", Method: security.MethodJWT, CredentialId: 3, Source: MethodSession})
+
+	// then
+	assert.NotNil(t, err, "jwt token of an ended session should not be valid")
+	assert.True(t, strings.HasPrefix(err.Error(), "unauthorized:"), "should be an unauthorized error")
+}
+
+func TestSVC_User_VerifySession_Inactive_Error(t *testing.T) {
+	// given
+	mock := st.NewMockDB(t)
+	svc := newTestService(nil)
+
+	mocking.NewQueryMocker(sessionED).ExpectFindOne(mock).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at"}).
+			AddRow(3, 1, hashToken("token"), now.Add(time.Hour)))
+	mocking.NewQueryMocker(userED).ExpectFindOne(mock).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "active"}).AddRow(1, "ann", false))
+
+	// when
+	err := svc.VerifySession(context.Background(), security.Principal{UserId: 1, Subject: "ann", Method: security.MethodJWT, CredentialId: 3, Source: MethodSession})
+
+	// then
+	assert.NotNil(t, err, "jwt token of an inactive user should not be valid")
+	assert.True(t, strings.HasPrefix(err.Error(), "unauthorized:"), "should be an unauthorized error")
+}
+
+func TestSVC_User_Create_ShortPassword_Error(t *testing.T) {
+	// given
+	svc := newTestService(nil)
+
+	// when
+	_, err := svc.Create(context.Background(), &User{Username: "ann"}, "short")
+
+	// then
+	assert.NotNil(t, err, "short password should not be accepted")
+	assert.True(t, strings.HasPrefix(err.Error(), "validation:"), "should be a validation error")
+}
+
+func newTestService(vp validation.ValidationProvider) userServiceImpl {
+	svc := newUserService(newUserRepository(), newSessionRepository(), vp, testConf)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func expectUser(mock sqlmock.Sqlmock, password string, failedLogins int, lockedUntil *time.Time) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	mocking.NewQueryMocker(userED).ExpectFindOneByAttr(mock, "Username").
+		WithArgs("ann").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, 0, "ann", true, string(hash), failedLogins, lockedUntil))
+}
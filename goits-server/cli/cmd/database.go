@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	// the event types have no api, their package only registers their repositories for seeding
+	_ "github.com/cpekyaman/goits/application/event"
+	"github.com/cpekyaman/goits/config"
+	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/schema"
+	"github.com/cpekyaman/goits/framework/seeding"
+)
+
+var DbCommand *cobra.Command
+
+func init() {
+	DbCommand = &cobra.Command{
+		Use:   "db",
+		Short: "manage goits database",
+		Long:  "database management commands such as schema verification and seeding",
+	}
+
+	verify := &cobra.Command{
+		Use:   "verify",
+		Short: "verify db schema",
+		Long:  "verify the tables in the database against the entity mappings and metadata",
+		Run: func(cmd *cobra.Command, args []string) {
+			dbVerify()
+		},
+	}
+
+	var env *string
+	var modules *[]string
+	seed := &cobra.Command{
+		Use:   "seed",
+		Short: "seed db data",
+		Long:  "load the reference data, and demo data depending on environment, from seed files into the database",
+		Run: func(cmd *cobra.Command, args []string) {
+			dbSeed(*env, *modules)
+		},
+	}
+	env = seed.Flags().String("env", "prod", "environment whose seed sets are loaded")
+	modules = seed.Flags().StringSlice("module", []string{}, "comma separated list of modules to seed, all modules by default")
+
+	DbCommand.AddCommand(verify, seed)
+}
+
+type seedConfig struct {
+	Dir  string              `mapstructure:"dir"`
+	Envs map[string][]string `mapstructure:"envs"`
+}
+
+// dbSeed upserts the seed sets of the environment within a single transaction.
+func dbSeed(env string, modules []string) {
+	var conf seedConfig
+	config.ReadInto("seed", &conf)
+
+	sets, found := conf.Envs[env]
+	if !found {
+		log.Fatalf("No seed sets are configured for %s\n", env)
+	}
+
+	var result seeding.Result
+	err := db.NewTxManager().WithinTx(context.Background(), func(ctx context.Context) error {
+		var err error
+		result, err = seeding.NewSeeder(config.AppPath(conf.Dir)).Seed(ctx, sets, modules)
+		return err
+	})
+	if err != nil {
+		log.Fatal("Could not seed db : ", err)
+	}
+
+	for entity, n := range result {
+		log.Printf("Seeded %d rows of %s\n", n, entity)
+	}
+	log.Printf("Seeded %s for %s !\n", strings.Join(sets, ", "), env)
+}
+
+// dbVerify reports the mismatches between the registered entities and the database schema.
+func dbVerify() {
+	mismatches, err := schema.NewVerifier(db.DB()).VerifyRegistered(context.Background())
+	if err != nil {
+		log.Fatal("Could not verify db schema : ", err)
+	}
+
+	for _, m := range mismatches {
+		fmt.Println(m)
+	}
+	if len(mismatches) > 0 {
+		log.Fatalf("Found %d mismatches !\n", len(mismatches))
+	}
+	log.Println("Db schema matches the entities !")
+}
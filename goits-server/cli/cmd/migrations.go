@@ -1,7 +1,13 @@
 package cmd
 
 import (
+	"fmt"
+	"io/ioutil"
 	"log"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -22,9 +28,16 @@ type migrationConfig struct {
 	Dialect       string `mapstructure:"dialect"`
 }
 
+// migrationVersionPattern matches the V<major>_<minor>_ version prefix of migration file names.
+var migrationVersionPattern = regexp.MustCompile(`^V(\d+)_(\d+)_`)
+
+const migrationTemplate = "-- +migrate Up\n\n-- +migrate Down\n"
+
 var mconf dbConfig
 var MigrateCommand *cobra.Command
 
+var dryRun *bool
+
 func init() {
 	MigrateCommand = &cobra.Command{
 		Use:     "migrate",
@@ -32,42 +45,247 @@ func init() {
 		Short:   "run db migrations",
 		Long:    "applies db migrations to the database",
 	}
+	dryRun = MigrateCommand.PersistentFlags().Bool("dry-run", false, "print the sql of migrations instead of applying them")
+
+	var to *string
 	up := &cobra.Command{
 		Use:   "up",
 		Short: "apply migrations",
-		Long:  "apply migrations for schema upgrade",
+		Long:  "apply migrations for schema upgrade, all pending ones or up to and including the one given with --to, which must be newer than the last applied one",
 		Run: func(cmd *cobra.Command, args []string) {
-			dbMigrate(migrate.Up)
+			dbMigrateUp(*to)
 		},
 	}
+	to = up.Flags().String("to", "", "id or version prefix of the last migration to apply")
+
+	var steps *int
 	down := &cobra.Command{
 		Use:   "down",
 		Short: "rollback migrations",
-		Long:  "apply migrations for schema downgrade",
+		Long:  "apply migrations for schema downgrade, rolling back the given number of steps",
 		Run: func(cmd *cobra.Command, args []string) {
-			dbMigrate(migrate.Down)
+			dbMigrate(migrate.Down, *steps)
 		},
 	}
-	MigrateCommand.AddCommand(up, down)
-}
+	steps = down.Flags().Int("steps", 1, "number of migrations to roll back, 0 rolls back all of them")
 
-// dbMigrate runs the db migration scripts for new version or rolling back to previous version.
-func dbMigrate(direction migrate.MigrationDirection) {
-	config.ReadInto("db", &mconf)
+	redo := &cobra.Command{
+		Use:   "redo",
+		Short: "reapply last migration",
+		Long:  "roll back the last applied migration and apply it again",
+		Run: func(cmd *cobra.Command, args []string) {
+			dbMigrateRedo()
+		},
+	}
 
-	migrations := &migrate.FileMigrationSource{
-		Dir: mconf.Migrations.MigrationsDir + "/" + mconf.Migrations.Platform,
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "show migration status",
+		Long:  "show the migrations with the time they are applied, or as pending",
+		Run: func(cmd *cobra.Command, args []string) {
+			dbMigrateStatus()
+		},
+	}
+
+	create := &cobra.Command{
+		Use:   "new <name>",
+		Short: "create a new migration",
+		Long:  "create an empty migration file numbered after the latest one",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dbMigrateNew(args[0])
+		},
 	}
 
+	MigrateCommand.AddCommand(up, down, redo, status, create)
+}
+
+// readMigrationConfig reads the migration config and returns the migration set and the migration files.
+func readMigrationConfig() (migrate.MigrationSet, migrate.FileMigrationSource) {
+	config.ReadInto("db", &mconf)
+
 	ms := migrate.MigrationSet{
 		TableName:  mconf.Migrations.TableName,
 		SchemaName: mconf.Migrations.SchemaName,
 	}
+	return ms, migrate.FileMigrationSource{Dir: migrationsDir()}
+}
+
+// migrationsDir returns the directory of the migrations of the configured platform, which is platform/migrations under dir.
+func migrationsDir() string {
+	return config.AppPath(filepath.Join(mconf.Migrations.MigrationsDir, mconf.Migrations.Platform, "migrations"))
+}
+
+// dbMigrate runs at most max db migration scripts for new version or rolling back to previous version, all of them if max is 0.
+func dbMigrate(direction migrate.MigrationDirection, max int) {
+	ms, migrations := readMigrationConfig()
+
+	if *dryRun {
+		printPlan(ms, migrations, direction, max)
+		return
+	}
 
 	log.Printf("Applying migrations for %v\n", direction)
-	n, err := ms.Exec(db.DB().DB, mconf.Migrations.Dialect, migrations, direction)
+	n, err := ms.ExecMax(db.DB().DB, mconf.Migrations.Dialect, migrations, direction, max)
 	if err != nil {
 		log.Fatal("Could not execute migrations : ", err)
 	}
 	log.Printf("Applied %d migrations !\n", n)
 }
+
+// dbMigrateUp applies the pending migrations up to and including the one matching the target, all of them if target is empty.
+func dbMigrateUp(target string) {
+	if target == "" {
+		dbMigrate(migrate.Up, 0)
+		return
+	}
+
+	ms, migrations := readMigrationConfig()
+	planned, _, err := ms.PlanMigration(db.DB().DB, mconf.Migrations.Dialect, migrations, migrate.Up, 0)
+	if err != nil {
+		log.Fatal("Could not plan migrations : ", err)
+	}
+	last, err := lastAppliedMigration(ms)
+	if err != nil {
+		log.Fatal("Could not read applied migrations : ", err)
+	}
+
+	// migrations older than the last applied one are always applied, the limit only counts the newer ones
+	max := 0
+	for _, p := range planned {
+		newer := last == nil || last.Less(p.Migration)
+		if newer {
+			max++
+		}
+		if !strings.HasPrefix(p.Id, target) {
+			continue
+		}
+		if !newer {
+			// a limit of 0 would apply all pending migrations, and the older ones can not be applied on their own
+			log.Fatalf("Pending migration %s is older than the last applied one %s, it can only be applied by migrating up without --to\n", p.Id, last.Id)
+		}
+		dbMigrate(migrate.Up, max)
+		return
+	}
+	log.Fatalf("Could not find pending migration %s\n", target)
+}
+
+// dbMigrateRedo rolls back the last applied migration and applies it again.
+func dbMigrateRedo() {
+	ms, migrations := readMigrationConfig()
+
+	if *dryRun {
+		planned := printPlan(ms, migrations, migrate.Down, 1)
+		for _, p := range planned {
+			printQueries(p.Id, migrate.Up, p.Up)
+		}
+		return
+	}
+
+	dbMigrate(migrate.Down, 1)
+	dbMigrate(migrate.Up, 1)
+}
+
+// dbMigrateStatus prints all migrations with the time they are applied.
+func dbMigrateStatus() {
+	ms, migrations := readMigrationConfig()
+
+	found, err := migrations.FindMigrations()
+	if err != nil {
+		log.Fatal("Could not read migrations : ", err)
+	}
+	records, err := ms.GetMigrationRecords(db.DB().DB, mconf.Migrations.Dialect)
+	if err != nil {
+		log.Fatal("Could not read applied migrations : ", err)
+	}
+
+	applied := make(map[string]string, len(records))
+	for _, r := range records {
+		applied[r.Id] = r.AppliedAt.Format("2006-01-02 15:04:05")
+	}
+
+	fmt.Printf("%-50s %s\n", "MIGRATION", "APPLIED AT")
+	for _, m := range found {
+		at, ok := applied[m.Id]
+		if !ok {
+			at = "pending"
+		}
+		fmt.Printf("%-50s %s\n", m.Id, at)
+		delete(applied, m.Id)
+	}
+	for id, at := range applied {
+		fmt.Printf("%-50s %s (missing file)\n", id, at)
+	}
+}
+
+// dbMigrateNew creates an empty migration file with the version following the latest migration.
+func dbMigrateNew(name string) {
+	_, migrations := readMigrationConfig()
+
+	found, err := migrations.FindMigrations()
+	if err != nil {
+		log.Fatal("Could not read migrations : ", err)
+	}
+
+	major, minor := 100, 0
+	for _, m := range found {
+		v := migrationVersionPattern.FindStringSubmatch(m.Id)
+		if v == nil {
+			continue
+		}
+		vmajor, _ := strconv.Atoi(v[1])
+		vminor, _ := strconv.Atoi(v[2])
+		if vmajor > major || (vmajor == major && vminor > minor) {
+			major, minor = vmajor, vminor
+		}
+	}
+
+	file := filepath.Join(migrations.Dir, fmt.Sprintf("V%d_%04d_%s.sql", major, minor+1, name))
+	if err := ioutil.WriteFile(file, []byte(migrationTemplate), 0644); err != nil {
+		log.Fatal("Could not create migration : ", err)
+	}
+	log.Printf("Created %s !\n", file)
+}
+
+// lastAppliedMigration returns the latest applied migration, which is nil if none is applied yet.
+func lastAppliedMigration(ms migrate.MigrationSet) (*migrate.Migration, error) {
+	records, err := ms.GetMigrationRecords(db.DB().DB, mconf.Migrations.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *migrate.Migration
+	for _, r := range records {
+		m := &migrate.Migration{Id: r.Id}
+		if last == nil || last.Less(m) {
+			last = m
+		}
+	}
+	return last, nil
+}
+
+// printPlan prints the sql of the migrations that would be run and returns them.
+func printPlan(ms migrate.MigrationSet, migrations migrate.MigrationSource, direction migrate.MigrationDirection, max int) []*migrate.PlannedMigration {
+	planned, _, err := ms.PlanMigration(db.DB().DB, mconf.Migrations.Dialect, migrations, direction, max)
+	if err != nil {
+		log.Fatal("Could not plan migrations : ", err)
+	}
+
+	for _, p := range planned {
+		printQueries(p.Id, direction, p.Queries)
+	}
+	return planned
+}
+
+func printQueries(id string, direction migrate.MigrationDirection, queries []string) {
+	dir := "up"
+	if direction == migrate.Down {
+		dir = "down"
+	}
+
+	fmt.Printf("-- %s (%s)\n", id, dir)
+	for _, q := range queries {
+		fmt.Println(strings.TrimSpace(q))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/cpekyaman/goits/application/user"
+	"github.com/cpekyaman/goits/framework/validation"
+)
+
+var UserCommand *cobra.Command
+
+func init() {
+	UserCommand = &cobra.Command{
+		Use:   "user",
+		Short: "manage goits users",
+		Long:  "user management commands such as creating the first admin and resetting forgotten passwords",
+	}
+
+	var username, email, fullName *string
+	var admin *bool
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "create a user",
+		Long:  "create an active user whose password is read from the terminal, or from stdin if it is not a terminal",
+		Run: func(cmd *cobra.Command, args []string) {
+			userCreate(&user.User{Username: *username, Email: *email, FullName: *fullName, Active: true, Admin: *admin})
+		},
+	}
+	username = create.Flags().String("username", "", "name the user logs in with")
+	email = create.Flags().String("email", "", "email address of the user")
+	fullName = create.Flags().String("name", "", "full name of the user")
+	admin = create.Flags().Bool("admin", false, "make the user an admin")
+	create.MarkFlagRequired("username")
+	create.MarkFlagRequired("email")
+	create.MarkFlagRequired("name")
+
+	var resetUsername *string
+	reset := &cobra.Command{
+		Use:   "reset-password",
+		Short: "reset the password of a user",
+		Long:  "set a new password of a user, which also unlocks the user and ends its sessions",
+		Run: func(cmd *cobra.Command, args []string) {
+			userResetPassword(*resetUsername)
+		},
+	}
+	resetUsername = reset.Flags().String("username", "", "name of the user")
+	reset.MarkFlagRequired("username")
+
+	UserCommand.AddCommand(create, reset)
+}
+
+// userCreate saves the user with the password read from the input.
+func userCreate(u *user.User) {
+	validation.InitValidation()
+
+	created, err := user.NewUserService().Create(context.Background(), u, readPassword())
+	if err != nil {
+		log.Fatal("Could not create user : ", err)
+	}
+	log.Printf("Created user %s with id %d !\n", created.Username, created.Id)
+}
+
+// userResetPassword changes the password of the user to the one read from the input.
+func userResetPassword(username string) {
+	validation.InitValidation()
+
+	if err := user.NewUserService().ResetPassword(context.Background(), username, readPassword()); err != nil {
+		log.Fatal("Could not reset password : ", err)
+	}
+	log.Printf("Reset password of %s !\n", username)
+}
+
+// readPassword reads the password from the terminal without echoing it, asking it twice to prevent typos.
+// When the input is not a terminal, the first line of it is the password.
+func readPassword() string {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatal("Could not read password : ", err)
+		}
+		return strings.TrimRight(line, "\r\n")
+	}
+
+	prompt := func(label string) string {
+		fmt.Print(label)
+		b, err := terminal.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			log.Fatal("Could not read password : ", err)
+		}
+		return string(b)
+	}
+
+	password := prompt("Password: ")
+	if prompt("Repeat password: ") != password {
+		log.Fatal("Passwords do not match !")
+	}
+	return password
+}
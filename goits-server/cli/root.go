@@ -14,8 +14,10 @@ func init() {
 		Long:  "command line interface to manage goits server",
 	}
 
+	rootCmd.AddCommand(cmd.DbCommand)
 	rootCmd.AddCommand(cmd.MigrateCommand)
 	rootCmd.AddCommand(cmd.ServerCommand)
+	rootCmd.AddCommand(cmd.UserCommand)
 }
 
 // Execute runs the root cli command as the main entry point.
@@ -69,7 +69,12 @@ func ReadConfig(configKey string, rootDir string, baseName string, configStruct
 	return err
 }
 
-// AppPath returns the full path for the relative config dir.
+// ConfigPath returns the full path for the relative config dir.
 func ConfigPath(dir string) string {
 	return filepath.Join(configRootDir, dir)
 }
+
+// AppPath returns the full path for the dir relative to the application root.
+func AppPath(dir string) string {
+	return filepath.Join(appRootDir, dir)
+}
@@ -0,0 +1,18 @@
+package commons
+
+import (
+	"context"
+)
+
+type actorCtxKey struct{}
+
+// WithActor returns a copy of ctx that carries the name of the one performing the operations, e.g. the current user.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+// ActorFromContext returns the name of the one performing the operations, if ctx carries it.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorCtxKey{}).(string)
+	return actor, ok && actor != ""
+}
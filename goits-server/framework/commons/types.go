@@ -36,7 +36,7 @@ func (this Subsystem) String() string {
 
 type ErrorType uint8
 
-var errorTypes = [...]string{"validation", "client", "notfound", "db", "internal"}
+var errorTypes = [...]string{"validation", "client", "notfound", "db", "internal", "conflict", "precondition", "forbidden", "unauthorized"}
 
 const (
 	ErrValidation ErrorType = iota
@@ -44,6 +44,10 @@ const (
 	ErrNotFound
 	ErrDb
 	ErrInternal
+	ErrConflict
+	ErrPrecondition
+	ErrForbidden
+	ErrUnauthorized
 )
 
 func (this ErrorType) String() string {
@@ -52,9 +56,16 @@ func (this ErrorType) String() string {
 
 // AppError
 type AppError struct {
-	ErrorType ErrorType `json:"errorType"`
-	Message   string    `json:"message"`
-	Cause     string    `json:"cause"`
+	ErrorType ErrorType              `json:"errorType"`
+	Message   string                 `json:"message"`
+	Cause     string                 `json:"cause"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// DetailedError is implemented by errors that carry structured details to be reported to clients.
+type DetailedError interface {
+	error
+	Details() map[string]interface{}
 }
 
 func DetermineErrorType(err error) ErrorType {
@@ -68,8 +79,18 @@ func DetermineErrorType(err error) ErrorType {
 		} else {
 			return ErrDb
 		}
-	} else if strings.HasPrefix(msg, "binding:") {
+	} else if strings.HasPrefix(msg, "binding:") || strings.HasPrefix(msg, "criteria:") {
 		return ErrClient
+	} else if strings.HasPrefix(msg, "notfound:") {
+		return ErrNotFound
+	} else if strings.HasPrefix(msg, "conflict:") {
+		return ErrConflict
+	} else if strings.HasPrefix(msg, "precondition:") {
+		return ErrPrecondition
+	} else if strings.HasPrefix(msg, "forbidden:") {
+		return ErrForbidden
+	} else if strings.HasPrefix(msg, "unauthorized:") {
+		return ErrUnauthorized
 	} else {
 		return ErrInternal
 	}
@@ -19,6 +19,7 @@ const (
 	LF_ErrorCause = "eca"
 	LF_Type       = "tp"
 	LF_Query      = "q"
+	LF_Rows       = "rows"
 )
 
 var logger *zap.Logger
@@ -3,12 +3,15 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/cpekyaman/goits/config"
 	"github.com/cpekyaman/goits/framework/monitoring"
+	"github.com/cpekyaman/goits/framework/orm/dialect"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
 	"go.uber.org/zap"
 )
 
@@ -20,6 +23,10 @@ type serverConfig struct {
 	Dbname   string `mapstructure:"dbname"`
 }
 
+type sqliteConfig struct {
+	File string `mapstructure:"file"`
+}
+
 type connectionConfig struct {
 	MaxOpen  int           `mapstructure:"maxOpen"`
 	MaxIdle  int           `mapstructure:"maxIdle"`
@@ -27,8 +34,10 @@ type connectionConfig struct {
 }
 
 type dbConfig struct {
-	Server serverConfig     `mapstructure:"server"`
-	Conn   connectionConfig `mapstructure:"conn"`
+	Dialect string           `mapstructure:"dialect"`
+	Server  serverConfig     `mapstructure:"server"`
+	SQLite  sqliteConfig     `mapstructure:"sqlite"`
+	Conn    connectionConfig `mapstructure:"conn"`
 }
 
 var appDB *sqlx.DB
@@ -36,14 +45,21 @@ var dbURL string
 var conf dbConfig
 
 // NewDB creates and initializes db layer of the application.
+// The configured dialect, which is postgres by default, decides the driver and the statements of repositories.
 func NewDB() {
 	config.ReadInto("db", &conf)
 
-	dbURL = fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
-		conf.Server.UserName, conf.Server.Password,
-		conf.Server.Host, conf.Server.Port, conf.Server.Dbname)
+	if conf.Dialect == "" {
+		conf.Dialect = dialect.Postgres().Name()
+	}
+	d, err := dialect.ByName(conf.Dialect)
+	if err != nil {
+		monitoring.RootLogger().With(zap.Error(err)).Fatal("could not open database")
+	}
+	dialect.Use(d)
+	dbURL = dataSourceName(d)
 
-	db, err := sqlx.Open("pgx", dbURL)
+	db, err := sqlx.Open(d.DriverName(), dbURL)
 	if err != nil {
 		// This will not be a connection error, but a DSN parse error or
 		// another initialization error.
@@ -54,9 +70,31 @@ func NewDB() {
 	db.DB.SetMaxIdleConns(conf.Conn.MaxIdle)
 	db.DB.SetConnMaxLifetime(conf.Conn.LifeTime * time.Second)
 
+	// an in memory sqlite db lives as long as its only connection
+	if d.Name() == dialect.SQLite().Name() && conf.SQLite.File == ":memory:" {
+		db.DB.SetMaxOpenConns(1)
+		db.DB.SetConnMaxLifetime(0)
+	}
+
 	appDB = db
 }
 
+// dataSourceName creates the connection string of the dialect from config.
+func dataSourceName(d dialect.Dialect) string {
+	if d.Name() == dialect.SQLite().Name() {
+		// relative files are under the application root, foreign keys are not enforced by sqlite unless enabled
+		file := conf.SQLite.File
+		if file != ":memory:" && !filepath.IsAbs(file) {
+			file = config.AppPath(file)
+		}
+		return fmt.Sprintf("file:%s?_foreign_keys=1&_busy_timeout=5000", file)
+	}
+
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
+		conf.Server.UserName, conf.Server.Password,
+		conf.Server.Host, conf.Server.Port, conf.Server.Dbname)
+}
+
 // WithDB creates the db layer with pre-initialized db.
 func WithDB(db *sql.DB, driverName string) {
 	appDB = sqlx.NewDb(db, driverName)
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	TxCtxKey = "dbTransaction"
+)
+
+// TxManager runs units of work inside a db transaction.
+type TxManager interface {
+	// WithinTx runs fn inside a transaction that is committed when fn returns without error.
+	// If the given context already carries a transaction, fn simply joins it.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// sqlTxManager is the TxManager implementation that uses the sqlx db of the application.
+type sqlTxManager struct {
+	db *sqlx.DB
+}
+
+// NewTxManager creates a new TxManager that starts transactions on the application db.
+func NewTxManager() TxManager {
+	return sqlTxManager{appDB}
+}
+
+// NewTxManagerFor creates a new TxManager that starts transactions on the given db, such as the db of a repository.
+func NewTxManagerFor(db *sqlx.DB) TxManager {
+	return sqlTxManager{db}
+}
+
+func (this sqlTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	// nested call, the outermost caller owns commit and rollback
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := this.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, TxCtxKey, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %s)", err, rbErr.Error())
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TxFromContext gets the active transaction from given context, if there is any.
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	tx, ok := ctx.Value(TxCtxKey).(*sqlx.Tx)
+	return tx, ok
+}
+
+// Executor returns the active transaction of the context or falls back to the given db.
+func Executor(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
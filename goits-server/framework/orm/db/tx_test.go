@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithinTx_Success_Commits(t *testing.T) {
+	// given
+	mock := newTxMock(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var inTx bool
+
+	// when
+	err := NewTxManager().WithinTx(context.Background(), func(ctx context.Context) error {
+		_, inTx = TxFromContext(ctx)
+		return nil
+	})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.True(t, inTx, "context should carry the transaction")
+	assert.Nil(t, mock.ExpectationsWereMet(), "transaction should be committed")
+}
+
+func TestWithinTx_Error_RollsBack(t *testing.T) {
+	// given
+	mock := newTxMock(t)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	expectedErr := errors.New("sql: error")
+
+	// when
+	err := NewTxManager().WithinTx(context.Background(), func(ctx context.Context) error {
+		return expectedErr
+	})
+
+	// then
+	assert.Equal(t, expectedErr, err, "should return the error of the unit of work")
+	assert.Nil(t, mock.ExpectationsWereMet(), "transaction should be rolled back")
+}
+
+func TestWithinTx_Panic_RollsBack(t *testing.T) {
+	// given
+	mock := newTxMock(t)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	// when
+	assert.Panics(t, func() {
+		NewTxManager().WithinTx(context.Background(), func(ctx context.Context) error {
+			panic("failure")
+		})
+	}, "panic should be propagated")
+
+	// then
+	assert.Nil(t, mock.ExpectationsWereMet(), "transaction should be rolled back")
+}
+
+func TestWithinTx_Nested_JoinsOuterTx(t *testing.T) {
+	// given
+	mock := newTxMock(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	txm := NewTxManager()
+
+	// when
+	err := txm.WithinTx(context.Background(), func(outer context.Context) error {
+		outerTx, _ := TxFromContext(outer)
+		return txm.WithinTx(outer, func(inner context.Context) error {
+			innerTx, _ := TxFromContext(inner)
+			assert.Same(t, outerTx, innerTx, "nested call should use the same transaction")
+			return nil
+		})
+	})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "only one transaction should be started")
+}
+
+func TestExecutor_NoTx_UsesDB(t *testing.T) {
+	// given
+	newTxMock(t)
+
+	// when
+	ext := Executor(context.Background(), DB())
+
+	// then
+	assert.Equal(t, DB(), ext, "should fall back to db")
+}
+
+func newTxMock(t *testing.T) sqlmock.Sqlmock {
+	mockDB, mock, err := sqlmock.New()
+	assert.Nil(t, err, "could not create mock db")
+	WithDB(mockDB, "sqlmock")
+
+	t.Cleanup(func() {
+		mockDB.Close()
+	})
+
+	return mock
+}
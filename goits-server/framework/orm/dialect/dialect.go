@@ -0,0 +1,76 @@
+package dialect
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Dialect renders the parts of sql statements that differ between databases.
+type Dialect interface {
+	// Name is the name of the dialect as given in config.
+	Name() string
+
+	// DriverName is the name of the database/sql driver of the dialect.
+	DriverName() string
+
+	// Placeholder returns the positional parameter with the given 1 based index.
+	Placeholder(idx int) string
+
+	// Table returns the name a table in the given schema is referred to in statements.
+	Table(schema string, table string) string
+
+	// Now returns the expression of the current time.
+	Now() string
+
+	// ILike returns the condition that matches the column against the pattern given as a parameter ignoring case.
+	ILike(column string, param string) string
+
+	// Paging returns the clause that limits the rows of a query to the given page.
+	Paging(limit uint, offset uint64) string
+
+	// Returning returns the clause that returns the given columns of the rows changed by a statement.
+	Returning(columns []string) string
+
+	// AnyOf returns the condition that matches the column against the values of a list given as a single parameter.
+	AnyOf(column string, idx int) string
+
+	// List converts the values to the single parameter used by AnyOf.
+	List(values []uint64) driver.Valuer
+
+	// MaxParams is the maximum number of parameters a single statement can have.
+	MaxParams() int
+}
+
+var dialects map[string]Dialect
+var current Dialect
+
+func init() {
+	dialects = make(map[string]Dialect)
+	register(Postgres())
+	register(SQLite())
+
+	current = Postgres()
+}
+
+func register(d Dialect) {
+	dialects[d.Name()] = d
+}
+
+// ByName finds the dialect by its name.
+func ByName(name string) (Dialect, error) {
+	d, found := dialects[name]
+	if !found {
+		return nil, fmt.Errorf("dialect %s is not supported", name)
+	}
+	return d, nil
+}
+
+// Use sets the dialect the statements are rendered with, which should be done before any repository is created.
+func Use(d Dialect) {
+	current = d
+}
+
+// Current returns the dialect in use, which is postgres unless another one is set.
+func Current() Dialect {
+	return current
+}
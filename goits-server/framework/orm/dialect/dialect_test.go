@@ -0,0 +1,56 @@
+package dialect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByName_Supported(t *testing.T) {
+	// when
+	d, err := ByName("sqlite")
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "sqlite3", d.DriverName(), "dialect is not correct")
+}
+
+func TestByName_Unknown_Error(t *testing.T) {
+	// when
+	_, err := ByName("oracle")
+
+	// then
+	assert.NotNil(t, err, "unknown dialect should not be found")
+}
+
+func TestPostgres(t *testing.T) {
+	// given
+	d := Postgres()
+
+	// when
+	list, err := d.List([]uint64{1, 2}).Value()
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "{1,2}", list, "list is not correct")
+	assert.Equal(t, "$2", d.Placeholder(2), "placeholder is not correct")
+	assert.Equal(t, "data.project", d.Table("data", "project"), "table is not correct")
+	assert.Equal(t, "id = ANY($1)", d.AnyOf("id", 1), "any of is not correct")
+	assert.Equal(t, "name ilike $1", d.ILike("name", "$1"), "ilike is not correct")
+}
+
+func TestSQLite(t *testing.T) {
+	// given
+	d := SQLite()
+
+	// when
+	list, err := d.List([]uint64{1, 2}).Value()
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "[1,2]", list, "list is not correct")
+	assert.Equal(t, "?2", d.Placeholder(2), "placeholder is not correct")
+	assert.Equal(t, "project", d.Table("data", "project"), "table is not correct")
+	assert.Equal(t, "id in (select value from json_each(?1))", d.AnyOf("id", 1), "any of is not correct")
+	assert.Equal(t, "name like ?1", d.ILike("name", "?1"), "ilike is not correct")
+}
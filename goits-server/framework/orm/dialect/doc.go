@@ -0,0 +1,2 @@
+// Package dialect contains the differences of the sql of supported databases, which the query builders render through.
+package dialect
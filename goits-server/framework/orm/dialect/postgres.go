@@ -0,0 +1,70 @@
+package dialect
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type postgres struct{}
+
+// Postgres returns the dialect of postgres, which is used with the pgx driver.
+func Postgres() Dialect {
+	return postgres{}
+}
+
+func (this postgres) Name() string {
+	return "postgres"
+}
+
+func (this postgres) DriverName() string {
+	return "pgx"
+}
+
+func (this postgres) Placeholder(idx int) string {
+	return fmt.Sprintf("$%d", idx)
+}
+
+func (this postgres) Table(schema string, table string) string {
+	return fmt.Sprintf("%s.%s", schema, table)
+}
+
+func (this postgres) Now() string {
+	return "now()"
+}
+
+func (this postgres) ILike(column string, param string) string {
+	return fmt.Sprintf("%s ilike %s", column, param)
+}
+
+func (this postgres) Paging(limit uint, offset uint64) string {
+	return fmt.Sprintf("limit %d offset %d", limit, offset)
+}
+
+func (this postgres) Returning(columns []string) string {
+	return "returning " + strings.Join(columns, ", ")
+}
+
+func (this postgres) AnyOf(column string, idx int) string {
+	return fmt.Sprintf("%s = ANY(%s)", column, this.Placeholder(idx))
+}
+
+func (this postgres) List(values []uint64) driver.Valuer {
+	return pgArray(values)
+}
+
+func (this postgres) MaxParams() int {
+	return 65535
+}
+
+// pgArray is a list of ids that is given to a statement as a single postgres array parameter.
+type pgArray []uint64
+
+func (this pgArray) Value() (driver.Value, error) {
+	ids := make([]string, len(this))
+	for i, id := range this {
+		ids[i] = strconv.FormatUint(id, 10)
+	}
+	return "{" + strings.Join(ids, ",") + "}", nil
+}
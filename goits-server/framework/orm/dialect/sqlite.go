@@ -0,0 +1,74 @@
+package dialect
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type sqlite struct{}
+
+// SQLite returns the dialect of sqlite, which is used with the go-sqlite3 driver.
+// SQLite has no schemas, so tables are referred to by their names only and they must be unique across schemas.
+// Lists are given as json arrays, and returning clauses require sqlite 3.35 or later.
+func SQLite() Dialect {
+	return sqlite{}
+}
+
+func (this sqlite) Name() string {
+	return "sqlite"
+}
+
+func (this sqlite) DriverName() string {
+	return "sqlite3"
+}
+
+// Placeholder uses numbered parameters, since plain ones are bound in the order they appear in the statement.
+func (this sqlite) Placeholder(idx int) string {
+	return fmt.Sprintf("?%d", idx)
+}
+
+func (this sqlite) Table(schema string, table string) string {
+	return table
+}
+
+func (this sqlite) Now() string {
+	return "current_timestamp"
+}
+
+// ILike uses like, which already ignores the case of ascii characters in sqlite.
+func (this sqlite) ILike(column string, param string) string {
+	return fmt.Sprintf("%s like %s", column, param)
+}
+
+func (this sqlite) Paging(limit uint, offset uint64) string {
+	return fmt.Sprintf("limit %d offset %d", limit, offset)
+}
+
+func (this sqlite) Returning(columns []string) string {
+	return "returning " + strings.Join(columns, ", ")
+}
+
+func (this sqlite) AnyOf(column string, idx int) string {
+	return fmt.Sprintf("%s in (select value from json_each(%s))", column, this.Placeholder(idx))
+}
+
+func (this sqlite) List(values []uint64) driver.Valuer {
+	return jsonArray(values)
+}
+
+func (this sqlite) MaxParams() int {
+	return 32766
+}
+
+// jsonArray is a list of ids that is given to a statement as a single json array parameter.
+type jsonArray []uint64
+
+func (this jsonArray) Value() (driver.Value, error) {
+	ids := make([]string, len(this))
+	for i, id := range this {
+		ids[i] = strconv.FormatUint(id, 10)
+	}
+	return "[" + strings.Join(ids, ",") + "]", nil
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"reflect"
+)
+
+// Snapshot copies the current state of the entity to compare with its later states by ChangedFields.
+// The copy is shallow, so reference typed fields like slices are shared with the entity.
+func Snapshot(entity interface{}) interface{} {
+	return reflect.Indirect(reflect.ValueOf(entity)).Interface()
+}
+
+// ChangedFields compares two states of the same entity and returns the names of the fields that differ.
+// Fields of embedded structs are compared as fields of the entity itself, in the same way they are mapped to columns.
+func ChangedFields(before interface{}, after interface{}) []string {
+	vb := reflect.Indirect(reflect.ValueOf(before))
+	va := reflect.Indirect(reflect.ValueOf(after))
+	if vb.Kind() != reflect.Struct || vb.Type() != va.Type() {
+		return nil
+	}
+
+	var changed []string
+	collectChanges(vb, va, &changed)
+	return changed
+}
+
+func collectChanges(before reflect.Value, after reflect.Value, changed *[]string) {
+	t := before.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			collectChanges(before.Field(i), after.Field(i), changed)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if !reflect.DeepEqual(before.Field(i).Interface(), after.Field(i).Interface()) {
+			*changed = append(*changed, f.Name)
+		}
+	}
+}
@@ -1,16 +1,21 @@
 package domain
 
 import (
+	"reflect"
+	"sort"
+
 	"github.com/cpekyaman/goits/framework/monitoring"
 	"github.com/cpekyaman/goits/framework/orm/metadata"
 )
 
 var entityMetaData map[string]metadata.EntityDef
+var entityTypes map[string]reflect.Type
 var defaultNonInsertableColumns map[string]bool
 var defaultNonUpdatableColumns map[string]bool
 
 func init() {
 	entityMetaData = make(map[string]metadata.EntityDef)
+	entityTypes = make(map[string]reflect.Type)
 
 	defaultNonInsertableColumns = map[string]bool{
 		"Id":               true,
@@ -50,7 +55,39 @@ func RegisterEntityConfig(module string) {
 	} else {
 		for _, v := range ed {
 			monitoring.RootLogger().With(monitoring.StrLogField("domainType", v.Name())).Info("Registering EntityDef")
-			entityMetaData[v.Name()] = v
+			RegisterEntityDef(v)
 		}
 	}
 }
+
+// RegisterEntityDef registers the given entity metadata by its fully qualified type name.
+func RegisterEntityDef(ed metadata.EntityDef) {
+	entityMetaData[ed.Name()] = ed
+}
+
+// EntityDefs returns all registered entity metadata ordered by name.
+func EntityDefs() []metadata.EntityDef {
+	defs := make([]metadata.EntityDef, 0, len(entityMetaData))
+	for _, ed := range entityMetaData {
+		defs = append(defs, ed)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Name() < defs[j].Name()
+	})
+	return defs
+}
+
+// RegisterEntityType registers the type of the given example entity by its fully qualified type name,
+// so that the mapping of the entity can be introspected without creating a repository.
+func RegisterEntityType(name string, example interface{}) {
+	entityTypes[name] = reflect.Indirect(reflect.ValueOf(example)).Type()
+}
+
+// NewEntityOf creates a new zero entity of the type registered by the given name, which is nil if the type is not registered.
+func NewEntityOf(name string) interface{} {
+	t, found := entityTypes[name]
+	if !found {
+		return nil
+	}
+	return reflect.New(t).Interface()
+}
@@ -3,10 +3,15 @@ package metadata
 import (
 	"reflect"
 	"sort"
+	"strings"
+	"sync"
 )
 
 var columnMapperRegistry map[string]ColumnMapper
 
+// columnMapperMutex guards the registry, since repositories can be created while requests read it.
+var columnMapperMutex sync.RWMutex
+
 func init() {
 	columnMapperRegistry = make(map[string]ColumnMapper)
 }
@@ -20,6 +25,12 @@ type ColumnMapper interface {
 	Fields() []string
 
 	Columns() []string
+
+	// FieldByJSONName finds the field that is serialized with the given json name.
+	FieldByJSONName(name string) (string, bool)
+
+	// FieldType returns the go type of the field.
+	FieldType(field string) reflect.Type
 }
 
 // fieldMapColumnMapper is a ColumnMapper that uses a simple field-column map.
@@ -27,6 +38,8 @@ type fieldMapColumnMapper struct {
 	fieldMap       map[string]string
 	orderedFields  []string
 	orderedColumns []string
+	jsonMap        map[string]string
+	typeMap        map[string]reflect.Type
 }
 
 func (this fieldMapColumnMapper) HasColumn(field string) bool {
@@ -46,8 +59,20 @@ func (this fieldMapColumnMapper) Columns() []string {
 	return this.orderedColumns
 }
 
+func (this fieldMapColumnMapper) FieldByJSONName(name string) (string, bool) {
+	f, ok := this.jsonMap[name]
+	return f, ok
+}
+
+func (this fieldMapColumnMapper) FieldType(field string) reflect.Type {
+	return this.typeMap[field]
+}
+
 // GetColumnMapper returns an already registered ColumnMapper for the entity represented by provided metadata.
 func GetColumnMapper(ed EntityDef) (ColumnMapper, bool) {
+	columnMapperMutex.RLock()
+	defer columnMapperMutex.RUnlock()
+
 	cm, found := columnMapperRegistry[ed.Name()]
 	return cm, found
 }
@@ -59,10 +84,11 @@ func NewColumnMapper(ed EntityDef, introspect interface{}) ColumnMapper {
 		v = v.Elem()
 	}
 
-	fieldMap := make(map[string]string)
+	fm := newFieldMaps()
 	if v.Kind() == reflect.Struct {
-		buildFieldMap(fieldMap, v)
+		buildFieldMap(fm, v)
 	}
+	fieldMap := fm.columns
 
 	// trying to have a deterministic order of columns in generated statements
 	orderedFields := make([]string, len(fieldMap))
@@ -76,38 +102,74 @@ func NewColumnMapper(ed EntityDef, introspect interface{}) ColumnMapper {
 	sort.Strings(orderedFields)
 	sort.Strings(orderedColumns)
 
-	cm := fieldMapColumnMapper{fieldMap, orderedFields, orderedColumns}
+	cm := fieldMapColumnMapper{fieldMap, orderedFields, orderedColumns, fm.json, fm.types}
+	columnMapperMutex.Lock()
 	columnMapperRegistry[ed.Name()] = cm
+	columnMapperMutex.Unlock()
 	return cm
 }
 
+// fieldMaps keeps the column, json name and type information of fields collected during introspection.
+type fieldMaps struct {
+	columns map[string]string
+	json    map[string]string
+	types   map[string]reflect.Type
+}
+
+func newFieldMaps() fieldMaps {
+	return fieldMaps{
+		columns: make(map[string]string),
+		json:    make(map[string]string),
+		types:   make(map[string]reflect.Type),
+	}
+}
+
 // buildFieldMap builds a map of field names and their corresponding column names by reflecting on value.
-func buildFieldMap(fieldMap map[string]string, v reflect.Value) {
+func buildFieldMap(fm fieldMaps, v reflect.Value) {
 	for i := 0; i < v.NumField(); i++ {
 		f := v.Type().Field(i)
 
+		// fields excluded from db, such as the ones holding loaded relations, are not mapped
+		if f.Tag.Get("db") == "-" {
+			continue
+		}
+
 		switch f.Type.Kind() {
 		case reflect.Struct:
 			if f.Anonymous {
-				buildFieldMap(fieldMap, v.Field(i))
+				buildFieldMap(fm, v.Field(i))
 			} else {
-				addField(f, fieldMap)
+				addField(f, fm)
 			}
 		case reflect.Ptr:
-			buildFieldMap(fieldMap, v.Elem())
+			if f.Anonymous && f.Type.Elem().Kind() == reflect.Struct {
+				buildFieldMap(fm, reflect.New(f.Type.Elem()).Elem())
+			} else {
+				addField(f, fm)
+			}
 		default:
-			addField(f, fieldMap)
+			addField(f, fm)
 		}
 	}
 }
 
-// addField adds the mapping of given field to fieldMap.
-func addField(f reflect.StructField, fieldMap map[string]string) {
+// addField adds the mapping of given field to field maps.
+func addField(f reflect.StructField, fm fieldMaps) {
 	fieldName := f.Name
 	colName, found := f.Tag.Lookup("db")
 	if found {
-		fieldMap[fieldName] = colName
+		fm.columns[fieldName] = colName
 	} else {
-		fieldMap[fieldName] = fieldName
+		fm.columns[fieldName] = fieldName
+	}
+
+	jsonName := fieldName
+	if tag, found := f.Tag.Lookup("json"); found {
+		jsonName = strings.Split(tag, ",")[0]
 	}
+	if jsonName != "-" {
+		fm.json[jsonName] = fieldName
+	}
+
+	fm.types[fieldName] = f.Type
 }
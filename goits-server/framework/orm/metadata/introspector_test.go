@@ -1,6 +1,7 @@
 package metadata
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,3 +34,71 @@ func TestColumnMapper(t *testing.T) {
 	colName := cm.Column("CreateTime")
 	assert.Equal(t, "create_time", colName, "not correct column name")
 }
+
+type JSONIntrospectTestEntity struct {
+	Name    string `json:"name,omitempty" db:"name"`
+	Age     uint32 `db:"age"`
+	Secret  string `json:"-" db:"secret"`
+	Country string `json:"country" db:"country_code"`
+}
+
+func TestColumnMapper_JSONNamesAndTypes(t *testing.T) {
+	// given
+	target := &JSONIntrospectTestEntity{}
+
+	// when
+	cm := NewColumnMapper(ormEntityDef{Name_: "JSONIntrospectTestEntity"}, target)
+
+	// then
+	field, found := cm.FieldByJSONName("name")
+	assert.True(t, found, "name should be found")
+	assert.Equal(t, "Name", field, "json name should map to field")
+
+	field, found = cm.FieldByJSONName("Age")
+	assert.True(t, found, "field without json tag should be found by its name")
+	assert.Equal(t, "Age", field, "field name should be used as json name")
+
+	_, found = cm.FieldByJSONName("Secret")
+	assert.False(t, found, "ignored json field should not be found")
+
+	assert.Equal(t, reflect.TypeOf(uint32(0)), cm.FieldType("Age"), "field type is not correct")
+	assert.Nil(t, cm.FieldType("Unknown"), "unknown field should have no type")
+}
+
+type RelationIntrospectTestEntity struct {
+	Name     string                     `json:"name" db:"name"`
+	Owner    uint64                     `json:"owner" db:"owner"`
+	OwnerRef *IntrospectTestEntity      `json:"ownerRef,omitempty" db:"-"`
+	Children []JSONIntrospectTestEntity `json:"children,omitempty" db:"-"`
+}
+
+func TestColumnMapper_IgnoredFields(t *testing.T) {
+	// given
+	target := &RelationIntrospectTestEntity{}
+
+	// when
+	cm := NewColumnMapper(ormEntityDef{Name_: "RelationIntrospectTestEntity"}, target)
+
+	// then
+	assert.Equal(t, []string{"Name", "Owner"}, cm.Fields(), "fields excluded from db should not be mapped")
+	assert.False(t, cm.HasColumn("OwnerRef"), "relation field should not have a column")
+
+	_, found := cm.FieldByJSONName("children")
+	assert.False(t, found, "relation field should not be found by json name")
+}
+
+func TestEntityDef_Relation(t *testing.T) {
+	// given
+	ed := NewEntityDef("RelationIntrospectTestEntity", "data", "rel", "id", "name asc", false,
+		RelationDef{Name: "Owner", Kind: ManyToOne, Target: "IntrospectTestEntity", ForeignKey: "Owner", Field: "OwnerRef"})
+
+	// when
+	rel, found := ed.Relation("owner")
+
+	// then
+	assert.True(t, found, "relation should be found ignoring case")
+	assert.Equal(t, "OwnerRef", rel.Field, "relation is not correct")
+
+	_, found = ed.Relation("Children")
+	assert.False(t, found, "undeclared relation should not be found")
+}
@@ -2,6 +2,7 @@ package metadata
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/cpekyaman/goits/config"
 )
@@ -22,16 +23,69 @@ type EntityDef interface {
 	PKColumn() string
 	DefaultSort() string
 	SoftDelete() bool
+
+	// Relations returns the relations of the entity to other entities.
+	Relations() []RelationDef
+
+	// Relation finds the relation by its name, ignoring case.
+	Relation(name string) (RelationDef, bool)
+
+	// UniqueKey returns the columns of the natural key of the entity, which is the conflict target of upserts.
+	UniqueKey() []string
+}
+
+// RelationKind is the cardinality of a relation from the point of view of the owning entity.
+type RelationKind string
+
+const (
+	// ManyToOne relates the entity to a single target entity by a foreign key field of the entity.
+	ManyToOne RelationKind = "manyToOne"
+
+	// OneToMany relates the entity to a list of target entities by a foreign key field of the targets.
+	OneToMany RelationKind = "oneToMany"
+)
+
+// RelationDef is the metadata of a relation that can be loaded along with the entity.
+type RelationDef struct {
+	Name string       `mapstructure:"name"`
+	Kind RelationKind `mapstructure:"kind"`
+
+	// Target is the fully qualified type name of the related entity.
+	Target string `mapstructure:"target"`
+
+	// ForeignKey is the field holding the id of the other side, which is a field of the entity for many-to-one
+	// and a field of the target for one-to-many relations.
+	ForeignKey string `mapstructure:"foreignKey"`
+
+	// Field is the field of the entity the related entities are loaded into, which must not be mapped to a column.
+	Field string `mapstructure:"field"`
+}
+
+// NewEntityDef creates an EntityDef with the given values instead of reading them from orm config.
+func NewEntityDef(name string, schema string, table string, pkColumn string, defaultSort string, softDelete bool, relations ...RelationDef) EntityDef {
+	return ormEntityDef{name, schema, table, pkColumn, defaultSort, softDelete, relations, nil}
+}
+
+// WithUniqueKey returns a copy of the EntityDef created by NewEntityDef with the given unique key columns.
+func WithUniqueKey(ed EntityDef, columns ...string) EntityDef {
+	oed, ok := ed.(ormEntityDef)
+	if !ok {
+		return ed
+	}
+	oed.UniqueKey_ = columns
+	return oed
 }
 
 // ormEntityDef is the package private implementation for EntityDef.
 type ormEntityDef struct {
-	Name_        string `mapstructure:"name"`
-	Schema_      string `mapstructure:"schema"`
-	Table_       string `mapstructure:"table"`
-	PkColumn_    string `mapstructure:"pkColumn"`
-	DefaultSort_ string `mapstructure:"defaultSort"`
-	SoftDelete_  bool   `mapstructure:"softDelete"`
+	Name_        string        `mapstructure:"name"`
+	Schema_      string        `mapstructure:"schema"`
+	Table_       string        `mapstructure:"table"`
+	PkColumn_    string        `mapstructure:"pkColumn"`
+	DefaultSort_ string        `mapstructure:"defaultSort"`
+	SoftDelete_  bool          `mapstructure:"softDelete"`
+	Relations_   []RelationDef `mapstructure:"relations"`
+	UniqueKey_   []string      `mapstructure:"uniqueKey"`
 }
 
 func (this ormEntityDef) Name() string {
@@ -55,3 +109,17 @@ func (this ormEntityDef) DefaultSort() string {
 func (this ormEntityDef) SoftDelete() bool {
 	return this.SoftDelete_
 }
+func (this ormEntityDef) Relations() []RelationDef {
+	return this.Relations_
+}
+func (this ormEntityDef) Relation(name string) (RelationDef, bool) {
+	for _, r := range this.Relations_ {
+		if strings.EqualFold(r.Name, name) {
+			return r, true
+		}
+	}
+	return RelationDef{}, false
+}
+func (this ormEntityDef) UniqueKey() []string {
+	return this.UniqueKey_
+}
@@ -2,45 +2,98 @@ package query
 
 import (
 	"fmt"
-	"strings"
 
+	"github.com/cpekyaman/goits/framework/orm/dialect"
 	"github.com/cpekyaman/goits/framework/orm/metadata"
 )
 
 // BuildQueryByAttributes builds a select query by using provided attribute map as criteria.
-func BuildQueryByAttributes(ed metadata.EntityDef, qd QueryDef, cm metadata.ColumnMapper, attrs map[string]interface{}, limit uint, offset uint64) (string, []interface{}) {
-	where, params := BuildCriteria(ed, qd, cm, attrs)
+func BuildQueryByAttributes(ed metadata.EntityDef, qd QueryDef, cm metadata.ColumnMapper, attrs map[string]interface{}, limit uint, offset uint64) (string, []interface{}, error) {
+	return BuildQueryByCriteria(ed, qd, cm, AttributesCriteria(attrs), limit, offset)
+}
+
+// BuildQueryByCriteria builds a select query with the where part rendered from given criteria.
+// The query is paged if a non-zero limit is given.
+func BuildQueryByCriteria(ed metadata.EntityDef, qd QueryDef, cm metadata.ColumnMapper, c Criteria, limit uint, offset uint64, opts ...Option) (string, []interface{}, error) {
+	o := NewOptions(opts...)
+
+	where, params, err := BuildWhere(cm, c, 1)
+	if err != nil {
+		return "", nil, err
+	}
+	where = filterDeleted(ed, where, o)
+
+	orderBy, err := BuildOrderBy(ed, cm, o.Sort)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var q string
+	if where == "" {
+		q = fmt.Sprintf(findAllTemplate, qd.SelectColumns(), tableName(ed), orderBy)
+	} else {
+		q = fmt.Sprintf(findAllByAttributesTemplate, qd.SelectColumns(), tableName(ed), where, orderBy)
+	}
 
-	template := findAllByAttributesTemplate
 	if limit > 0 {
-		template = findAllByAttributesPagedTemplate
+		q = fmt.Sprintf(pagingTemplate, q, dialect.Current().Paging(limit, offset))
+	}
+	return q, params, nil
+}
+
+// BuildCountQueryByCriteria builds a query that counts the rows matching the given criteria.
+func BuildCountQueryByCriteria(ed metadata.EntityDef, cm metadata.ColumnMapper, c Criteria, opts ...Option) (string, []interface{}, error) {
+	where, params, err := BuildWhere(cm, c, 1)
+	if err != nil {
+		return "", nil, err
 	}
+	where = filterDeleted(ed, where, NewOptions(opts...))
 
-	return fmt.Sprintf(template, qd.SelectColumns(), ed.FullTableName(), where, ed.DefaultSort()), params
+	if where == "" {
+		return fmt.Sprintf(countTemplate, tableName(ed)), nil, nil
+	}
+	return fmt.Sprintf(countByCriteriaTemplate, tableName(ed), where), params, nil
 }
 
 // BuildCriteria builds the where fragment of the select query by using provided attributes and their values.
-func BuildCriteria(ed metadata.EntityDef, qd QueryDef, cm metadata.ColumnMapper, attrs map[string]interface{}) (string, []interface{}) {
-	var criteria []string
-	var params []interface{}
-	var idx = 1
-	for k, v := range attrs {
-		if cm.HasColumn(k) {
-			criteria = append(criteria, fmt.Sprintf("%s=$%d", cm.Column(k), idx))
-			params = append(params, v)
-			idx++
-		}
-	}
-	return strings.Join(criteria, " AND "), params
+// An error is returned if any of the attributes is not a mapped field of the entity.
+func BuildCriteria(ed metadata.EntityDef, qd QueryDef, cm metadata.ColumnMapper, attrs map[string]interface{}) (string, []interface{}, error) {
+	return BuildWhere(cm, AttributesCriteria(attrs), 1)
 }
 
 // BuildFindOneQuery builds a single row select query by using attr as the only criteria.
 // It is expected that attr corresponds to a unique column (by definition or in practice).
-func BuildFindOneQuery(ed metadata.EntityDef, qd QueryDef, cm metadata.ColumnMapper, attr string) string {
-	return fmt.Sprintf(findOneByAttributeTemplate, qd.SelectColumns(), ed.FullTableName(), cm.Column(attr))
+func BuildFindOneQuery(ed metadata.EntityDef, qd QueryDef, cm metadata.ColumnMapper, attr string, opts ...Option) string {
+	return buildFindOneQuery(ed, qd, cm.Column(attr), NewOptions(opts...))
+}
+
+// BuildFindOneByIdQuery builds the single row select query by primary key, which is the same as FindOne of the QueryDef
+// unless the options include soft deleted rows.
+func BuildFindOneByIdQuery(ed metadata.EntityDef, qd QueryDef, opts ...Option) string {
+	return buildFindOneQuery(ed, qd, ed.PKColumn(), NewOptions(opts...))
+}
+
+// buildFindOneQuery builds the single row select by column, excluding soft deleted rows in the same way as filterDeleted does.
+func buildFindOneQuery(ed metadata.EntityDef, qd QueryDef, column string, o Options) string {
+	stmt := fmt.Sprintf(findOneByAttributeTemplate, qd.SelectColumns(), tableName(ed), column, placeholder(1))
+	if o.IncludeDeleted {
+		return stmt
+	}
+	return WithNotDeleted(ed, stmt)
 }
 
 // BuildFindAllPagedQuery builds the paging on top of default find all query for the given offset and limit values.
 func BuildFindAllPagedQuery(ed metadata.EntityDef, qd QueryDef, limit uint, offset uint64) string {
-	return fmt.Sprintf(findAllPagedTemplate, qd.SelectColumns(), ed.FullTableName(), ed.DefaultSort(), limit, offset)
+	return fmt.Sprintf(pagingTemplate, qd.FindAll(), dialect.Current().Paging(limit, offset))
+}
+
+// filterDeleted adds the condition that excludes soft deleted rows to the where fragment, unless they are requested.
+func filterDeleted(ed metadata.EntityDef, where string, o Options) string {
+	if !ed.SoftDelete() || o.IncludeDeleted {
+		return where
+	}
+	if where == "" {
+		return notDeletedCondition
+	}
+	return fmt.Sprintf("(%s) AND %s", where, notDeletedCondition)
 }
@@ -0,0 +1,281 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+)
+
+// Operator is the comparison operator of a single field criteria.
+type Operator uint8
+
+var operators = [...]string{"=", "<>", "<", "<=", ">", ">=", "in", "like", "ilike", "is null", "is not null", "between"}
+
+const (
+	OpEq Operator = iota
+	OpNe
+	OpLt
+	OpLe
+	OpGt
+	OpGe
+	OpIn
+	OpLike
+	OpILike
+	OpIsNull
+	OpIsNotNull
+	OpBetween
+)
+
+func (this Operator) String() string {
+	return operators[this]
+}
+
+// UnknownFieldError is returned when a criteria refers to a field that is not mapped to a column.
+type UnknownFieldError struct {
+	Field string
+}
+
+func (this UnknownFieldError) Error() string {
+	return fmt.Sprintf("criteria: unknown field %s", this.Field)
+}
+
+// UnknownRelationError is returned when a relation to be included is not declared for the entity.
+type UnknownRelationError struct {
+	Relation string
+}
+
+func (this UnknownRelationError) Error() string {
+	return fmt.Sprintf("criteria: unknown relation %s", this.Relation)
+}
+
+// Criteria is a node of a criteria tree which is rendered as the where fragment of a query.
+// Criteria always refer to entity fields, the actual columns are resolved via a ColumnMapper.
+type Criteria interface {
+	// Fields returns the names of all fields referenced by the criteria tree.
+	Fields() []string
+
+	build(b *criteriaBuilder) error
+}
+
+// fieldCriteria is a single condition on a field.
+type fieldCriteria struct {
+	field  string
+	op     Operator
+	values []interface{}
+}
+
+func (this fieldCriteria) Fields() []string {
+	return []string{this.field}
+}
+
+func (this fieldCriteria) build(b *criteriaBuilder) error {
+	col, err := b.column(this.field)
+	if err != nil {
+		return err
+	}
+
+	switch this.op {
+	case OpIsNull, OpIsNotNull:
+		b.write(fmt.Sprintf("%s %s", col, this.op))
+	case OpIn:
+		if len(this.values) == 0 {
+			// nothing can be in an empty set
+			b.write("1=0")
+			return nil
+		}
+		ph := make([]string, len(this.values))
+		for i, v := range this.values {
+			ph[i] = b.param(v)
+		}
+		b.write(fmt.Sprintf("%s in (%s)", col, strings.Join(ph, ", ")))
+	case OpILike:
+		b.write(dialect.Current().ILike(col, b.param(this.values[0])))
+	case OpBetween:
+		b.write(fmt.Sprintf("%s between %s and %s", col, b.param(this.values[0]), b.param(this.values[1])))
+	default:
+		b.write(fmt.Sprintf("%s %s %s", col, this.op, b.param(this.values[0])))
+	}
+	return nil
+}
+
+// groupCriteria combines its children with the same logical operator.
+type groupCriteria struct {
+	conj     string
+	children []Criteria
+}
+
+func (this groupCriteria) Fields() []string {
+	var fields []string
+	for _, c := range this.children {
+		fields = append(fields, c.Fields()...)
+	}
+	return fields
+}
+
+func (this groupCriteria) build(b *criteriaBuilder) error {
+	if len(this.children) == 1 {
+		return this.children[0].build(b)
+	}
+
+	for i, c := range this.children {
+		if i > 0 {
+			b.write(" " + this.conj + " ")
+		}
+		_, nested := c.(groupCriteria)
+		if nested {
+			b.write("(")
+		}
+		if err := c.build(b); err != nil {
+			return err
+		}
+		if nested {
+			b.write(")")
+		}
+	}
+	return nil
+}
+
+// Eq creates a criteria that matches when field is equal to value.
+func Eq(field string, value interface{}) Criteria {
+	return fieldCriteria{field, OpEq, []interface{}{value}}
+}
+
+// Ne creates a criteria that matches when field is not equal to value.
+func Ne(field string, value interface{}) Criteria {
+	return fieldCriteria{field, OpNe, []interface{}{value}}
+}
+
+// Lt creates a criteria that matches when field is less than value.
+func Lt(field string, value interface{}) Criteria {
+	return fieldCriteria{field, OpLt, []interface{}{value}}
+}
+
+// Le creates a criteria that matches when field is less than or equal to value.
+func Le(field string, value interface{}) Criteria {
+	return fieldCriteria{field, OpLe, []interface{}{value}}
+}
+
+// Gt creates a criteria that matches when field is greater than value.
+func Gt(field string, value interface{}) Criteria {
+	return fieldCriteria{field, OpGt, []interface{}{value}}
+}
+
+// Ge creates a criteria that matches when field is greater than or equal to value.
+func Ge(field string, value interface{}) Criteria {
+	return fieldCriteria{field, OpGe, []interface{}{value}}
+}
+
+// In creates a criteria that matches when field is equal to any of the values.
+func In(field string, values ...interface{}) Criteria {
+	return fieldCriteria{field, OpIn, values}
+}
+
+// Like creates a criteria that matches field against the sql pattern.
+func Like(field string, pattern string) Criteria {
+	return fieldCriteria{field, OpLike, []interface{}{pattern}}
+}
+
+// ILike creates a criteria that matches field against the sql pattern ignoring case.
+func ILike(field string, pattern string) Criteria {
+	return fieldCriteria{field, OpILike, []interface{}{pattern}}
+}
+
+// IsNull creates a criteria that matches when field has no value.
+func IsNull(field string) Criteria {
+	return fieldCriteria{field, OpIsNull, nil}
+}
+
+// IsNotNull creates a criteria that matches when field has a value.
+func IsNotNull(field string) Criteria {
+	return fieldCriteria{field, OpIsNotNull, nil}
+}
+
+// Between creates a criteria that matches when field is in the inclusive range of low and high.
+func Between(field string, low interface{}, high interface{}) Criteria {
+	return fieldCriteria{field, OpBetween, []interface{}{low, high}}
+}
+
+// And creates a criteria that matches when all of the given criteria match.
+func And(criteria ...Criteria) Criteria {
+	return newGroup("AND", criteria)
+}
+
+// Or creates a criteria that matches when any of the given criteria match.
+func Or(criteria ...Criteria) Criteria {
+	return newGroup("OR", criteria)
+}
+
+// newGroup creates a group by dropping nil or empty children so that they do not render as empty parentheses.
+func newGroup(conj string, criteria []Criteria) groupCriteria {
+	children := make([]Criteria, 0, len(criteria))
+	for _, c := range criteria {
+		if c == nil {
+			continue
+		}
+		if g, ok := c.(groupCriteria); ok && len(g.children) == 0 {
+			continue
+		}
+		children = append(children, c)
+	}
+	return groupCriteria{conj, children}
+}
+
+// AttributesCriteria converts an attribute map to a criteria that matches all attributes by equality.
+func AttributesCriteria(attrs map[string]interface{}) Criteria {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	// map iteration order is random, sorting keeps parameter order stable
+	sort.Strings(keys)
+
+	criteria := make([]Criteria, len(keys))
+	for i, k := range keys {
+		criteria[i] = Eq(k, attrs[k])
+	}
+	return And(criteria...)
+}
+
+// BuildWhere renders the criteria as a where fragment and returns it with its parameters.
+// Numbering of parameter placeholders starts from startIdx.
+// An empty fragment is returned if the criteria is nil or has no conditions.
+func BuildWhere(cm metadata.ColumnMapper, c Criteria, startIdx int) (string, []interface{}, error) {
+	if c == nil {
+		return "", nil, nil
+	}
+
+	b := &criteriaBuilder{cm: cm, idx: startIdx}
+	if err := c.build(b); err != nil {
+		return "", nil, err
+	}
+	return b.sb.String(), b.params, nil
+}
+
+// criteriaBuilder collects the where fragment and parameters while walking a criteria tree.
+type criteriaBuilder struct {
+	cm     metadata.ColumnMapper
+	sb     strings.Builder
+	params []interface{}
+	idx    int
+}
+
+func (this *criteriaBuilder) column(field string) (string, error) {
+	if !this.cm.HasColumn(field) {
+		return "", UnknownFieldError{field}
+	}
+	return this.cm.Column(field), nil
+}
+
+func (this *criteriaBuilder) param(v interface{}) string {
+	this.params = append(this.params, v)
+	ph := placeholder(this.idx)
+	this.idx++
+	return ph
+}
+
+func (this *criteriaBuilder) write(s string) {
+	this.sb.WriteString(s)
+}
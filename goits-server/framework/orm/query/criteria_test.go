@@ -0,0 +1,133 @@
+package query
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testColumnMapper is a simple ColumnMapper that maps known fields to predefined columns.
+type testColumnMapper map[string]string
+
+func (this testColumnMapper) HasColumn(field string) bool {
+	_, ok := this[field]
+	return ok
+}
+
+func (this testColumnMapper) Column(field string) string {
+	return this[field]
+}
+
+func (this testColumnMapper) Fields() []string {
+	fields := make([]string, 0, len(this))
+	for f := range this {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (this testColumnMapper) Columns() []string {
+	return nil
+}
+
+func (this testColumnMapper) FieldByJSONName(name string) (string, bool) {
+	return "", false
+}
+
+func (this testColumnMapper) FieldType(field string) reflect.Type {
+	return nil
+}
+
+var tcm = testColumnMapper{
+	"Name":        "name",
+	"Status":      "status",
+	"Type":        "type",
+	"Description": "description",
+	"Id":          "id",
+}
+
+func TestBuildWhere_Nil_Empty(t *testing.T) {
+	// when
+	where, params, err := BuildWhere(tcm, nil, 1)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Empty(t, where, "where should be empty")
+	assert.Empty(t, params, "there should be no params")
+}
+
+func TestBuildWhere_Operators(t *testing.T) {
+	cases := []struct {
+		criteria Criteria
+		where    string
+		params   []interface{}
+	}{
+		{Eq("Name", "a"), "name = $1", []interface{}{"a"}},
+		{Ne("Name", "a"), "name <> $1", []interface{}{"a"}},
+		{Lt("Status", 2), "status < $1", []interface{}{2}},
+		{Le("Status", 2), "status <= $1", []interface{}{2}},
+		{Gt("Status", 2), "status > $1", []interface{}{2}},
+		{Ge("Status", 2), "status >= $1", []interface{}{2}},
+		{In("Status", 1, 2, 3), "status in ($1, $2, $3)", []interface{}{1, 2, 3}},
+		{In("Status"), "1=0", nil},
+		{Like("Name", "a%"), "name like $1", []interface{}{"a%"}},
+		{ILike("Name", "a%"), "name ilike $1", []interface{}{"a%"}},
+		{IsNull("Description"), "description is null", nil},
+		{IsNotNull("Description"), "description is not null", nil},
+		{Between("Status", 1, 5), "status between $1 and $2", []interface{}{1, 5}},
+	}
+
+	for _, c := range cases {
+		// when
+		where, params, err := BuildWhere(tcm, c.criteria, 1)
+
+		// then
+		assert.Nil(t, err, "no error expected")
+		assert.Equal(t, c.where, where, "where is not correct")
+		assert.Equal(t, c.params, params, "params are not correct")
+	}
+}
+
+func TestBuildWhere_NestedGroups(t *testing.T) {
+	// given
+	c := And(
+		Eq("Type", 1),
+		Or(Eq("Status", 1), And(Eq("Status", 2), IsNull("Description"))),
+		And(),
+	)
+
+	// when
+	where, params, err := BuildWhere(tcm, c, 3)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "type = $3 AND (status = $4 OR (status = $5 AND description is null))", where, "where is not correct")
+	assert.Equal(t, []interface{}{1, 1, 2}, params, "params are not correct")
+}
+
+func TestBuildWhere_UnknownField_Error(t *testing.T) {
+	// given
+	c := Or(Eq("Name", "a"), Eq("Owner", "b"))
+
+	// when
+	_, _, err := BuildWhere(tcm, c, 1)
+
+	// then
+	assert.Equal(t, UnknownFieldError{"Owner"}, err, "unknown field should be reported")
+}
+
+func TestAttributesCriteria_SortedByField(t *testing.T) {
+	// given
+	attrs := map[string]interface{}{"Status": 2, "Name": "a", "Type": 1}
+
+	// when
+	where, params, err := BuildWhere(tcm, AttributesCriteria(attrs), 1)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "name = $1 AND status = $2 AND type = $3", where, "where is not correct")
+	assert.Equal(t, []interface{}{"a", 2, 1}, params, "params are not correct")
+}
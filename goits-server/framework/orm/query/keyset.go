@@ -0,0 +1,133 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+)
+
+var ErrCursorMismatch = errors.New("criteria: cursor does not match the sort of the query")
+
+// Cursor is a position in a keyset paged list.
+// It holds the sort used for paging and the values of the sort fields of the last row before the position.
+type Cursor struct {
+	Sort   []SortField
+	Values []interface{}
+}
+
+// IsZero checks if the cursor points to the beginning of the list.
+func (this Cursor) IsZero() bool {
+	return len(this.Values) == 0
+}
+
+// DefaultSortFields converts the default sort of the entity to sort fields.
+// Parts of the default sort that are not mapped to a field are ignored.
+func DefaultSortFields(ed metadata.EntityDef, cm metadata.ColumnMapper) []SortField {
+	var sort []SortField
+	for _, part := range strings.Split(ed.DefaultSort(), ",") {
+		tokens := strings.Fields(part)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		field, ok := fieldByColumn(cm, tokens[0])
+		if !ok {
+			continue
+		}
+		desc := len(tokens) > 1 && strings.EqualFold(tokens[1], "desc")
+		sort = append(sort, SortField{field, desc})
+	}
+	return sort
+}
+
+// KeysetSort returns the sort to be used for keyset paging, which is the given sort or the default sort of the entity
+// followed by the primary key so that the position of every row is unique.
+func KeysetSort(ed metadata.EntityDef, cm metadata.ColumnMapper, sort []SortField) []SortField {
+	if len(sort) == 0 {
+		sort = DefaultSortFields(ed, cm)
+	}
+
+	pk, ok := fieldByColumn(cm, ed.PKColumn())
+	if !ok {
+		return sort
+	}
+	for _, s := range sort {
+		if s.Field == pk {
+			return sort
+		}
+	}
+
+	keyset := make([]SortField, len(sort), len(sort)+1)
+	copy(keyset, sort)
+	return append(keyset, Asc(pk))
+}
+
+// KeysetCriteria creates the criteria that matches the rows coming after the given values in the given sort.
+// For a sort of (a, b) it matches a > va OR (a = va AND b > vb), using less than for descending fields.
+// Sort fields are expected to be not null, since null values can not be compared.
+func KeysetCriteria(sort []SortField, values []interface{}) Criteria {
+	alternatives := make([]Criteria, len(sort))
+	for i, s := range sort {
+		conds := make([]Criteria, 0, i+1)
+		for j := 0; j < i; j++ {
+			conds = append(conds, Eq(sort[j].Field, values[j]))
+		}
+		if s.Desc {
+			conds = append(conds, Lt(s.Field, values[i]))
+		} else {
+			conds = append(conds, Gt(s.Field, values[i]))
+		}
+		alternatives[i] = And(conds...)
+	}
+	return Or(alternatives...)
+}
+
+// BuildQueryByCursor builds a select query that returns up to limit rows matching the criteria after the cursor.
+// The keyset sort used by the query is returned to be able to create the cursor of the next page.
+func BuildQueryByCursor(ed metadata.EntityDef, qd QueryDef, cm metadata.ColumnMapper, c Criteria, after Cursor, limit uint, opts ...Option) (string, []interface{}, []SortField, error) {
+	o := NewOptions(opts...)
+	sort := KeysetSort(ed, cm, o.Sort)
+
+	if !after.IsZero() {
+		if !reflect.DeepEqual(sort, after.Sort) || len(after.Values) != len(sort) {
+			return "", nil, nil, ErrCursorMismatch
+		}
+		c = And(c, KeysetCriteria(sort, after.Values))
+	}
+
+	// the keyset sort overrides the given one, other options are kept as they are
+	keysetOpts := append(append([]Option{}, opts...), OrderBy(sort...))
+	q, params, err := BuildQueryByCriteria(ed, qd, cm, c, limit, 0, keysetOpts...)
+	return q, params, sort, err
+}
+
+// NewCursor creates the cursor that points after the given row by reading the values of sort fields from it.
+func NewCursor(sort []SortField, row interface{}) (Cursor, error) {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	if v.Kind() != reflect.Struct {
+		return Cursor{}, fmt.Errorf("criteria: can not read cursor values from %s", v.Type())
+	}
+
+	values := make([]interface{}, len(sort))
+	for i, s := range sort {
+		f := v.FieldByName(s.Field)
+		if !f.IsValid() {
+			return Cursor{}, UnknownFieldError{s.Field}
+		}
+		values[i] = f.Interface()
+	}
+	return Cursor{sort, values}, nil
+}
+
+// fieldByColumn finds the field that is mapped to the given column.
+func fieldByColumn(cm metadata.ColumnMapper, col string) (string, bool) {
+	for _, f := range cm.Fields() {
+		if cm.Column(f) == col {
+			return f, true
+		}
+	}
+	return "", false
+}
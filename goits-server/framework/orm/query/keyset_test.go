@@ -0,0 +1,71 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+var keysetED = metadata.NewEntityDef("query.KeysetTest", "test", "keyset", "id", "name asc, status desc", false)
+
+type keysetRow struct {
+	Id     uint64
+	Name   string
+	Status int
+}
+
+func TestKeysetSort_Default_AppendsPK(t *testing.T) {
+	// when
+	sort := KeysetSort(keysetED, tcm, nil)
+
+	// then
+	assert.Equal(t, []SortField{Asc("Name"), Desc("Status"), Asc("Id")}, sort, "sort is not correct")
+}
+
+func TestKeysetSort_ContainsPK_Unchanged(t *testing.T) {
+	// given
+	given := []SortField{Desc("Id"), Asc("Name")}
+
+	// when
+	sort := KeysetSort(keysetED, tcm, given)
+
+	// then
+	assert.Equal(t, given, sort, "sort should not be changed")
+}
+
+func TestKeysetCriteria_MixedDirections(t *testing.T) {
+	// given
+	sort := []SortField{Asc("Name"), Desc("Status"), Asc("Id")}
+
+	// when
+	where, params, err := BuildWhere(tcm, KeysetCriteria(sort, []interface{}{"a", 2, 7}), 1)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "(name > $1) OR (name = $2 AND status < $3) OR (name = $4 AND status = $5 AND id > $6)", where, "where is not correct")
+	assert.Equal(t, []interface{}{"a", "a", 2, "a", 2, 7}, params, "params are not correct")
+}
+
+func TestNewCursor_ReadsSortValues(t *testing.T) {
+	// given
+	sort := []SortField{Asc("Name"), Asc("Id")}
+
+	// when
+	c, err := NewCursor(sort, &keysetRow{Id: 3, Name: "c", Status: 1})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, Cursor{sort, []interface{}{"c", uint64(3)}}, c, "cursor is not correct")
+}
+
+func TestBuildQueryByCursor_SortMismatch_Error(t *testing.T) {
+	// given
+	after := Cursor{[]SortField{Asc("Name"), Asc("Id")}, []interface{}{"c", uint64(3)}}
+
+	// when
+	_, _, _, err := BuildQueryByCursor(keysetED, sqlQueryDef{selectColumns: "id, name"}, tcm, nil, after, 10, OrderBy(Desc("Status")))
+
+	// then
+	assert.Equal(t, ErrCursorMismatch, err, "cursor of another sort should be rejected")
+}
@@ -0,0 +1,86 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+)
+
+// SortField is a single element of a sort specification.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Asc creates a SortField that sorts by field in ascending order.
+func Asc(field string) SortField {
+	return SortField{field, false}
+}
+
+// Desc creates a SortField that sorts by field in descending order.
+func Desc(field string) SortField {
+	return SortField{field, true}
+}
+
+// Options contains the optional settings of a finder call.
+type Options struct {
+	Sort           []SortField
+	IncludeDeleted bool
+	Include        []string
+}
+
+// Option customizes the Options of a finder call.
+type Option func(o *Options)
+
+// NewOptions creates Options by applying the given options in order.
+func NewOptions(opts ...Option) Options {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// OrderBy makes the finder sort the results by given fields instead of the default sort of the entity.
+func OrderBy(sort ...SortField) Option {
+	return func(o *Options) {
+		o.Sort = sort
+	}
+}
+
+// IncludeDeleted makes the finder return the soft deleted entities too, which are excluded by default.
+func IncludeDeleted() Option {
+	return func(o *Options) {
+		o.IncludeDeleted = true
+	}
+}
+
+// Include makes the finder load the given relations of the found entities along with them.
+func Include(relations ...string) Option {
+	return func(o *Options) {
+		o.Include = append(o.Include, relations...)
+	}
+}
+
+// BuildOrderBy builds the order by fragment for the given sort fields.
+// The default sort of the entity is used if no sort fields are given.
+func BuildOrderBy(ed metadata.EntityDef, cm metadata.ColumnMapper, sort []SortField) (string, error) {
+	if len(sort) == 0 {
+		return ed.DefaultSort(), nil
+	}
+
+	parts := make([]string, len(sort))
+	for i, s := range sort {
+		if !cm.HasColumn(s.Field) {
+			return "", UnknownFieldError{s.Field}
+		}
+
+		dir := "asc"
+		if s.Desc {
+			dir = "desc"
+		}
+		parts[i] = fmt.Sprintf("%s %s", cm.Column(s.Field), dir)
+	}
+	return strings.Join(parts, ", "), nil
+}
@@ -3,80 +3,257 @@ package query
 import (
 	"fmt"
 	"strings"
+	"sync"
 
-	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/dialect"
 	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
 )
 
 var queryDefRegistry map[string]QueryDef
 
+// queryDefMutex guards the registry, since repositories can be created while requests read it.
+var queryDefMutex sync.RWMutex
+
 func init() {
 	queryDefRegistry = make(map[string]QueryDef)
 }
 
 const (
-	findAllTemplate                  = "select %s from %s order by %s"
-	findAllPagedTemplate             = findAllTemplate + " limit %d offset %d"
-	findOneByAttributeTemplate       = "select %s from %s where %s = $1"
-	findAllByAttributesTemplate      = "select %s from %s where %s order by %s"
-	findAllByAttributesPagedTemplate = findAllByAttributesTemplate + " limit %d offset %d"
+	findAllTemplate             = "select %s from %s order by %s"
+	findOneByAttributeTemplate  = "select %s from %s where %s = %s"
+	findAllByAttributesTemplate = "select %s from %s where %s order by %s"
+	pagingTemplate              = "%s %s"
+	countTemplate               = "select count(*) from %s"
+	countByCriteriaTemplate     = countTemplate + " where %s"
+	currentVersionTemplate      = "select version from %s where %s = %s"
+
+	// notDeletedCondition excludes the soft deleted rows of entities using soft delete
+	notDeletedCondition = "deleted = false"
 )
 
 // GetQueryDef returns an already registered QueryDef for the entity represented by provided metadata.
 func GetQueryDef(ed metadata.EntityDef) (QueryDef, bool) {
+	queryDefMutex.RLock()
+	defer queryDefMutex.RUnlock()
+
 	cm, found := queryDefRegistry[ed.Name()]
 	return cm, found
 }
 
 // BuildQueryDef builds the default static sql statements that will be used by a repository.
+// Statements are rendered with the current dialect.
 func BuildQueryDef(introspect interface{}, ed metadata.EntityDef, cm metadata.ColumnMapper) QueryDef {
 	selectColumns := strings.Join(cm.Columns(), ", ")
 
 	qd := sqlQueryDef{
 		selectColumns: selectColumns,
-		findOne:       fmt.Sprintf(findOneByAttributeTemplate, selectColumns, ed.FullTableName(), ed.PKColumn()),
-		findAll:       fmt.Sprintf(findAllTemplate, selectColumns, ed.FullTableName(), ed.DefaultSort()),
+		findOne:       WithNotDeleted(ed, fmt.Sprintf(findOneByAttributeTemplate, selectColumns, tableName(ed), ed.PKColumn(), placeholder(1))),
+		findAll:       generateFindAllQuery(ed, selectColumns),
+		count:         generateCountQuery(ed),
 		insert:        generateInsertStatement(ed.Schema(), ed.Table(), cm),
-		update:        generateUpdateStatement(ed.Schema(), ed.Table(), cm, introspect),
+		upsert:        generateUpsertStatement(ed, cm, introspect),
+		update:        generateUpdateStatement(ed, cm, introspect),
 		delete:        generateDeleteStatement(ed, introspect),
+		deleteAll:     generateDeleteAllStatement(ed, introspect),
+		restore:       generateRestoreStatement(ed, introspect),
+	}
+
+	if _, ok := introspect.(domain.Versioned); ok {
+		qd.currentVersion = WithNotDeleted(ed, fmt.Sprintf(currentVersionTemplate, tableName(ed), ed.PKColumn(), placeholder(1)))
+		qd.deleteVersion = generateDeleteVersionStatement(ed, introspect)
 	}
 
+	queryDefMutex.Lock()
 	queryDefRegistry[ed.Name()] = qd
+	queryDefMutex.Unlock()
 
 	return qd
 }
 
+// tableName returns the name the table of the entity is referred to by the current dialect.
+func tableName(ed metadata.EntityDef) string {
+	return dialect.Current().Table(ed.Schema(), ed.Table())
+}
+
+// placeholder returns the positional parameter of the current dialect with the given 1 based index.
+func placeholder(idx int) string {
+	return dialect.Current().Placeholder(idx)
+}
+
+// generateFindAllQuery creates the query that selects all entities, except the soft deleted ones.
+func generateFindAllQuery(ed metadata.EntityDef, selectColumns string) string {
+	if ed.SoftDelete() {
+		return fmt.Sprintf(findAllByAttributesTemplate, selectColumns, tableName(ed), notDeletedCondition, ed.DefaultSort())
+	}
+	return fmt.Sprintf(findAllTemplate, selectColumns, tableName(ed), ed.DefaultSort())
+}
+
+// generateCountQuery creates the query that counts all entities, except the soft deleted ones.
+func generateCountQuery(ed metadata.EntityDef) string {
+	if ed.SoftDelete() {
+		return fmt.Sprintf(countByCriteriaTemplate, tableName(ed), notDeletedCondition)
+	}
+	return fmt.Sprintf(countTemplate, tableName(ed))
+}
+
+// WithNotDeleted adds the condition that excludes soft deleted rows of the entity to a statement that already has a where part.
+func WithNotDeleted(ed metadata.EntityDef, stmt string) string {
+	if ed.SoftDelete() {
+		return stmt + " AND " + notDeletedCondition
+	}
+	return stmt
+}
+
 // generateInsertStatement creates the insert sql statement.
 func generateInsertStatement(schema string, table string, cm metadata.ColumnMapper) string {
+	return withReturning(buildInsertStatement(schema, table, cm), cm, domain.IsNonInsertableField)
+}
+
+// buildInsertStatement creates the insert statement of the insertable columns without returning any columns.
+func buildInsertStatement(schema string, table string, cm metadata.ColumnMapper) string {
+	columns := insertColumns(cm)
+	values := make([]string, len(columns))
+	for i, col := range columns {
+		values[i] = ":" + col
+	}
+
+	columnsPart := strings.Join(columns, ", ")
+	valuesPart := strings.Join(values, ", ")
+
+	return fmt.Sprintf("insert into %s(%s) values(%s)", dialect.Current().Table(schema, table), columnsPart, valuesPart)
+}
+
+// generateUpsertStatement creates the statement that inserts the entity or updates the existing one with the same unique key.
+// On conflict, the insertable columns other than the unique key are overwritten and a soft deleted entity is restored.
+// It is empty if the entity has no unique key.
+func generateUpsertStatement(ed metadata.EntityDef, cm metadata.ColumnMapper, introspect interface{}) string {
+	if len(ed.UniqueKey()) == 0 {
+		return ""
+	}
+
+	key := make(map[string]bool, len(ed.UniqueKey()))
+	for _, col := range ed.UniqueKey() {
+		key[col] = true
+	}
+
+	var stmt []string
+	for _, f := range cm.Fields() {
+		if domain.IsNonInsertableField(f) || domain.IsNonUpdatableField(f) || !cm.HasColumn(f) {
+			continue
+		}
+		if col := cm.Column(f); !key[col] {
+			stmt = append(stmt, fmt.Sprintf("%s=excluded.%s", col, col))
+		}
+	}
+	if ed.SoftDelete() {
+		stmt = append(stmt, "deleted=false", "deleted_time=null", "deleted_by=null")
+	}
+	stmt = append(stmt, stateChanges(introspect)...)
+
+	// the conflicting row must still be updated to be returned
+	if len(stmt) == 0 {
+		col := ed.UniqueKey()[0]
+		stmt = append(stmt, fmt.Sprintf("%s=excluded.%s", col, col))
+	}
+
+	upsert := fmt.Sprintf("%s on conflict (%s) do update set %s", buildInsertStatement(ed.Schema(), ed.Table(), cm), strings.Join(ed.UniqueKey(), ", "), strings.Join(stmt, ", "))
+	return withReturning(upsert, cm, domain.IsNonInsertableField)
+}
+
+// BuildInsertAllQuery builds an insert statement of the given number of rows with positional parameters.
+// Parameters of each row are in the same order as the named parameters of the default insert statement,
+// The db generated columns of the rows are returned along with their unique key, since the order of the returned rows is not guaranteed.
+func BuildInsertAllQuery(ed metadata.EntityDef, cm metadata.ColumnMapper, rows int) string {
+	columns := insertColumns(cm)
+
+	values := make([]string, rows)
+	params := make([]string, len(columns))
+	for r := 0; r < rows; r++ {
+		for i := range columns {
+			params[i] = placeholder(r*len(columns) + i + 1)
+		}
+		values[r] = "(" + strings.Join(params, ", ") + ")"
+	}
+
+	// the unique key is returned along with the generated columns, so that the returned rows can be matched to the entities
+	key := make(map[string]bool, len(ed.UniqueKey()))
+	for _, col := range ed.UniqueKey() {
+		key[col] = true
+	}
+
+	stmt := fmt.Sprintf("insert into %s(%s) values%s", tableName(ed), strings.Join(columns, ", "), strings.Join(values, ", "))
+	return withReturning(stmt, cm, func(f string) bool {
+		return domain.IsNonInsertableField(f) || key[cm.Column(f)]
+	})
+}
+
+// InsertColumnCount returns the number of columns that are set by insert statements of the entity.
+func InsertColumnCount(cm metadata.ColumnMapper) int {
+	return len(insertColumns(cm))
+}
+
+// insertColumns returns the columns of insertable fields in field order.
+func insertColumns(cm metadata.ColumnMapper) []string {
 	var columns []string
-	var values []string
 	for _, f := range cm.Fields() {
 		if domain.IsNonInsertableField(f) {
 			continue
 		}
 		if cm.HasColumn(f) {
-			col := cm.Column(f)
-
-			columns = append(columns, col)
-			values = append(values, ":"+col)
+			columns = append(columns, cm.Column(f))
 		}
 	}
+	return columns
+}
 
-	columnsPart := strings.Join(columns, ", ")
-	valuesPart := strings.Join(values, ", ")
+// withReturning adds a returning clause to the statement for the columns that are generated by db,
+// so that the entity can be refreshed with their values after the statement is executed.
+func withReturning(stmt string, cm metadata.ColumnMapper, generated func(string) bool) string {
+	var columns []string
+	for _, f := range cm.Fields() {
+		if generated(f) && cm.HasColumn(f) {
+			columns = append(columns, cm.Column(f))
+		}
+	}
 
-	return fmt.Sprintf("insert into %s.%s(%s) values(%s)", schema, table, columnsPart, valuesPart)
+	if len(columns) == 0 {
+		return stmt
+	}
+	return fmt.Sprintf("%s %s", stmt, dialect.Current().Returning(columns))
 }
 
 // generateUpdateStatement creates appropriate update-all statement that updates all fields.
 // The generated statement also contains versioning / timestamping if the target type supports those.
-func generateUpdateStatement(schema string, table string, cm metadata.ColumnMapper, introspect interface{}) string {
-	var stmt []string
+func generateUpdateStatement(ed metadata.EntityDef, cm metadata.ColumnMapper, introspect interface{}) string {
+	return buildUpdateStatement(ed, cm, cm.Fields(), introspect)
+}
+
+// BuildUpdateQuery builds an update statement that only sets the given fields of the entity.
+// Fields that are not mapped to columns or not updatable are skipped, versioning / timestamping is applied as usual.
+// Columns are set in the same order as the update-all statement regardless of the order of the fields.
+func BuildUpdateQuery(ed metadata.EntityDef, cm metadata.ColumnMapper, entity interface{}, fields []string) string {
+	requested := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		requested[f] = true
+	}
+
+	var ordered []string
 	for _, f := range cm.Fields() {
+		if requested[f] {
+			ordered = append(ordered, f)
+		}
+	}
+	return buildUpdateStatement(ed, cm, ordered, entity)
+}
+
+func buildUpdateStatement(ed metadata.EntityDef, cm metadata.ColumnMapper, fields []string, introspect interface{}) string {
+	var stmt []string
+	for _, f := range fields {
 		if domain.IsNonUpdatableField(f) {
 			continue
 		}
-		
+
 		if cm.HasColumn(f) {
 			col := cm.Column(f)
 			stmt = append(stmt, fmt.Sprintf("%s=:%s", col, col))
@@ -95,45 +272,119 @@ func generateUpdateStatement(schema string, table string, cm metadata.ColumnMapp
 	// if timestamped, we also need to update modify time
 	_, ok = introspect.(domain.Timestamped)
 	if ok {
-		stmt = append(stmt, "last_modified_time=now()")
+		stmt = append(stmt, "last_modified_time="+dialect.Current().Now())
+	}
+
+	// an entity without any updatable field still needs a valid statement to check its existence
+	if len(stmt) == 0 {
+		stmt = append(stmt, "id=id")
 	}
 
-	return fmt.Sprintf("update %s.%s set %s %s", schema, table, strings.Join(stmt, ", "), where)
+	// soft deleted entities can not be updated until they are restored
+	where = WithNotDeleted(ed, where)
+
+	update := fmt.Sprintf("update %s set %s %s", tableName(ed), strings.Join(stmt, ", "), where)
+	return withReturning(update, cm, func(f string) bool {
+		// create time does not change, id is returned to tell whether any row is updated
+		return domain.IsNonUpdatableField(f) && f != "CreateTime"
+	})
 }
 
 // generateDeleteStatement builds the default delete statement for the entity.
 // It generates a delete or update statement depending on whether the entity uses soft delete or not.
+// Soft delete statement also records who deleted the entity, which is given as the second parameter.
 func generateDeleteStatement(ed metadata.EntityDef, introspect interface{}) string {
+	return buildDeleteStatement(ed, introspect, fmt.Sprintf("%s = %s", ed.PKColumn(), placeholder(1)))
+}
+
+// generateDeleteVersionStatement builds the delete statement that only deletes the entity if it still has the given version.
+// The version is the last parameter, which follows the parameters of the default delete statement.
+func generateDeleteVersionStatement(ed metadata.EntityDef, introspect interface{}) string {
+	versionIdx := 2
 	if ed.SoftDelete() {
-		_, ok := introspect.(domain.Timestamped)
-		if ok {
-			return fmt.Sprintf("update %s.%s set deleted=true, last_modified_time=now() where %s = $1", ed.Schema(), ed.Table(), ed.PKColumn())
-		} else {
-			return fmt.Sprintf("update %s.%s set deleted=true where %s = $1", ed.Schema(), ed.Table(), ed.PKColumn())
-		}
-	} else {
-		return fmt.Sprintf("delete from %s.%s where %s = $1", ed.Schema(), ed.Table(), ed.PKColumn())
+		versionIdx = 3
 	}
+	return buildDeleteStatement(ed, introspect, fmt.Sprintf("%s = %s AND version = %s", ed.PKColumn(), placeholder(1), placeholder(versionIdx)))
+}
+
+// generateDeleteAllStatement builds the delete statement of multiple entities, whose ids are given as a single list parameter.
+func generateDeleteAllStatement(ed metadata.EntityDef, introspect interface{}) string {
+	return buildDeleteStatement(ed, introspect, dialect.Current().AnyOf(ed.PKColumn(), 1))
+}
+
+func buildDeleteStatement(ed metadata.EntityDef, introspect interface{}, where string) string {
+	if !ed.SoftDelete() {
+		return fmt.Sprintf("delete from %s where %s", tableName(ed), where)
+	}
+
+	stmt := append([]string{"deleted=true", "deleted_time=" + dialect.Current().Now(), "deleted_by=" + placeholder(2)}, stateChanges(introspect)...)
+	return fmt.Sprintf("update %s set %s where %s AND deleted = false", tableName(ed), strings.Join(stmt, ", "), where)
+}
+
+// generateRestoreStatement builds the statement that brings back a soft deleted entity.
+// It is empty if the entity does not use soft delete.
+func generateRestoreStatement(ed metadata.EntityDef, introspect interface{}) string {
+	if !ed.SoftDelete() {
+		return ""
+	}
+
+	stmt := append([]string{"deleted=false", "deleted_time=null", "deleted_by=null"}, stateChanges(introspect)...)
+	return fmt.Sprintf("update %s set %s where %s = %s AND deleted = true", tableName(ed), strings.Join(stmt, ", "), ed.PKColumn(), placeholder(1))
+}
+
+// stateChanges returns the versioning / timestamping updates of statements that change the state of an entity.
+func stateChanges(introspect interface{}) []string {
+	var stmt []string
+	if _, ok := introspect.(domain.Versioned); ok {
+		stmt = append(stmt, "version=version + 1")
+	}
+	if _, ok := introspect.(domain.Timestamped); ok {
+		stmt = append(stmt, "last_modified_time="+dialect.Current().Now())
+	}
+	return stmt
 }
 
 // QueryDef is the metadata of pre-generated queries for an entity.
 type QueryDef interface {
 	FindOne() string
 	FindAll() string
+	Count() string
 	Insert() string
 	Update() string
 	Delete() string
 	SelectColumns() string
+
+	// Upsert is the insert statement that updates the existing entity with the same unique key instead of failing,
+	// it is empty if the entity has no unique key.
+	Upsert() string
+
+	// DeleteAll is the delete statement of multiple entities, which takes the ids as a single list parameter of the dialect.
+	DeleteAll() string
+
+	// Restore is the statement that restores a soft deleted entity by primary key, it is empty if soft delete is not used.
+	Restore() string
+
+	// DeleteVersion is the delete statement by primary key and version, it is empty if the entity is not versioned.
+	DeleteVersion() string
+
+	// CurrentVersion is the query of the version column by primary key, it is empty if the entity is not versioned.
+	CurrentVersion() string
 }
 
 // sqlQueryDef provides statically defined sql statements for a repository.
 type sqlQueryDef struct {
-	findOne       string
-	findAll       string
-	insert        string
-	update        string
-	delete        string
-	selectColumns string
+	findOne        string
+	findAll        string
+	count          string
+	insert         string
+	upsert         string
+	update         string
+	delete         string
+	deleteAll      string
+	restore        string
+	selectColumns  string
+	currentVersion string
+	deleteVersion  string
 }
 
 func (this sqlQueryDef) FindOne() string {
@@ -142,6 +393,9 @@ func (this sqlQueryDef) FindOne() string {
 func (this sqlQueryDef) FindAll() string {
 	return this.findAll
 }
+func (this sqlQueryDef) Count() string {
+	return this.count
+}
 func (this sqlQueryDef) Insert() string {
 	return this.insert
 }
@@ -151,6 +405,21 @@ func (this sqlQueryDef) Update() string {
 func (this sqlQueryDef) Delete() string {
 	return this.delete
 }
+func (this sqlQueryDef) Upsert() string {
+	return this.upsert
+}
+func (this sqlQueryDef) DeleteAll() string {
+	return this.deleteAll
+}
+func (this sqlQueryDef) Restore() string {
+	return this.restore
+}
 func (this sqlQueryDef) SelectColumns() string {
 	return this.selectColumns
-}
\ No newline at end of file
+}
+func (this sqlQueryDef) CurrentVersion() string {
+	return this.currentVersion
+}
+func (this sqlQueryDef) DeleteVersion() string {
+	return this.deleteVersion
+}
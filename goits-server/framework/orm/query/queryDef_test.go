@@ -0,0 +1,102 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInsertAllQuery(t *testing.T) {
+	// given
+	e := &SoftDeleteTestEntity{}
+	cm := metadata.NewColumnMapper(softED, e)
+
+	// when
+	q := BuildInsertAllQuery(softED, cm, 3)
+
+	// then
+	assert.Equal(t, "insert into data.soft(name) values($1), ($2), ($3) returning id, version", q, "insert all is not correct")
+	assert.Equal(t, 1, InsertColumnCount(cm), "insert column count is not correct")
+}
+
+func TestBuildInsertAllQuery_ReturnsUniqueKey(t *testing.T) {
+	// given
+	ed := metadata.WithUniqueKey(metadata.NewEntityDef("query.UpsertTestEntity", "config", "upsert", "id", "name asc", false), "name")
+	cm := metadata.NewColumnMapper(ed, &UpsertTestEntity{})
+
+	// when
+	q := BuildInsertAllQuery(ed, cm, 2)
+
+	// then
+	assert.Equal(t, "insert into config.upsert(description, name) values($1, $2), ($3, $4) "+
+		"returning create_time, id, last_modified_time, name, version", q, "insert all should return the unique key")
+}
+
+type UpsertTestEntity struct {
+	domain.VersionedTimeStampedEntity
+	Name        string `db:"name"`
+	Description string `db:"description"`
+}
+
+func TestBuildQueryDef_Upsert(t *testing.T) {
+	// given
+	ed := metadata.WithUniqueKey(metadata.NewEntityDef("query.UpsertTestEntity", "config", "upsert", "id", "name asc", false), "name")
+	e := &UpsertTestEntity{}
+
+	// when
+	qd := BuildQueryDef(e, ed, metadata.NewColumnMapper(ed, e))
+
+	// then
+	assert.Equal(t, "insert into config.upsert(description, name) values(:description, :name) on conflict (name) do update set "+
+		"description=excluded.description, version=version + 1, last_modified_time=now() returning create_time, id, last_modified_time, version",
+		qd.Upsert(), "upsert is not correct")
+}
+
+func TestBuildQueryDef_DeleteVersion(t *testing.T) {
+	// given
+	ed := metadata.NewEntityDef("query.UpsertTestEntity", "config", "upsert", "id", "name asc", false)
+	e := &UpsertTestEntity{}
+
+	// when
+	qd := BuildQueryDef(e, ed, metadata.NewColumnMapper(ed, e))
+
+	// then
+	assert.Equal(t, "delete from config.upsert where id = $1 AND version = $2", qd.DeleteVersion(), "delete version is not correct")
+}
+
+func TestBuildQueryDef_NoUniqueKey_NoUpsert(t *testing.T) {
+	// given
+	e := &UpsertTestEntity{}
+	ed := metadata.NewEntityDef("query.UpsertTestEntity", "config", "upsert", "id", "name asc", false)
+
+	// when
+	qd := BuildQueryDef(e, ed, metadata.NewColumnMapper(ed, e))
+
+	// then
+	assert.Empty(t, qd.Upsert(), "upsert should not be supported")
+}
+
+func TestBuildQueryDef_SQLite(t *testing.T) {
+	// given
+	dialect.Use(dialect.SQLite())
+	defer dialect.Use(dialect.Postgres())
+
+	// when
+	qd, cm := newSoftDeleteQueryDef()
+	q, params, err := BuildQueryByCriteria(softED, qd, cm, Or(Eq("Name", "a"), ILike("Name", "b%")), 10, 20)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "select id, name, version from soft where (name = ?1 OR name like ?2) AND deleted = false order by name asc limit 10 offset 20", q, "criteria query is not correct")
+	assert.Equal(t, []interface{}{"a", "b%"}, params, "params are not correct")
+	assert.Equal(t, "select id, name, version from soft where id = ?1 AND deleted = false", qd.FindOne(), "find one is not correct")
+	assert.Equal(t, "insert into soft(name) values(:name) returning id, version", qd.Insert(), "insert is not correct")
+	assert.Equal(t, "update soft set deleted=true, deleted_time=current_timestamp, deleted_by=?2, version=version + 1 "+
+		"where id in (select value from json_each(?1)) AND deleted = false", qd.DeleteAll(), "delete all is not correct")
+	assert.Equal(t, "update soft set deleted=true, deleted_time=current_timestamp, deleted_by=?2, version=version + 1 "+
+		"where id = ?1 AND version = ?3 AND deleted = false", qd.DeleteVersion(), "delete version is not correct")
+	assert.Equal(t, "insert into soft(name) values(?1), (?2) returning id, version", BuildInsertAllQuery(softED, cm, 2), "insert all is not correct")
+}
@@ -0,0 +1,127 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+type SoftDeleteTestEntity struct {
+	domain.VersionedEntity
+	Name string `db:"name"`
+}
+
+var softED = metadata.NewEntityDef("query.SoftDeleteTestEntity", "data", "soft", "id", "name asc", true)
+
+func newSoftDeleteQueryDef() (QueryDef, metadata.ColumnMapper) {
+	e := &SoftDeleteTestEntity{}
+	cm := metadata.NewColumnMapper(softED, e)
+	return BuildQueryDef(e, softED, cm), cm
+}
+
+func TestBuildQueryDef_SoftDelete_ReadsExcludeDeleted(t *testing.T) {
+	// when
+	qd, _ := newSoftDeleteQueryDef()
+
+	// then
+	assert.Equal(t, "select id, name, version from data.soft where id = $1 AND deleted = false", qd.FindOne(), "find one is not correct")
+	assert.Equal(t, "select id, name, version from data.soft where deleted = false order by name asc", qd.FindAll(), "find all is not correct")
+	assert.Equal(t, "select count(*) from data.soft where deleted = false", qd.Count(), "count is not correct")
+	assert.Equal(t, "select version from data.soft where id = $1 AND deleted = false", qd.CurrentVersion(), "current version is not correct")
+	assert.Contains(t, qd.Update(), "where id=:id AND version=:version AND deleted = false", "deleted entities should not be updated")
+}
+
+func TestBuildQueryDef_SoftDelete_DeleteAndRestore(t *testing.T) {
+	// when
+	qd, _ := newSoftDeleteQueryDef()
+
+	// then
+	assert.Equal(t, "update data.soft set deleted=true, deleted_time=now(), deleted_by=$2, version=version + 1 where id = $1 AND deleted = false",
+		qd.Delete(), "delete is not correct")
+	assert.Equal(t, "update data.soft set deleted=true, deleted_time=now(), deleted_by=$2, version=version + 1 where id = ANY($1) AND deleted = false",
+		qd.DeleteAll(), "delete all is not correct")
+	assert.Equal(t, "update data.soft set deleted=false, deleted_time=null, deleted_by=null, version=version + 1 where id = $1 AND deleted = true",
+		qd.Restore(), "restore is not correct")
+}
+
+func TestBuildQueryDef_NoSoftDelete_NoRestore(t *testing.T) {
+	// given
+	ed := metadata.NewEntityDef("query.HardDeleteTestEntity", "data", "hard", "id", "name asc", false)
+	e := &SoftDeleteTestEntity{}
+
+	// when
+	qd := BuildQueryDef(e, ed, metadata.NewColumnMapper(ed, e))
+
+	// then
+	assert.Equal(t, "delete from data.hard where id = $1", qd.Delete(), "delete is not correct")
+	assert.Equal(t, "delete from data.hard where id = ANY($1)", qd.DeleteAll(), "delete all is not correct")
+	assert.Empty(t, qd.Restore(), "restore should not be supported")
+	assert.Equal(t, "select id, name, version from data.hard order by name asc", qd.FindAll(), "find all is not correct")
+}
+
+func TestBuildQueryByCriteria_SoftDelete(t *testing.T) {
+	// given
+	qd, cm := newSoftDeleteQueryDef()
+	c := Or(Eq("Name", "a"), Eq("Name", "b"))
+
+	// when
+	q, params, err := BuildQueryByCriteria(softED, qd, cm, c, 10, 20)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "select id, name, version from data.soft where (name = $1 OR name = $2) AND deleted = false order by name asc limit 10 offset 20", q, "query is not correct")
+	assert.Equal(t, []interface{}{"a", "b"}, params, "params are not correct")
+
+	// when
+	q, _, err = BuildQueryByCriteria(softED, qd, cm, nil, 0, 0, IncludeDeleted())
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "select id, name, version from data.soft order by name asc", q, "deleted should be included")
+}
+
+func TestBuildOtherQueries_SoftDelete(t *testing.T) {
+	// given
+	qd, cm := newSoftDeleteQueryDef()
+
+	// when
+	count, _, err := BuildCountQueryByCriteria(softED, cm, Eq("Name", "a"))
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "select count(*) from data.soft where (name = $1) AND deleted = false", count, "count is not correct")
+	assert.Equal(t, "select id, name, version from data.soft where name = $1 AND deleted = false", BuildFindOneQuery(softED, qd, cm, "Name"), "find one is not correct")
+	assert.Equal(t, "select id, name, version from data.soft where name = $1", BuildFindOneQuery(softED, qd, cm, "Name", IncludeDeleted()),
+		"find one including deleted is not correct")
+	assert.Equal(t, qd.FindOne(), BuildFindOneByIdQuery(softED, qd), "find one by id is not correct")
+	assert.Equal(t, "select id, name, version from data.soft where id = $1", BuildFindOneByIdQuery(softED, qd, IncludeDeleted()),
+		"find one by id including deleted is not correct")
+	assert.Equal(t, "select id, name, version from data.soft where deleted = false order by name asc limit 5 offset 10", BuildFindAllPagedQuery(softED, qd, 5, 10), "paged find all is not correct")
+}
+
+func TestBuildQueryByCursor_SoftDelete_KeepsOptions(t *testing.T) {
+	// given
+	qd, cm := newSoftDeleteQueryDef()
+
+	// when
+	q, _, _, err := BuildQueryByCursor(softED, qd, cm, nil, Cursor{}, 5, IncludeDeleted())
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, "select id, name, version from data.soft order by name asc, id asc limit 5 offset 0", q, "query is not correct")
+}
+
+func TestBuildQueryDef_SoftDelete_UpsertRestores(t *testing.T) {
+	// given
+	ed := metadata.WithUniqueKey(softED, "name")
+	e := &SoftDeleteTestEntity{}
+
+	// when
+	qd := BuildQueryDef(e, ed, metadata.NewColumnMapper(ed, e))
+
+	// then
+	assert.Equal(t, "insert into data.soft(name) values(:name) on conflict (name) do update set "+
+		"deleted=false, deleted_time=null, deleted_by=null, version=version + 1 returning id, version", qd.Upsert(), "upsert is not correct")
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when the entity to be modified does not exist.
+	ErrNotFound = errors.New("notfound: entity does not exist")
+
+	// ErrRestoreNotSupported is returned when restore is requested for an entity that does not use soft delete.
+	ErrRestoreNotSupported = errors.New("notfound: entity does not use soft delete, it can not be restored")
+
+	// ErrVersionNotSupported is returned when a version conditioned operation is requested for an entity that is not versioned.
+	ErrVersionNotSupported = errors.New("precondition: entity is not versioned, it can not be changed by version")
+
+	// ErrUpsertNotSupported is returned when upsert is requested for an entity that has no unique key in its metadata.
+	ErrUpsertNotSupported = errors.New("conflict: entity has no unique key, it can not be upserted")
+
+	// ErrOptimisticLock is returned when a versioned entity is modified by someone else since it was read.
+	ErrOptimisticLock = errors.New("conflict: entity is modified concurrently")
+)
+
+// OptimisticLockError is the ErrOptimisticLock of a specific entity that carries its current version.
+type OptimisticLockError struct {
+	Entity         string
+	Id             uint64
+	Version        uint32
+	CurrentVersion uint32
+}
+
+func (this OptimisticLockError) Error() string {
+	return fmt.Sprintf("%s: %s %d has version %d, not %d", ErrOptimisticLock.Error(), this.Entity, this.Id, this.CurrentVersion, this.Version)
+}
+
+// Is makes errors.Is match this error against ErrOptimisticLock.
+func (this OptimisticLockError) Is(target error) bool {
+	return target == ErrOptimisticLock
+}
+
+// Details returns the current version of the entity, so that clients can reload it before retrying.
+func (this OptimisticLockError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"id":             this.Id,
+		"version":        this.Version,
+		"currentVersion": this.CurrentVersion,
+	}
+}
@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/query"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// relationBatchSize is the maximum number of keys given to a single in query while loading related entities.
+const relationBatchSize = 500
+
+// relationTargetMutex guards the lazy registration of the metadata of relation targets.
+var relationTargetMutex sync.Mutex
+
+// relationTarget is the metadata of the target entity of a relation, which is used to query the related entities.
+type relationTarget struct {
+	ed metadata.EntityDef
+	cm metadata.ColumnMapper
+	qd query.QueryDef
+}
+
+// includedRelations resolves the relations requested to be included in the options.
+func (this SqlRepository) includedRelations(o query.Options) ([]metadata.RelationDef, error) {
+	relations := make([]metadata.RelationDef, 0, len(o.Include))
+	for _, name := range o.Include {
+		rel, found := this.ed.Relation(name)
+		if !found {
+			return nil, query.UnknownRelationError{Relation: name}
+		}
+		relations = append(relations, rel)
+	}
+	return relations, nil
+}
+
+// find runs the finder and then loads the relations requested in the options into the found entities in dest.
+// Requested relations are resolved before running the finder, so that an unknown relation fails early.
+func (this SqlRepository) find(ctx context.Context, dest interface{}, opts []query.Option, finder func(o query.Options) error) error {
+	o := query.NewOptions(opts...)
+	relations, err := this.includedRelations(o)
+	if err != nil {
+		return err
+	}
+
+	if err := finder(o); err != nil {
+		return err
+	}
+	return this.loadRelations(ctx, dest, relations, o)
+}
+
+// loadRelations loads the given relations of the entities in dest with an in query per relation (and per batch of keys).
+func (this SqlRepository) loadRelations(ctx context.Context, dest interface{}, relations []metadata.RelationDef, o query.Options) error {
+	if len(relations) == 0 {
+		return nil
+	}
+
+	entities := entityValues(dest)
+	if len(entities) == 0 {
+		return nil
+	}
+
+	for _, rel := range relations {
+		var err error
+		switch rel.Kind {
+		case metadata.ManyToOne:
+			err = this.loadManyToOne(ctx, entities, rel, o)
+		case metadata.OneToMany:
+			err = this.loadOneToMany(ctx, entities, rel, o)
+		default:
+			err = fmt.Errorf("relation %s of %s has unknown kind %s", rel.Name, this.ed.Name(), rel.Kind)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadManyToOne finds the targets referenced by the foreign keys of the entities and sets them to the relation field.
+func (this SqlRepository) loadManyToOne(ctx context.Context, entities []reflect.Value, rel metadata.RelationDef, o query.Options) error {
+	holder, err := this.relationField(entities[0], rel)
+	if err != nil {
+		return err
+	}
+
+	targetType := holder.Type
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+	if targetType.Kind() != reflect.Struct {
+		return fmt.Errorf("field %s of relation %s of %s is not an entity", rel.Field, rel.Name, this.ed.Name())
+	}
+
+	if _, found := entities[0].Type().FieldByName(rel.ForeignKey); !found {
+		return fmt.Errorf("foreign key %s of relation %s of %s does not exist", rel.ForeignKey, rel.Name, this.ed.Name())
+	}
+	keys := distinctKeys(entities, rel.ForeignKey)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	target, err := relationTargetOf(rel, targetType)
+	if err != nil {
+		return err
+	}
+	pkField, err := pkFieldOf(target.ed, target.cm)
+	if err != nil {
+		return err
+	}
+
+	related, err := this.findRelated(ctx, target, targetType, pkField, keys, o)
+	if err != nil {
+		return err
+	}
+
+	byKey := make(map[string]reflect.Value, related.Len())
+	for i := 0; i < related.Len(); i++ {
+		r := related.Index(i)
+		byKey[keyOf(r.FieldByName(pkField))] = r
+	}
+
+	for _, e := range entities {
+		r, found := byKey[keyOf(e.FieldByName(rel.ForeignKey))]
+		if !found {
+			continue
+		}
+
+		f := e.FieldByName(rel.Field)
+		if f.Kind() == reflect.Ptr {
+			f.Set(r.Addr())
+		} else {
+			f.Set(r)
+		}
+	}
+	return nil
+}
+
+// loadOneToMany finds the targets referring to the entities by their foreign keys and sets them to the relation field.
+func (this SqlRepository) loadOneToMany(ctx context.Context, entities []reflect.Value, rel metadata.RelationDef, o query.Options) error {
+	holder, err := this.relationField(entities[0], rel)
+	if err != nil {
+		return err
+	}
+
+	if holder.Type.Kind() != reflect.Slice {
+		return fmt.Errorf("field %s of relation %s of %s is not a slice", rel.Field, rel.Name, this.ed.Name())
+	}
+	elemType := holder.Type.Elem()
+	targetType := elemType
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+	if targetType.Kind() != reflect.Struct {
+		return fmt.Errorf("field %s of relation %s of %s is not a slice of entities", rel.Field, rel.Name, this.ed.Name())
+	}
+
+	pkField, err := pkFieldOf(this.ed, this.cm)
+	if err != nil {
+		return err
+	}
+	keys := distinctKeys(entities, pkField)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	target, err := relationTargetOf(rel, targetType)
+	if err != nil {
+		return err
+	}
+	if !target.cm.HasColumn(rel.ForeignKey) {
+		return fmt.Errorf("foreign key %s of relation %s of %s is not a field of %s", rel.ForeignKey, rel.Name, this.ed.Name(), rel.Target)
+	}
+
+	related, err := this.findRelated(ctx, target, targetType, rel.ForeignKey, keys, o)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string][]reflect.Value)
+	for i := 0; i < related.Len(); i++ {
+		r := related.Index(i)
+		k := keyOf(r.FieldByName(rel.ForeignKey))
+		groups[k] = append(groups[k], r)
+	}
+
+	for _, e := range entities {
+		group := groups[keyOf(e.FieldByName(pkField))]
+
+		list := reflect.MakeSlice(holder.Type, 0, len(group))
+		for _, r := range group {
+			if elemType.Kind() == reflect.Ptr {
+				list = reflect.Append(list, r.Addr())
+			} else {
+				list = reflect.Append(list, r)
+			}
+		}
+		e.FieldByName(rel.Field).Set(list)
+	}
+	return nil
+}
+
+// findRelated finds the target entities whose field has one of the keys, splitting the keys into batches.
+// Soft deleted targets are only found if the options include deleted entities.
+func (this SqlRepository) findRelated(ctx context.Context, target relationTarget, targetType reflect.Type, field string, keys []interface{}, o query.Options) (reflect.Value, error) {
+	var opts []query.Option
+	if o.IncludeDeleted {
+		opts = append(opts, query.IncludeDeleted())
+	}
+
+	related := reflect.MakeSlice(reflect.SliceOf(targetType), 0, len(keys))
+	for start := 0; start < len(keys); start += relationBatchSize {
+		end := start + relationBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		q, params, err := query.BuildQueryByCriteria(target.ed, target.qd, target.cm, query.In(field, keys[start:end]...), 0, 0, opts...)
+		if err != nil {
+			return related, err
+		}
+
+		batch := reflect.New(related.Type())
+		if err := sqlx.SelectContext(ctx, this.ext(ctx), batch.Interface(), q, params...); err != nil {
+			return related, err
+		}
+		related = reflect.AppendSlice(related, batch.Elem())
+	}
+	return related, nil
+}
+
+// relationField returns the field of the entity that holds the related entities, which must not be mapped to a column.
+func (this SqlRepository) relationField(entity reflect.Value, rel metadata.RelationDef) (reflect.StructField, error) {
+	f, found := entity.Type().FieldByName(rel.Field)
+	if !found {
+		return f, fmt.Errorf("field %s of relation %s of %s does not exist", rel.Field, rel.Name, this.ed.Name())
+	}
+	if this.cm.HasColumn(rel.Field) {
+		return f, fmt.Errorf("field %s of relation %s of %s is mapped to a column", rel.Field, rel.Name, this.ed.Name())
+	}
+	return f, nil
+}
+
+// registerRelationTargets registers the metadata of the relation targets of the entity when its repository is created,
+// so that loading the relations does not register them while requests are served.
+// Targets whose entity def is not registered yet are left to relationTargetOf, which registers them on first use.
+func registerRelationTargets(ed metadata.EntityDef, introspect interface{}) {
+	t := reflect.Indirect(reflect.ValueOf(introspect)).Type()
+	for _, rel := range ed.Relations() {
+		f, found := t.FieldByName(rel.Field)
+		if !found {
+			continue
+		}
+
+		targetType := f.Type
+		if targetType.Kind() == reflect.Slice {
+			targetType = targetType.Elem()
+		}
+		if targetType.Kind() == reflect.Ptr {
+			targetType = targetType.Elem()
+		}
+		if targetType.Kind() != reflect.Struct || domain.EntityDefByName(rel.Target) == nil {
+			continue
+		}
+		// problems of the relation are reported when it is loaded
+		relationTargetOf(rel, targetType)
+	}
+}
+
+// relationTargetOf returns the metadata of the relation target, registering it if neither the repository of the target
+// nor the repository of the owner has registered it yet.
+func relationTargetOf(rel metadata.RelationDef, targetType reflect.Type) (relationTarget, error) {
+	ed := domain.EntityDefByName(rel.Target)
+	if ed == nil {
+		return relationTarget{}, fmt.Errorf("target %s of relation %s is not a registered entity", rel.Target, rel.Name)
+	}
+
+	relationTargetMutex.Lock()
+	defer relationTargetMutex.Unlock()
+
+	introspect := reflect.New(targetType).Interface()
+	cm, found := metadata.GetColumnMapper(ed)
+	if !found {
+		cm = metadata.NewColumnMapper(ed, introspect)
+	}
+	qd, found := query.GetQueryDef(ed)
+	if !found {
+		qd = query.BuildQueryDef(introspect, ed, cm)
+	}
+	return relationTarget{ed, cm, qd}, nil
+}
+
+// pkFieldOf finds the field that is mapped to the primary key column of the entity.
+func pkFieldOf(ed metadata.EntityDef, cm metadata.ColumnMapper) (string, error) {
+	for _, f := range cm.Fields() {
+		if cm.Column(f) == ed.PKColumn() {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no field for primary key column %s", ed.Name(), ed.PKColumn())
+}
+
+// entityValues returns the addressable entities in dest, which is a pointer to an entity or to a slice of entities.
+func entityValues(dest interface{}) []reflect.Value {
+	v := reflect.Indirect(reflect.ValueOf(dest))
+	switch v.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{v}
+	case reflect.Slice:
+		entities := make([]reflect.Value, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if e := reflect.Indirect(v.Index(i)); e.Kind() == reflect.Struct {
+				entities = append(entities, e)
+			}
+		}
+		return entities
+	}
+	return nil
+}
+
+// distinctKeys collects the distinct non-zero values of the field of the entities.
+func distinctKeys(entities []reflect.Value, field string) []interface{} {
+	seen := make(map[string]bool)
+	var keys []interface{}
+	for _, e := range entities {
+		f := e.FieldByName(field)
+		if f.IsZero() {
+			continue
+		}
+
+		k := keyOf(f)
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, reflect.Indirect(f).Interface())
+		}
+	}
+	return keys
+}
+
+// keyOf renders a key value as a string, so that keys of different integer types are matched with each other.
+func keyOf(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return ""
+	}
+	return fmt.Sprint(reflect.Indirect(v).Interface())
+}
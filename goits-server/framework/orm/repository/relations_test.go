@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/stretchr/testify/assert"
+)
+
+type RelationTargetTestEntity struct {
+	domain.DomainEntity
+	Name  string `db:"name"`
+	Owner uint64 `db:"owner"`
+}
+
+type RelationOwnerTestEntity struct {
+	domain.DomainEntity
+	Name      string                     `db:"name"`
+	Target    uint64                     `db:"target"`
+	TargetRef *RelationTargetTestEntity  `db:"-"`
+	Children  []RelationTargetTestEntity `db:"-"`
+}
+
+type RelationLateTestEntity struct {
+	domain.DomainEntity
+	Name string `db:"name"`
+}
+
+type RelationLateOwnerTestEntity struct {
+	domain.DomainEntity
+	Name    string                  `db:"name"`
+	Late    uint64                  `db:"late"`
+	LateRef *RelationLateTestEntity `db:"-"`
+}
+
+func init() {
+	domain.RegisterEntityDef(metadata.NewEntityDef("repository.RelationTargetTestEntity", "data", "target", "id", "name asc", false))
+}
+
+func newRelationRepository(t *testing.T) (SqlRepository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	assert.Nil(t, err, "could not create mock db")
+	db.WithDB(mockDB, "sqlmock")
+	t.Cleanup(func() {
+		mockDB.Close()
+	})
+
+	ed := metadata.NewEntityDef("repository.RelationOwnerTestEntity", "data", "owner", "id", "name asc", false,
+		metadata.RelationDef{Name: "Target", Kind: metadata.ManyToOne, Target: "repository.RelationTargetTestEntity", ForeignKey: "Target", Field: "TargetRef"},
+		metadata.RelationDef{Name: "Children", Kind: metadata.OneToMany, Target: "repository.RelationTargetTestEntity", ForeignKey: "Owner", Field: "Children"})
+	return NewRepository(ed, &RelationOwnerTestEntity{}), mock
+}
+
+func TestFindAll_IncludeManyToOne(t *testing.T) {
+	// given
+	repo, mock := newRelationRepository(t)
+	mock.ExpectQuery("select .* from data.owner order by name asc").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "target"}).
+			AddRow(1, "first", 7).
+			AddRow(2, "second", 7).
+			AddRow(3, "third", 0))
+	mock.ExpectQuery("select .* from data.target where id in \\(\\$1\\) order by name asc").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "target"))
+
+	// when
+	var owners []RelationOwnerTestEntity
+	err := repo.FindAll(context.Background(), &owners, query.Include("target"))
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "relation should be loaded by a single query")
+	assert.Equal(t, 3, len(owners), "not all rows are returned")
+	assert.Equal(t, "target", owners[0].TargetRef.Name, "relation is not loaded")
+	assert.Same(t, owners[0].TargetRef, owners[1].TargetRef, "same target should be shared")
+	assert.Nil(t, owners[2].TargetRef, "entity without foreign key should have no relation")
+}
+
+func TestFindOneById_IncludeOneToMany(t *testing.T) {
+	// given
+	repo, mock := newRelationRepository(t)
+	mock.ExpectQuery("select .* from data.owner where id = \\$1").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "target"}).AddRow(5, "owner", 0))
+	mock.ExpectQuery("select .* from data.target where owner in \\(\\$1\\) order by name asc").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner"}).
+			AddRow(10, "first child", 5).
+			AddRow(11, "second child", 5))
+
+	// when
+	var owner RelationOwnerTestEntity
+	err := repo.FindOneById(context.Background(), &owner, 5, query.Include("Children"))
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "relation should be loaded by a single query")
+	assert.Equal(t, 2, len(owner.Children), "children are not loaded")
+	assert.Equal(t, uint64(11), owner.Children[1].Id, "children are not in order")
+}
+
+func TestFindAll_IncludeUnknownRelation_Error(t *testing.T) {
+	// given
+	repo, mock := newRelationRepository(t)
+
+	// when
+	var owners []RelationOwnerTestEntity
+	err := repo.FindAll(context.Background(), &owners, query.Include("Parent"))
+
+	// then
+	assert.Equal(t, query.UnknownRelationError{Relation: "Parent"}, err, "relation should be unknown")
+	assert.Nil(t, mock.ExpectationsWereMet(), "no query should be run")
+}
+
+func TestNewRepository_RegistersRelationTargets(t *testing.T) {
+	// given
+	ed := metadata.NewEntityDef("repository.RelationEagerOwnerTestEntity", "data", "eager_owner", "id", "name asc", false,
+		metadata.RelationDef{Name: "Target", Kind: metadata.ManyToOne, Target: "repository.RelationTargetTestEntity", ForeignKey: "Target", Field: "TargetRef"})
+	targetEd := domain.EntityDefByName("repository.RelationTargetTestEntity")
+
+	// when
+	NewRepository(ed, &RelationOwnerTestEntity{})
+
+	// then
+	_, cmFound := metadata.GetColumnMapper(targetEd)
+	_, qdFound := query.GetQueryDef(targetEd)
+	assert.True(t, cmFound, "column mapper of relation target is not registered")
+	assert.True(t, qdFound, "query def of relation target is not registered")
+}
+
+func TestFindAll_IncludeConcurrent_TargetNotBuilt(t *testing.T) {
+	// given
+	const workers = 8
+	mockDB, mock, err := sqlmock.New()
+	assert.Nil(t, err, "could not create mock db")
+	db.WithDB(mockDB, "sqlmock")
+	t.Cleanup(func() {
+		mockDB.Close()
+	})
+	mock.MatchExpectationsInOrder(false)
+
+	ed := metadata.NewEntityDef("repository.RelationLateOwnerTestEntity", "data", "late_owner", "id", "name asc", false,
+		metadata.RelationDef{Name: "Late", Kind: metadata.ManyToOne, Target: "repository.RelationLateTestEntity", ForeignKey: "Late", Field: "LateRef"})
+	repo := NewRepository(ed, &RelationLateOwnerTestEntity{})
+	// the target is registered after the owner, so it is built while the relation is loaded
+	targetEd := metadata.NewEntityDef("repository.RelationLateTestEntity", "data", "late", "id", "name asc", false)
+	domain.RegisterEntityDef(targetEd)
+
+	for i := 0; i < workers; i++ {
+		mock.ExpectQuery("select .* from data.late_owner order by name asc").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "late"}).AddRow(1, "owner", 3))
+		mock.ExpectQuery("select .* from data.late where id in \\(\\$1\\) order by name asc").
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "late"))
+	}
+
+	// when
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			var owners []RelationLateOwnerTestEntity
+			if err := repo.FindAll(context.Background(), &owners, query.Include("Late")); err != nil {
+				errs <- err
+				return
+			}
+			if len(owners) != 1 || owners[0].LateRef == nil {
+				errs <- assert.AnError
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			metadata.GetColumnMapper(targetEd)
+			query.GetQueryDef(targetEd)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// then
+	for err := range errs {
+		assert.Nil(t, err, "relation should be loaded concurrently")
+	}
+	assert.Nil(t, mock.ExpectationsWereMet(), "not all relations are loaded")
+}
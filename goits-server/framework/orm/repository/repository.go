@@ -2,38 +2,93 @@ package repository
 
 import (
 	"context"
+	"reflect"
 
+	"github.com/cpekyaman/goits/framework/orm/db"
 	"github.com/cpekyaman/goits/framework/orm/domain"
 	"github.com/cpekyaman/goits/framework/orm/metadata"
 	"github.com/cpekyaman/goits/framework/orm/query"
-	"github.com/cpekyaman/goits/framework/orm/db"
 )
 
 // ReaderRepository provides common querying functionality for db entities.
+// Finders load the relations requested by the query.Include option into the found entities,
+// where single entity finders ignore the other options.
 type ReaderRepository interface {
 	// FindOneById finds a single entity by the given id.
-	FindOneById(ctx context.Context, dest interface{}, id uint64) error
+	FindOneById(ctx context.Context, dest interface{}, id uint64, opts ...query.Option) error
 
 	// FindAll finds all entities.
-	FindAll(ctx context.Context, dest interface{}) error
+	FindAll(ctx context.Context, dest interface{}, opts ...query.Option) error
 
 	// FindAllPaged finds all entities in the given page offset and limit.
-	FindAllPaged(ctx context.Context, dest interface{}, limit uint, offset uint64) error
+	FindAllPaged(ctx context.Context, dest interface{}, limit uint, offset uint64, opts ...query.Option) error
 
 	// FindOneByAttribute is a generic finder to find a single entity by a unique attribute.
-	FindOneByAttribute(ctx context.Context, dest interface{}, attr string, bindval interface{}) error
+	FindOneByAttribute(ctx context.Context, dest interface{}, attr string, bindval interface{}, opts ...query.Option) error
 
 	// FindAllByAttributes finds all entities that match the criteria.
-	FindAllByAttributes(ctx context.Context, dest interface{}, attrs map[string]interface{}) error
+	FindAllByAttributes(ctx context.Context, dest interface{}, attrs map[string]interface{}, opts ...query.Option) error
 
 	// FindAllByAttributesPaged finds all entities in the given page offset and limit which match the criteria.
-	FindAllByAttributesPaged(ctx context.Context, dest interface{}, attrs map[string]interface{}, limit uint, offset uint64) error
+	FindAllByAttributesPaged(ctx context.Context, dest interface{}, attrs map[string]interface{}, limit uint, offset uint64, opts ...query.Option) error
+
+	// FindAllByCriteria finds all entities that match the criteria.
+	FindAllByCriteria(ctx context.Context, dest interface{}, c query.Criteria, opts ...query.Option) error
+
+	// FindAllByCriteriaPaged finds all entities in the given page offset and limit which match the criteria.
+	FindAllByCriteriaPaged(ctx context.Context, dest interface{}, c query.Criteria, limit uint, offset uint64, opts ...query.Option) error
+
+	// FindAllByCursor finds up to limit entities which match the criteria and come after the cursor position.
+	// The sort is the one given in options or the default sort of the entity, always followed by the primary key.
+	// The returned cursor points after the last found entity, and is zero if there are no more entities.
+	FindAllByCursor(ctx context.Context, dest interface{}, c query.Criteria, after query.Cursor, limit uint, opts ...query.Option) (query.Cursor, error)
+
+	// Stream reads the entities which match the criteria one row at a time and gives each one to the handler,
+	// so that any number of entities can be processed without loading them all into memory.
+	// Each entity is a new pointer of the entity type. Reading stops at the first error of the handler, which is returned,
+	// or when the context is done. Relations can not be included.
+	Stream(ctx context.Context, c query.Criteria, handler func(entity interface{}) error, opts ...query.Option) error
+
+	// Count returns the number of all entities.
+	Count(ctx context.Context) (uint64, error)
+
+	// CountByCriteria returns the number of entities that match the criteria.
+	CountByCriteria(ctx context.Context, c query.Criteria, opts ...query.Option) (uint64, error)
 }
 
 // WriterRepository provides basic modify functionality for db entities.
 type WriterRepository interface {
 	Save(ctx context.Context, entity domain.Entity) error
+
+	// Upsert inserts the entity or, if an entity with the same unique key exists, updates that one instead.
+	// The unique key is declared in the metadata of the entity, which is refreshed with the resulting id and version.
+	Upsert(ctx context.Context, entity domain.Entity) error
+
+	// SaveFields updates only the columns of the given fields of an existing entity.
+	SaveFields(ctx context.Context, entity domain.Entity, fields ...string) error
+
+	// SaveChanges updates only the columns of the fields that differ between an existing entity and its loaded snapshot.
+	// If nothing updatable has changed, no update is done but the entity is still checked against concurrent changes.
+	SaveChanges(ctx context.Context, entity domain.Entity, snapshot interface{}) error
+
+	// SaveAll inserts the new entities and updates the existing ones within a single transaction.
+	// New entities are inserted in batches with multi-row statements and refreshed with their db generated values,
+	// which are matched to them by the unique key. Entities without a unique key in metadata are inserted one by one.
+	// Existing entities are updated one by one, each with its own check against concurrent modifications.
+	SaveAll(ctx context.Context, entities []domain.Entity) error
+
+	// Delete deletes the entity, which only marks it as deleted if the entity uses soft delete.
 	Delete(ctx context.Context, id uint64) error
+
+	// DeleteVersion deletes the entity in the same way as Delete, but only if it still has the given version.
+	// It fails with OptimisticLockError if the entity has another version, and with ErrNotFound if there is no such entity.
+	DeleteVersion(ctx context.Context, id uint64, version uint32) error
+
+	// DeleteAll deletes the entities with a single statement, in the same way as Delete.
+	DeleteAll(ctx context.Context, ids []uint64) error
+
+	// Restore brings back a soft deleted entity, it fails with ErrNotFound if there is no such deleted entity.
+	Restore(ctx context.Context, id uint64) error
 }
 
 // Repository is a generic repository definition for standard crud operations.
@@ -46,6 +101,7 @@ type Repository interface {
 func NewRepository(ed metadata.EntityDef, introspect interface{}) SqlRepository {
 	cm := metadata.NewColumnMapper(ed, introspect)
 	qd := query.BuildQueryDef(introspect, ed, cm)
+	registerRelationTargets(ed, introspect)
 
-	return SqlRepository{db.DB(), ed, qd, cm}
+	return SqlRepository{db.DB(), ed, qd, cm, reflect.Indirect(reflect.ValueOf(introspect)).Type()}
 }
@@ -2,9 +2,17 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
+	"github.com/cpekyaman/goits/framework/commons"
 	"github.com/cpekyaman/goits/framework/monitoring"
+	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/dialect"
 	"github.com/cpekyaman/goits/framework/orm/domain"
 	"github.com/cpekyaman/goits/framework/orm/metadata"
 	"github.com/cpekyaman/goits/framework/orm/query"
@@ -14,54 +22,177 @@ import (
 
 var hist monitoring.HistogramBundle
 var cnt monitoring.CounterBundle
+var rowCnt monitoring.CounterBundle
 
 func init() {
 	labels := []string{"type", "query"}
 	hist = monitoring.NewHistogram("db_query_duration_ms", labels)
 	cnt = monitoring.NewCounter("db_query_executions", labels)
+	rowCnt = monitoring.NewCounter("db_batch_rows", labels)
 }
 
+// saveBatchSize is the maximum number of rows inserted by a single statement.
+const saveBatchSize = 500
+
 // SqlRepository is the Repository implementation for sql db.
 type SqlRepository struct {
 	db *sqlx.DB
 	ed metadata.EntityDef
 	qd query.QueryDef
 	cm metadata.ColumnMapper
+	et reflect.Type
 }
 
-func (this SqlRepository) FindOneById(ctx context.Context, dest interface{}, id uint64) error {
+func (this SqlRepository) FindOneById(ctx context.Context, dest interface{}, id uint64, opts ...query.Option) error {
 	defer this.log(ctx, "FindOneById", time.Now())
-	return this.db.GetContext(ctx, dest, this.qd.FindOne(), id)
+	return this.find(ctx, dest, opts, func(o query.Options) error {
+		return sqlx.GetContext(ctx, this.ext(ctx), dest, query.BuildFindOneByIdQuery(this.ed, this.qd, opts...), id)
+	})
 }
 
-func (this SqlRepository) FindAll(ctx context.Context, dest interface{}) error {
+func (this SqlRepository) FindAll(ctx context.Context, dest interface{}, opts ...query.Option) error {
 	defer this.log(ctx, "FindAll", time.Now())
-	return this.db.SelectContext(ctx, dest, this.qd.FindAll())
+	return this.findAllByCriteria(ctx, dest, nil, 0, 0, opts)
 }
 
-func (this SqlRepository) FindAllPaged(ctx context.Context, dest interface{}, limit uint, offset uint64) error {
+func (this SqlRepository) FindAllPaged(ctx context.Context, dest interface{}, limit uint, offset uint64, opts ...query.Option) error {
 	defer this.log(ctx, "FindAllPaged", time.Now())
-	return this.db.SelectContext(ctx, dest, query.BuildFindAllPagedQuery(this.ed, this.qd, limit, offset))
+	return this.findAllByCriteria(ctx, dest, nil, limit, offset, opts)
 }
 
-func (this SqlRepository) FindOneByAttribute(ctx context.Context, dest interface{}, attr string, bindval interface{}) error {
+func (this SqlRepository) FindOneByAttribute(ctx context.Context, dest interface{}, attr string, bindval interface{}, opts ...query.Option) error {
 	defer this.log(ctx, "FindOneByAttribute", time.Now())
-
-	return this.db.GetContext(ctx, dest, query.BuildFindOneQuery(this.ed, this.qd, this.cm, attr), bindval)
+	return this.find(ctx, dest, opts, func(o query.Options) error {
+		return sqlx.GetContext(ctx, this.ext(ctx), dest, query.BuildFindOneQuery(this.ed, this.qd, this.cm, attr, opts...), bindval)
+	})
 }
 
-func (this SqlRepository) FindAllByAttributes(ctx context.Context, dest interface{}, attrs map[string]interface{}) error {
+func (this SqlRepository) FindAllByAttributes(ctx context.Context, dest interface{}, attrs map[string]interface{}, opts ...query.Option) error {
 	defer this.log(ctx, "FindAllByAttributes", time.Now())
-
-	q, params := query.BuildQueryByAttributes(this.ed, this.qd, this.cm, attrs, 0, 0)
-	return this.db.SelectContext(ctx, dest, q, params)
+	return this.findAllByCriteria(ctx, dest, query.AttributesCriteria(attrs), 0, 0, opts)
 }
 
-func (this SqlRepository) FindAllByAttributesPaged(ctx context.Context, dest interface{}, attrs map[string]interface{}, limit uint, offset uint64) error {
+func (this SqlRepository) FindAllByAttributesPaged(ctx context.Context, dest interface{}, attrs map[string]interface{}, limit uint, offset uint64, opts ...query.Option) error {
 	defer this.log(ctx, "FindAllByAttributesPaged", time.Now())
+	return this.findAllByCriteria(ctx, dest, query.AttributesCriteria(attrs), limit, offset, opts)
+}
+
+func (this SqlRepository) FindAllByCriteria(ctx context.Context, dest interface{}, c query.Criteria, opts ...query.Option) error {
+	defer this.log(ctx, "FindAllByCriteria", time.Now())
+	return this.findAllByCriteria(ctx, dest, c, 0, 0, opts)
+}
+
+func (this SqlRepository) FindAllByCriteriaPaged(ctx context.Context, dest interface{}, c query.Criteria, limit uint, offset uint64, opts ...query.Option) error {
+	defer this.log(ctx, "FindAllByCriteriaPaged", time.Now())
+	return this.findAllByCriteria(ctx, dest, c, limit, offset, opts)
+}
+
+// findAllByCriteria runs the criteria query, which is paged if a non-zero limit is given, and loads the included relations.
+func (this SqlRepository) findAllByCriteria(ctx context.Context, dest interface{}, c query.Criteria, limit uint, offset uint64, opts []query.Option) error {
+	return this.find(ctx, dest, opts, func(o query.Options) error {
+		q, params, err := query.BuildQueryByCriteria(this.ed, this.qd, this.cm, c, limit, offset, opts...)
+		if err != nil {
+			return err
+		}
+		return sqlx.SelectContext(ctx, this.ext(ctx), dest, q, params...)
+	})
+}
+
+func (this SqlRepository) FindAllByCursor(ctx context.Context, dest interface{}, c query.Criteria, after query.Cursor, limit uint, opts ...query.Option) (query.Cursor, error) {
+	defer this.log(ctx, "FindAllByCursor", time.Now())
+
+	if limit == 0 {
+		return query.Cursor{}, fmt.Errorf("criteria: limit of a cursor query must be positive")
+	}
+
+	o := query.NewOptions(opts...)
+	relations, err := this.includedRelations(o)
+	if err != nil {
+		return query.Cursor{}, err
+	}
+
+	// one more row than requested tells if there is a next page
+	q, params, sort, err := query.BuildQueryByCursor(this.ed, this.qd, this.cm, c, after, limit+1, opts...)
+	if err != nil {
+		return query.Cursor{}, err
+	}
+	if err = sqlx.SelectContext(ctx, this.ext(ctx), dest, q, params...); err != nil {
+		return query.Cursor{}, err
+	}
+
+	var next query.Cursor
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() > int(limit) {
+		rows.Set(rows.Slice(0, int(limit)))
+		if next, err = query.NewCursor(sort, rows.Index(int(limit)-1).Interface()); err != nil {
+			return query.Cursor{}, err
+		}
+	}
+
+	// relations are loaded only for the rows of the page
+	if err = this.loadRelations(ctx, dest, relations, o); err != nil {
+		return query.Cursor{}, err
+	}
+	return next, nil
+}
+
+func (this SqlRepository) Stream(ctx context.Context, c query.Criteria, handler func(entity interface{}) error, opts ...query.Option) error {
+	count := 0
+	defer func(start time.Time) {
+		this.logBatch(ctx, "Stream", count, start)
+	}(time.Now())
+
+	if len(query.NewOptions(opts...).Include) > 0 {
+		return fmt.Errorf("criteria: relations can not be included in a stream")
+	}
+
+	q, params, err := query.BuildQueryByCriteria(this.ed, this.qd, this.cm, c, 0, 0, opts...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := this.ext(ctx).QueryxContext(ctx, q, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entity := reflect.New(this.et).Interface()
+		if err := rows.StructScan(entity); err != nil {
+			return err
+		}
+		if err := handler(entity); err != nil {
+			return err
+		}
+		count++
+	}
+	return rows.Err()
+}
+
+func (this SqlRepository) Count(ctx context.Context) (uint64, error) {
+	defer this.log(ctx, "Count", time.Now())
 
-	q, params := query.BuildQueryByAttributes(this.ed, this.qd, this.cm, attrs, limit, offset)
-	return this.db.SelectContext(ctx, dest, q, params)
+	var count uint64
+	err := sqlx.GetContext(ctx, this.ext(ctx), &count, this.qd.Count())
+	return count, err
+}
+
+func (this SqlRepository) CountByCriteria(ctx context.Context, c query.Criteria, opts ...query.Option) (uint64, error) {
+	defer this.log(ctx, "CountByCriteria", time.Now())
+
+	q, params, err := query.BuildCountQueryByCriteria(this.ed, this.cm, c, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	err = sqlx.GetContext(ctx, this.ext(ctx), &count, q, params...)
+	return count, err
 }
 
 func (this SqlRepository) Save(ctx context.Context, entity domain.Entity) error {
@@ -76,27 +207,369 @@ func (this SqlRepository) Save(ctx context.Context, entity domain.Entity) error
 	}
 
 	defer this.log(ctx, qt, time.Now())
+	return this.save(ctx, q, qt == "Update", entity)
+}
+
+func (this SqlRepository) Upsert(ctx context.Context, entity domain.Entity) error {
+	if this.qd.Upsert() == "" {
+		return ErrUpsertNotSupported
+	}
+
+	defer this.log(ctx, "Upsert", time.Now())
+	return this.save(ctx, this.qd.Upsert(), false, entity)
+}
+
+func (this SqlRepository) SaveFields(ctx context.Context, entity domain.Entity, fields ...string) error {
+	defer this.log(ctx, "UpdateFields", time.Now())
+	return this.save(ctx, query.BuildUpdateQuery(this.ed, this.cm, entity, fields), true, entity)
+}
+
+func (this SqlRepository) SaveChanges(ctx context.Context, entity domain.Entity, snapshot interface{}) error {
+	var fields []string
+	for _, f := range domain.ChangedFields(snapshot, entity) {
+		if this.cm.HasColumn(f) && !domain.IsNonUpdatableField(f) {
+			fields = append(fields, f)
+		}
+	}
+
+	if len(fields) == 0 {
+		defer this.log(ctx, "VerifyUnchanged", time.Now())
+		return this.verifyUnchanged(ctx, entity)
+	}
+	return this.SaveFields(ctx, entity, fields...)
+}
+
+// verifyUnchanged checks that a versioned entity which is not going to be updated still has the version it is loaded with.
+// Otherwise the caller would be told the state it has is current while it is modified concurrently.
+func (this SqlRepository) verifyUnchanged(ctx context.Context, entity domain.Entity) error {
+	v, ok := entity.(domain.Versioned)
+	if !ok {
+		return nil
+	}
+
+	var current uint32
+	err := sqlx.GetContext(ctx, this.ext(ctx), &current, this.qd.CurrentVersion(), entity.GetId())
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if current != v.GetVersion() {
+		return OptimisticLockError{this.ed.Name(), entity.GetId(), v.GetVersion(), current}
+	}
+	return nil
+}
+
+// save runs the insert or update statement and refreshes the entity with the returned db generated values.
+func (this SqlRepository) save(ctx context.Context, q string, update bool, entity domain.Entity) error {
+	rows, err := sqlx.NamedQueryContext(ctx, this.ext(ctx), q, entity)
+	if err != nil {
+		return err
+	}
+
+	// the statements return the db generated columns, which are scanned back into the entity
+	found := rows.Next()
+	if found {
+		err = rows.StructScan(entity)
+	}
+	// rows must be closed before running another query on the same connection
+	rows.Close()
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	if !found && update {
+		return this.updateConflict(ctx, entity)
+	}
+	return nil
+}
+
+// updateConflict finds out why an update did not affect any rows,
+// which is either a stale version of a versioned entity or a missing entity.
+func (this SqlRepository) updateConflict(ctx context.Context, entity domain.Entity) error {
+	v, ok := entity.(domain.Versioned)
+	if !ok {
+		return ErrNotFound
+	}
+
+	var current uint32
+	err := sqlx.GetContext(ctx, this.ext(ctx), &current, this.qd.CurrentVersion(), entity.GetId())
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	return OptimisticLockError{this.ed.Name(), entity.GetId(), v.GetVersion(), current}
+}
+
+func (this SqlRepository) SaveAll(ctx context.Context, entities []domain.Entity) error {
+	var inserts []domain.Entity
+	var updates []domain.Entity
+	for _, e := range entities {
+		if e.GetId() > 0 {
+			updates = append(updates, e)
+		} else {
+			inserts = append(inserts, e)
+		}
+	}
+
+	return db.NewTxManagerFor(this.db).WithinTx(ctx, func(ctx context.Context) error {
+		batchSize := saveBatchSize
+		maxParams := dialect.Current().MaxParams()
+		if cols := query.InsertColumnCount(this.cm); cols > 0 && maxParams/cols < batchSize {
+			batchSize = maxParams / cols
+		}
+
+		// without a unique key, the returned rows can not be matched to the entities of a batch
+		if len(this.ed.UniqueKey()) == 0 {
+			batchSize = 1
+		}
+
+		for start := 0; start < len(inserts); start += batchSize {
+			end := start + batchSize
+			if end > len(inserts) {
+				end = len(inserts)
+			}
+			if err := this.insertBatch(ctx, inserts[start:end]); err != nil {
+				return err
+			}
+		}
+
+		// updates are not batched, each of them is checked against concurrent modifications with its own version condition
+		for _, e := range updates {
+			start := time.Now()
+			err := this.save(ctx, this.qd.Update(), true, e)
+			this.log(ctx, "Update", start)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// insertBatch inserts the entities with a single multi-row insert statement, and refreshes them with the db generated values.
+// The returned rows are matched to the entities by their unique key, since the sql standard does not guarantee
+// that the rows are returned in the order of the values. A single entity is inserted with the default insert statement.
+func (this SqlRepository) insertBatch(ctx context.Context, entities []domain.Entity) error {
+	if len(entities) == 1 {
+		defer this.log(ctx, "Create", time.Now())
+		return this.save(ctx, this.qd.Insert(), false, entities[0])
+	}
+	defer this.logBatch(ctx, "CreateBatch", len(entities), time.Now())
+
+	byKey := make(map[string]domain.Entity, len(entities))
+	var params []interface{}
+	for _, e := range entities {
+		byKey[this.uniqueKeyOf(e)] = e
+
+		// the default insert statement gives the values of a row in the column order of the batch statement
+		_, args, err := sqlx.Named(this.qd.Insert(), e)
+		if err != nil {
+			return err
+		}
+		params = append(params, args...)
+	}
+	if len(byKey) != len(entities) {
+		return fmt.Errorf("validation: batch of %s has entities with the same unique key", this.ed.Name())
+	}
 
-	_, err := this.db.NamedExecContext(ctx, q, entity)
+	rows, err := this.ext(ctx).QueryxContext(ctx, query.BuildInsertAllQuery(this.ed, this.cm, len(entities)), params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// nothing to refresh the entities with if the entity has no db generated columns
+	if columns, err := rows.Columns(); err != nil || len(columns) == 0 {
+		return err
+	}
+
+	entityType := reflect.TypeOf(entities[0]).Elem()
+	for rows.Next() {
+		row := reflect.New(entityType)
+		if err := rows.StructScan(row.Interface()); err != nil {
+			return err
+		}
+
+		key := this.uniqueKeyOf(row.Interface())
+		entity, found := byKey[key]
+		if !found {
+			return fmt.Errorf("sql: batch insert of %s returned a row that is not in the batch", this.ed.Name())
+		}
+		delete(byKey, key)
+		this.copyGenerated(row.Elem(), reflect.ValueOf(entity).Elem())
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(byKey) > 0 {
+		return fmt.Errorf("sql: batch insert of %d %s returned %d rows", len(entities), this.ed.Name(), len(entities)-len(byKey))
+	}
+	return nil
+}
+
+// uniqueKeyOf builds a map key from the values of the unique key fields of the entity.
+func (this SqlRepository) uniqueKeyOf(entity interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+
+	var key strings.Builder
+	for _, col := range this.ed.UniqueKey() {
+		for _, f := range this.cm.Fields() {
+			if this.cm.Column(f) != col {
+				continue
+			}
+			fv := v.FieldByName(f)
+			if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			fmt.Fprintf(&key, "%v\x00", fv.Interface())
+		}
+	}
+	return key.String()
+}
+
+// copyGenerated copies the values of the db generated fields from the returned row to the entity.
+func (this SqlRepository) copyGenerated(row reflect.Value, entity reflect.Value) {
+	for _, f := range this.cm.Fields() {
+		if domain.IsNonInsertableField(f) && this.cm.HasColumn(f) {
+			entity.FieldByName(f).Set(row.FieldByName(f))
+		}
+	}
+}
+
+func (this SqlRepository) DeleteAll(ctx context.Context, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	defer this.logBatch(ctx, "DeleteBatch", len(ids), time.Now())
+
+	if !this.ed.SoftDelete() {
+		_, err := this.ext(ctx).ExecContext(ctx, this.qd.DeleteAll(), dialect.Current().List(ids))
+		return err
+	}
+
+	var deletedBy sql.NullString
+	deletedBy.String, deletedBy.Valid = commons.ActorFromContext(ctx)
+	_, err := this.ext(ctx).ExecContext(ctx, this.qd.DeleteAll(), dialect.Current().List(ids), deletedBy)
 	return err
 }
 
 func (this SqlRepository) Delete(ctx context.Context, id uint64) error {
 	defer this.log(ctx, "Delete", time.Now())
-	_, err := this.db.ExecContext(ctx, this.qd.Delete(), id)
+
+	if !this.ed.SoftDelete() {
+		_, err := this.ext(ctx).ExecContext(ctx, this.qd.Delete(), id)
+		return err
+	}
+
+	// the one deleting the entity is recorded if known
+	var deletedBy sql.NullString
+	deletedBy.String, deletedBy.Valid = commons.ActorFromContext(ctx)
+	_, err := this.ext(ctx).ExecContext(ctx, this.qd.Delete(), id, deletedBy)
 	return err
 }
 
+func (this SqlRepository) DeleteVersion(ctx context.Context, id uint64, version uint32) error {
+	defer this.log(ctx, "DeleteVersion", time.Now())
+
+	if this.qd.DeleteVersion() == "" {
+		return ErrVersionNotSupported
+	}
+
+	var res sql.Result
+	var err error
+	if this.ed.SoftDelete() {
+		var deletedBy sql.NullString
+		deletedBy.String, deletedBy.Valid = commons.ActorFromContext(ctx)
+		res, err = this.ext(ctx).ExecContext(ctx, this.qd.DeleteVersion(), id, deletedBy, version)
+	} else {
+		res, err = this.ext(ctx).ExecContext(ctx, this.qd.DeleteVersion(), id, version)
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return this.deleteConflict(ctx, id, version)
+	}
+	return nil
+}
+
+// deleteConflict finds out why a version conditioned delete did not affect any rows,
+// which is either another version of the entity or a missing entity.
+func (this SqlRepository) deleteConflict(ctx context.Context, id uint64, version uint32) error {
+	var current uint32
+	err := sqlx.GetContext(ctx, this.ext(ctx), &current, this.qd.CurrentVersion(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return OptimisticLockError{this.ed.Name(), id, version, current}
+}
+
+func (this SqlRepository) Restore(ctx context.Context, id uint64) error {
+	defer this.log(ctx, "Restore", time.Now())
+
+	if this.qd.Restore() == "" {
+		return ErrRestoreNotSupported
+	}
+
+	res, err := this.ext(ctx).ExecContext(ctx, this.qd.Restore(), id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Executor returns the transaction bound to ctx if there is one, the repository db otherwise.
+// It is used by the custom statements of the repositories embedding SqlRepository.
+func (this SqlRepository) Executor(ctx context.Context) sqlx.ExtContext {
+	return this.ext(ctx)
+}
+
+// ext returns the transaction bound to ctx if there is one, the repository db otherwise.
+func (this SqlRepository) ext(ctx context.Context) sqlx.ExtContext {
+	return db.Executor(ctx, this.db)
+}
+
 func (this SqlRepository) log(ctx context.Context, query string, start time.Time) {
+	this.logBatch(ctx, query, -1, start)
+}
+
+// logBatch logs a statement that is run for a batch of rows, recording the number of rows along with the duration.
+// A negative number of rows means that the statement is not a batch statement.
+func (this SqlRepository) logBatch(ctx context.Context, query string, rows int, start time.Time) {
 	mctx, ok := monitoring.GetMonitoringContext(ctx)
 	if ok {
 		duration := time.Since(start)
 
-		mctx.Logger().
+		logger := mctx.Logger().
 			With(monitoring.StrLogField(monitoring.LF_Type, this.ed.Name()),
 				monitoring.StrLogField(monitoring.LF_Query, query),
-				monitoring.Int64LogField(monitoring.LF_Ms, duration.Milliseconds())).
-			Info("query executed")
+				monitoring.Int64LogField(monitoring.LF_Ms, duration.Milliseconds()))
+		if rows >= 0 {
+			logger = logger.With(monitoring.IntLogField(monitoring.LF_Rows, rows))
+		}
+		logger.Info("query executed")
 
 		lv := map[string]string{
 			"type":  this.ed.Name(),
@@ -105,5 +578,8 @@ func (this SqlRepository) log(ctx context.Context, query string, start time.Time
 
 		hist.With(lv).Record(float64(duration.Milliseconds()))
 		cnt.With(lv).Incr()
+		if rows >= 0 {
+			rowCnt.With(lv).Add(float64(rows))
+		}
 	}
 }
@@ -0,0 +1,373 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cpekyaman/goits/framework/commons"
+	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/stretchr/testify/assert"
+)
+
+type SoftDeleteRepoTestEntity struct {
+	domain.DomainEntity
+	Name string `db:"name"`
+}
+
+func newSoftDeleteRepository(t *testing.T, softDelete bool) (SqlRepository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	assert.Nil(t, err, "could not create mock db")
+	db.WithDB(mockDB, "sqlmock")
+	t.Cleanup(func() {
+		mockDB.Close()
+	})
+
+	ed := metadata.NewEntityDef("repository.SoftDeleteRepoTestEntity", "data", "soft", "id", "name asc", softDelete)
+	return NewRepository(ed, &SoftDeleteRepoTestEntity{}), mock
+}
+
+func TestDelete_SoftDelete_RecordsActor(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, true)
+	mock.ExpectExec(regexp.QuoteMeta("update data.soft set deleted=true, deleted_time=now(), deleted_by=$2 where id = $1")).
+		WithArgs(5, sql.NullString{String: "jdoe", Valid: true}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := repo.Delete(commons.WithActor(context.Background(), "jdoe"), 5)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "soft delete is not correct")
+}
+
+func TestDelete_SoftDelete_UnknownActor(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, true)
+	mock.ExpectExec("update data.soft set deleted=true").
+		WithArgs(5, sql.NullString{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := repo.Delete(context.Background(), 5)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "soft delete is not correct")
+}
+
+func TestRestore_Success(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, true)
+	mock.ExpectExec(regexp.QuoteMeta("update data.soft set deleted=false, deleted_time=null, deleted_by=null where id = $1 AND deleted = true")).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// when
+	err := repo.Restore(context.Background(), 5)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "restore is not correct")
+}
+
+func TestRestore_NotDeleted_NotFound(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, true)
+	mock.ExpectExec("update data.soft set deleted=false").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// when
+	err := repo.Restore(context.Background(), 5)
+
+	// then
+	assert.Equal(t, ErrNotFound, err, "should be a not found error")
+}
+
+func TestRestore_NoSoftDelete_NotSupported(t *testing.T) {
+	// given
+	repo, _ := newSoftDeleteRepository(t, false)
+
+	// when
+	err := repo.Restore(context.Background(), 5)
+
+	// then
+	assert.Equal(t, ErrRestoreNotSupported, err, "restore should not be supported")
+}
+
+func newUniqueKeyRepository(t *testing.T) (SqlRepository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	assert.Nil(t, err, "could not create mock db")
+	db.WithDB(mockDB, "sqlmock")
+	t.Cleanup(func() {
+		mockDB.Close()
+	})
+
+	ed := metadata.WithUniqueKey(metadata.NewEntityDef("repository.SoftDeleteRepoTestEntity", "data", "soft", "id", "name asc", false), "name")
+	return NewRepository(ed, &SoftDeleteRepoTestEntity{}), mock
+}
+
+func TestSaveAll_InsertsInBatchAndUpdates(t *testing.T) {
+	// given
+	repo, mock := newUniqueKeyRepository(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("insert into data.soft(name) values($1), ($2) returning id, name")).
+		WithArgs("first", "second").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(12, "second").AddRow(11, "first"))
+	mock.ExpectQuery(regexp.QuoteMeta("update data.soft set name=? where id=?")).
+		WithArgs("third", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectCommit()
+
+	first := &SoftDeleteRepoTestEntity{Name: "first"}
+	second := &SoftDeleteRepoTestEntity{Name: "second"}
+	third := &SoftDeleteRepoTestEntity{domain.DomainEntity{Id: 3}, "third"}
+
+	// when
+	err := repo.SaveAll(context.Background(), []domain.Entity{first, third, second})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "save all is not correct")
+	assert.Equal(t, uint64(11), first.Id, "generated id is not matched by unique key")
+	assert.Equal(t, uint64(12), second.Id, "generated id is not matched by unique key")
+}
+
+func TestSaveAll_NoUniqueKey_InsertsOneByOne(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, false)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("insert into data.soft(name) values(?) returning id")).
+		WithArgs("first").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectQuery(regexp.QuoteMeta("insert into data.soft(name) values(?) returning id")).
+		WithArgs("second").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectCommit()
+
+	first := &SoftDeleteRepoTestEntity{Name: "first"}
+	second := &SoftDeleteRepoTestEntity{Name: "second"}
+
+	// when
+	err := repo.SaveAll(context.Background(), []domain.Entity{first, second})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "entities without unique key should not be batched")
+	assert.Equal(t, []uint64{11, 12}, []uint64{first.Id, second.Id}, "generated ids are not set")
+}
+
+func TestSaveAll_MissingReturnedRows_Error(t *testing.T) {
+	// given
+	repo, mock := newUniqueKeyRepository(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("insert into data.soft(name) values($1), ($2) returning id, name")).
+		WithArgs("first", "second").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(11, "first"))
+	mock.ExpectRollback()
+
+	// when
+	err := repo.SaveAll(context.Background(), []domain.Entity{&SoftDeleteRepoTestEntity{Name: "first"}, &SoftDeleteRepoTestEntity{Name: "second"}})
+
+	// then
+	assert.NotNil(t, err, "entities without generated values should fail the batch")
+	assert.Nil(t, mock.ExpectationsWereMet(), "batch should be rolled back")
+}
+
+func TestSaveAll_SameUniqueKey_Error(t *testing.T) {
+	// given
+	repo, mock := newUniqueKeyRepository(t)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	// when
+	err := repo.SaveAll(context.Background(), []domain.Entity{&SoftDeleteRepoTestEntity{Name: "same"}, &SoftDeleteRepoTestEntity{Name: "same"}})
+
+	// then
+	assert.Equal(t, commons.ErrValidation, commons.DetermineErrorType(err), "duplicate keys should fail validation")
+	assert.Nil(t, mock.ExpectationsWereMet(), "no statement should be run")
+}
+
+func TestSaveAll_UsesRepositoryDB(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, false)
+	otherDB, other, err := sqlmock.New()
+	assert.Nil(t, err, "could not create mock db")
+	db.WithDB(otherDB, "sqlmock")
+	t.Cleanup(func() {
+		otherDB.Close()
+	})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("insert into data.soft(name) values(?) returning id")).
+		WithArgs("first").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectCommit()
+
+	// when
+	err = repo.SaveAll(context.Background(), []domain.Entity{&SoftDeleteRepoTestEntity{Name: "first"}})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "batch should run in a transaction of the repository db")
+	assert.Nil(t, other.ExpectationsWereMet(), "application db should not be used")
+}
+
+func TestDeleteAll_HardDelete(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, false)
+	mock.ExpectExec(regexp.QuoteMeta("delete from data.soft where id = ANY($1)")).
+		WithArgs("{1,2}").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// when
+	err := repo.DeleteAll(context.Background(), []uint64{1, 2})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "delete all is not correct")
+}
+
+func TestDeleteAll_SoftDelete_RecordsActor(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, true)
+	mock.ExpectExec(regexp.QuoteMeta("update data.soft set deleted=true, deleted_time=now(), deleted_by=$2 where id = ANY($1) AND deleted = false")).
+		WithArgs("{1,2}", sql.NullString{String: "jdoe", Valid: true}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// when
+	err := repo.DeleteAll(commons.WithActor(context.Background(), "jdoe"), []uint64{1, 2})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "soft delete all is not correct")
+}
+
+func TestDeleteAll_NoIds(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, false)
+
+	// when
+	err := repo.DeleteAll(context.Background(), nil)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "no statement should be run")
+}
+
+func TestUpsert_Success(t *testing.T) {
+	// given
+	mockDB, mock, err := sqlmock.New()
+	assert.Nil(t, err, "could not create mock db")
+	db.WithDB(mockDB, "sqlmock")
+	defer mockDB.Close()
+
+	ed := metadata.WithUniqueKey(metadata.NewEntityDef("repository.SoftDeleteRepoTestEntity", "data", "soft", "id", "name asc", false), "name")
+	repo := NewRepository(ed, &SoftDeleteRepoTestEntity{})
+	mock.ExpectQuery(regexp.QuoteMeta("insert into data.soft(name) values(?) on conflict (name) do update set name=excluded.name returning id")).
+		WithArgs("existing").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	entity := &SoftDeleteRepoTestEntity{Name: "existing"}
+
+	// when
+	err = repo.Upsert(context.Background(), entity)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "upsert is not correct")
+	assert.Equal(t, uint64(4), entity.Id, "resulting id is not set")
+}
+
+func TestUpsert_NotSupported(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, false)
+
+	// when
+	err := repo.Upsert(context.Background(), &SoftDeleteRepoTestEntity{Name: "new"})
+
+	// then
+	assert.Equal(t, ErrUpsertNotSupported, err, "upsert should not be supported")
+	assert.Equal(t, commons.ErrConflict, commons.DetermineErrorType(err), "upsert error should be a conflict")
+	assert.Nil(t, mock.ExpectationsWereMet(), "no statement should be run")
+}
+
+func TestStream_HandlesEachRow(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, true)
+	mock.ExpectQuery(regexp.QuoteMeta("select id, name from data.soft where (name = $1) AND deleted = false order by name asc")).
+		WithArgs("same").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "same").AddRow(2, "same"))
+
+	// when
+	var ids []uint64
+	err := repo.Stream(context.Background(), query.Eq("Name", "same"), func(entity interface{}) error {
+		ids = append(ids, entity.(*SoftDeleteRepoTestEntity).Id)
+		return nil
+	})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "stream query is not correct")
+	assert.Equal(t, []uint64{1, 2}, ids, "not all rows are handled")
+}
+
+func TestStream_HandlerError_Stops(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, false)
+	mock.ExpectQuery("select id, name from data.soft order by name asc").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "first").AddRow(2, "second"))
+	stop := errors.New("stop")
+
+	// when
+	handled := 0
+	err := repo.Stream(context.Background(), nil, func(entity interface{}) error {
+		handled++
+		return stop
+	})
+
+	// then
+	assert.Equal(t, stop, err, "handler error should be returned")
+	assert.Equal(t, 1, handled, "reading should stop at the first error")
+}
+
+func TestStream_Cancelled_Stops(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, false)
+	mock.ExpectQuery("select id, name from data.soft order by name asc").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "first").AddRow(2, "second"))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// when
+	handled := 0
+	err := repo.Stream(ctx, nil, func(entity interface{}) error {
+		handled++
+		cancel()
+		return nil
+	})
+
+	// then
+	assert.Equal(t, context.Canceled, err, "cancellation should be returned")
+	assert.Equal(t, 1, handled, "reading should stop when context is done")
+}
+
+func TestDeleteVersion_NotSupported(t *testing.T) {
+	// given
+	repo, mock := newSoftDeleteRepository(t, false)
+
+	// when
+	err := repo.DeleteVersion(context.Background(), 5, 1)
+
+	// then
+	assert.Equal(t, ErrVersionNotSupported, err, "delete by version should not be supported")
+	assert.Equal(t, commons.ErrPrecondition, commons.DetermineErrorType(err), "version error should be a failed precondition")
+	assert.Nil(t, mock.ExpectationsWereMet(), "no statement should be run")
+}
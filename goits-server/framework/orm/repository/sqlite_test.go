@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cpekyaman/goits/framework/commons"
+	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+const sqliteTestTable = `create table embedded (
+    id                  integer primary key,
+    name                text not null unique,
+    version             integer not null default 1,
+    create_time         timestamp not null default current_timestamp,
+    last_modified_time  timestamp not null default current_timestamp,
+    deleted             boolean not null default false,
+    deleted_time        timestamp null,
+    deleted_by          text null
+)`
+
+type SQLiteTestEntity struct {
+	domain.VersionedTimeStampedEntity
+	Name string `db:"name"`
+}
+
+func newSQLiteRepository(t *testing.T) SqlRepository {
+	sqliteDB, err := sqlx.Open("sqlite3", ":memory:")
+	assert.Nil(t, err, "could not create sqlite db")
+	// every connection of an in memory db has its own database
+	sqliteDB.SetMaxOpenConns(1)
+	sqliteDB.MustExec(sqliteTestTable)
+
+	db.WithDB(sqliteDB.DB, "sqlite3")
+	dialect.Use(dialect.SQLite())
+	t.Cleanup(func() {
+		dialect.Use(dialect.Postgres())
+		sqliteDB.Close()
+	})
+
+	ed := metadata.WithUniqueKey(metadata.NewEntityDef("repository.SQLiteTestEntity", "data", "embedded", "id", "name asc", true), "name")
+	return NewRepository(ed, &SQLiteTestEntity{})
+}
+
+func TestSQLite_SaveFindAndDelete(t *testing.T) {
+	// given
+	repo := newSQLiteRepository(t)
+	ctx := commons.WithActor(context.Background(), "jdoe")
+	first := &SQLiteTestEntity{Name: "first"}
+	second := &SQLiteTestEntity{Name: "second"}
+
+	// when
+	saveErr := repo.SaveAll(ctx, []domain.Entity{first, second})
+	first.Name = "renamed"
+	updateErr := repo.Save(ctx, first)
+	deleteErr := repo.DeleteAll(ctx, []uint64{second.GetId()})
+
+	var found []SQLiteTestEntity
+	findErr := repo.FindAllByCriteriaPaged(ctx, &found, query.ILike("Name", "RE%"), 10, 0)
+	count, countErr := repo.CountByCriteria(ctx, nil, query.IncludeDeleted())
+
+	// then
+	assert.Nil(t, saveErr, "no error expected on save all")
+	assert.Nil(t, updateErr, "no error expected on update")
+	assert.Nil(t, deleteErr, "no error expected on delete all")
+	assert.Nil(t, findErr, "no error expected on find")
+	assert.Nil(t, countErr, "no error expected on count")
+
+	assert.Equal(t, []uint64{1, 2}, []uint64{first.GetId(), second.GetId()}, "ids are not generated")
+	assert.Equal(t, uint32(2), first.GetVersion(), "version is not increased")
+	assert.Len(t, found, 1, "deleted entity should not be found")
+	assert.Equal(t, "renamed", found[0].Name, "entity is not updated")
+	assert.False(t, found[0].CreateTime.IsZero(), "create time is not generated")
+	assert.Equal(t, uint64(2), count, "deleted entity should be kept")
+}
+
+func TestSQLite_FindOne_IncludeDeleted(t *testing.T) {
+	// given
+	repo := newSQLiteRepository(t)
+	ctx := context.Background()
+	assert.Nil(t, repo.Save(ctx, &SQLiteTestEntity{Name: "removed"}), "no error expected on save")
+	assert.Nil(t, repo.Delete(ctx, 1), "no error expected on delete")
+
+	// when
+	var byId, byName, hidden SQLiteTestEntity
+	byIdErr := repo.FindOneById(ctx, &byId, 1, query.IncludeDeleted())
+	byNameErr := repo.FindOneByAttribute(ctx, &byName, "Name", "removed", query.IncludeDeleted())
+	hiddenErr := repo.FindOneById(ctx, &hidden, 1)
+
+	// then
+	assert.Nil(t, byIdErr, "deleted entity should be found by id when included")
+	assert.Nil(t, byNameErr, "deleted entity should be found by attribute when included")
+	assert.Equal(t, "removed", byName.Name, "entity is not found")
+	assert.NotNil(t, hiddenErr, "deleted entity should not be found by default")
+}
+
+func TestSQLite_DeleteVersion(t *testing.T) {
+	// given
+	repo := newSQLiteRepository(t)
+	ctx := context.Background()
+	assert.Nil(t, repo.Save(ctx, &SQLiteTestEntity{Name: "versioned"}), "no error expected on save")
+
+	// when
+	staleErr := repo.DeleteVersion(ctx, 1, 2)
+	deleteErr := repo.DeleteVersion(ctx, 1, 1)
+	missingErr := repo.DeleteVersion(ctx, 1, 2)
+
+	// then
+	assert.Equal(t, OptimisticLockError{"repository.SQLiteTestEntity", 1, 2, 1}, staleErr, "stale version should not be deleted")
+	assert.Nil(t, deleteErr, "current version should be deleted")
+	assert.Equal(t, ErrNotFound, missingErr, "deleted entity should not be found")
+}
+
+func TestSQLite_Upsert(t *testing.T) {
+	// given
+	repo := newSQLiteRepository(t)
+	ctx := context.Background()
+	assert.Nil(t, repo.Save(ctx, &SQLiteTestEntity{Name: "existing"}), "no error expected on save")
+	assert.Nil(t, repo.DeleteAll(ctx, []uint64{1}), "no error expected on delete")
+	e := &SQLiteTestEntity{Name: "existing"}
+
+	// when
+	err := repo.Upsert(ctx, e)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, uint64(1), e.GetId(), "existing entity is not updated")
+	assert.Equal(t, uint32(3), e.GetVersion(), "version is not increased")
+}
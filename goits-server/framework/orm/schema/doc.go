@@ -0,0 +1,3 @@
+// Package schema verifies the entity metadata and mappings against the actual database schema.
+// It reads information_schema for the table of each entity and reports where the table and the mapping disagree.
+package schema
@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cpekyaman/goits/config"
+	"github.com/cpekyaman/goits/framework/monitoring"
+	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+)
+
+type verifyConfig struct {
+	OnStart bool `mapstructure:"onStart"`
+	Strict  bool `mapstructure:"strict"`
+}
+
+// VerifyOnStart verifies the registered entities against the database if it is enabled in config.
+// Mismatches are logged as warnings, or stop the application in strict mode.
+// Verification is skipped with a warning if the dialect does not support it.
+func VerifyOnStart() {
+	var conf verifyConfig
+	config.ReadInto("db.verify", &conf)
+	if !conf.OnStart {
+		return
+	}
+	if !Supported() {
+		monitoring.RootLogger().With(monitoring.StrLogField("dialect", dialect.Current().Name())).
+			Warn("db schema verification is not supported by dialect, skipping")
+		return
+	}
+
+	mismatches, err := NewVerifier(db.DB()).VerifyRegistered(context.Background())
+	if err != nil {
+		monitoring.RootLogger().With(monitoring.ErrLogField(err)).Fatal("could not verify db schema")
+	}
+
+	for _, m := range mismatches {
+		monitoring.RootLogger().With(monitoring.StrLogField("mismatch", m.String())).Warn("db schema does not match entity")
+	}
+	if conf.Strict && len(mismatches) > 0 {
+		monitoring.RootLogger().Fatal(fmt.Sprintf("db schema has %d mismatches", len(mismatches)))
+	}
+}
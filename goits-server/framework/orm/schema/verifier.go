@@ -0,0 +1,265 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	columnsQuery = "select column_name, data_type, is_nullable = 'YES' as nullable, column_default is not null as has_default " +
+		"from information_schema.columns where table_schema = $1 and table_name = $2"
+
+	pkQuery = "select kcu.column_name from information_schema.table_constraints tc " +
+		"join information_schema.key_column_usage kcu on kcu.constraint_name = tc.constraint_name and kcu.table_schema = tc.table_schema " +
+		"where tc.constraint_type = 'PRIMARY KEY' and tc.table_schema = $1 and tc.table_name = $2 order by kcu.ordinal_position"
+)
+
+// softDeleteColumns are the columns a table needs for its entity to use soft delete.
+var softDeleteColumns = []string{"deleted", "deleted_time", "deleted_by"}
+
+// Mismatch is a single disagreement between the mapping of an entity and its table.
+type Mismatch struct {
+	Entity string
+	Table  string
+	Column string
+	Reason string
+}
+
+func (this Mismatch) String() string {
+	if this.Column == "" {
+		return fmt.Sprintf("%s (%s): %s", this.Entity, this.Table, this.Reason)
+	}
+	return fmt.Sprintf("%s (%s.%s): %s", this.Entity, this.Table, this.Column, this.Reason)
+}
+
+// column is the definition of a table column read from information_schema.
+type column struct {
+	Name       string `db:"column_name"`
+	DataType   string `db:"data_type"`
+	Nullable   bool   `db:"nullable"`
+	HasDefault bool   `db:"has_default"`
+}
+
+// Verifier compares entity mappings with the tables in the database.
+type Verifier struct {
+	db *sqlx.DB
+}
+
+// NewVerifier creates a verifier that reads the schema of the given db.
+func NewVerifier(db *sqlx.DB) Verifier {
+	return Verifier{db}
+}
+
+// VerifyRegistered verifies all registered entities whose types are also registered.
+// An entity without a registered type is reported as a mismatch since its mapping is unknown.
+func (this Verifier) VerifyRegistered(ctx context.Context) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	for _, ed := range domain.EntityDefs() {
+		example := domain.NewEntityOf(ed.Name())
+		if example == nil {
+			mismatches = append(mismatches, Mismatch{Entity: ed.Name(), Table: ed.FullTableName(), Reason: "entity type is not registered"})
+			continue
+		}
+
+		cm, found := metadata.GetColumnMapper(ed)
+		if !found {
+			cm = metadata.NewColumnMapper(ed, example)
+		}
+
+		m, err := this.Verify(ctx, ed, cm)
+		if err != nil {
+			return mismatches, err
+		}
+		mismatches = append(mismatches, m...)
+	}
+	return mismatches, nil
+}
+
+// Supported tells if the schema of the current dialect can be verified, which requires information_schema.
+func Supported() bool {
+	return dialect.Current().Name() == dialect.Postgres().Name()
+}
+
+// Verify compares the mapping of a single entity with its table.
+// It checks that the table exists, every mapped column exists with a compatible type and nullability,
+// the primary key is the one in metadata, and unmapped columns do not require a value on insert.
+// Only postgres is supported, since the schema is read from information_schema.
+func (this Verifier) Verify(ctx context.Context, ed metadata.EntityDef, cm metadata.ColumnMapper) ([]Mismatch, error) {
+	if !Supported() {
+		return nil, fmt.Errorf("schema verification is not supported by %s", dialect.Current().Name())
+	}
+
+	var columns []column
+	if err := sqlx.SelectContext(ctx, this.db, &columns, columnsQuery, ed.Schema(), ed.Table()); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return []Mismatch{{Entity: ed.Name(), Table: ed.FullTableName(), Reason: "table does not exist"}}, nil
+	}
+
+	var pk []string
+	if err := sqlx.SelectContext(ctx, this.db, &pk, pkQuery, ed.Schema(), ed.Table()); err != nil {
+		return nil, err
+	}
+
+	v := entityVerification{ed: ed, cm: cm, columns: make(map[string]column, len(columns))}
+	for _, c := range columns {
+		v.columns[c.Name] = c
+	}
+
+	v.verifyMappedColumns()
+	v.verifyPrimaryKey(pk)
+	v.verifyMetadataColumns()
+	v.verifyUnmappedColumns(columns)
+	return v.mismatches, nil
+}
+
+// entityVerification collects the mismatches of a single entity.
+type entityVerification struct {
+	ed         metadata.EntityDef
+	cm         metadata.ColumnMapper
+	columns    map[string]column
+	mismatches []Mismatch
+}
+
+func (this *entityVerification) mismatch(col string, format string, args ...interface{}) {
+	this.mismatches = append(this.mismatches, Mismatch{this.ed.Name(), this.ed.FullTableName(), col, fmt.Sprintf(format, args...)})
+}
+
+func (this *entityVerification) verifyMappedColumns() {
+	for _, f := range this.cm.Fields() {
+		name := this.cm.Column(f)
+		c, found := this.columns[name]
+		if !found {
+			this.mismatch(name, "column of field %s does not exist", f)
+			continue
+		}
+
+		t := this.cm.FieldType(f)
+		if t == nil {
+			continue
+		}
+		if !compatible(t, c.DataType) {
+			this.mismatch(name, "column type %s does not match type %s of field %s", c.DataType, t, f)
+		}
+		if c.Nullable && !nullable(t) {
+			this.mismatch(name, "column is nullable but field %s of type %s can not be null", f, t)
+		}
+	}
+}
+
+func (this *entityVerification) verifyPrimaryKey(pk []string) {
+	if len(pk) == 0 {
+		this.mismatch("", "table has no primary key, metadata has %s", this.ed.PKColumn())
+	} else if len(pk) > 1 || pk[0] != this.ed.PKColumn() {
+		this.mismatch("", "primary key is (%s), metadata has %s", strings.Join(pk, ", "), this.ed.PKColumn())
+	}
+}
+
+func (this *entityVerification) verifyMetadataColumns() {
+	if this.ed.SoftDelete() {
+		for _, name := range softDeleteColumns {
+			if _, found := this.columns[name]; !found {
+				this.mismatch(name, "column required by soft delete does not exist")
+			}
+		}
+	}
+	for _, name := range this.ed.UniqueKey() {
+		if _, found := this.columns[name]; !found {
+			this.mismatch(name, "column of unique key does not exist")
+		}
+	}
+}
+
+func (this *entityVerification) verifyUnmappedColumns(columns []column) {
+	mapped := make(map[string]bool)
+	for _, name := range this.cm.Columns() {
+		mapped[name] = true
+	}
+	if this.ed.SoftDelete() {
+		for _, name := range softDeleteColumns {
+			mapped[name] = true
+		}
+	}
+
+	for _, c := range columns {
+		if !mapped[c.Name] && !c.Nullable && !c.HasDefault {
+			this.mismatch(c.Name, "column is not mapped but requires a value on insert")
+		}
+	}
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	nullStringType  = reflect.TypeOf(sql.NullString{})
+	nullInt32Type   = reflect.TypeOf(sql.NullInt32{})
+	nullInt64Type   = reflect.TypeOf(sql.NullInt64{})
+	nullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+	nullBoolType    = reflect.TypeOf(sql.NullBool{})
+	nullTimeType    = reflect.TypeOf(sql.NullTime{})
+)
+
+// dataTypes returns the sql data types the go type can be scanned from, which is nil if the type is not known.
+func dataTypes(t reflect.Type) []string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType, nullTimeType:
+		return []string{"timestamp with time zone", "timestamp without time zone", "date"}
+	case nullStringType:
+		return dataTypes(reflect.TypeOf(""))
+	case nullInt32Type, nullInt64Type:
+		return dataTypes(reflect.TypeOf(int64(0)))
+	case nullFloat64Type:
+		return dataTypes(reflect.TypeOf(float64(0)))
+	case nullBoolType:
+		return dataTypes(reflect.TypeOf(false))
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return []string{"character varying", "character", "text"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{"smallint", "integer", "bigint"}
+	case reflect.Float32, reflect.Float64:
+		return []string{"real", "double precision", "numeric"}
+	case reflect.Bool:
+		return []string{"boolean"}
+	}
+	return nil
+}
+
+// compatible checks if the column data type can be scanned into the go type, any data type is accepted for unknown types.
+func compatible(t reflect.Type, dataType string) bool {
+	types := dataTypes(t)
+	if types == nil {
+		return true
+	}
+	for _, dt := range types {
+		if dt == dataType {
+			return true
+		}
+	}
+	return false
+}
+
+// nullable checks if a null value can be scanned into the go type.
+func nullable(t reflect.Type) bool {
+	switch t {
+	case nullStringType, nullInt32Type, nullInt64Type, nullFloat64Type, nullBoolType, nullTimeType:
+		return true
+	}
+	return t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map || t.Kind() == reflect.Interface
+}
@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cpekyaman/goits/framework/orm/dialect"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type VerifierTestEntity struct {
+	domain.VersionedEntity
+	Name        string         `db:"name"`
+	Description sql.NullString `db:"description"`
+	Size        int            `db:"size"`
+}
+
+var verifierED = metadata.NewEntityDef("schema.VerifierTestEntity", "data", "verified", "id", "name asc", false)
+
+func newVerifier(t *testing.T) (Verifier, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	assert.Nil(t, err, "could not create mock db")
+	t.Cleanup(func() {
+		mockDB.Close()
+	})
+	return NewVerifier(sqlx.NewDb(mockDB, "sqlmock")), mock
+}
+
+func expectSchema(mock sqlmock.Sqlmock, columns *sqlmock.Rows, pk ...string) {
+	mock.ExpectQuery("select column_name, data_type.* from information_schema.columns").
+		WithArgs("data", "verified").
+		WillReturnRows(columns)
+
+	pkRows := sqlmock.NewRows([]string{"column_name"})
+	for _, c := range pk {
+		pkRows.AddRow(c)
+	}
+	mock.ExpectQuery("select kcu.column_name from information_schema.table_constraints").
+		WithArgs("data", "verified").
+		WillReturnRows(pkRows)
+}
+
+func schemaColumns() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"column_name", "data_type", "nullable", "has_default"})
+}
+
+func TestVerify_Matches(t *testing.T) {
+	// given
+	v, mock := newVerifier(t)
+	expectSchema(mock, schemaColumns().
+		AddRow("id", "bigint", false, true).
+		AddRow("name", "character varying", false, false).
+		AddRow("description", "text", true, false).
+		AddRow("size", "integer", false, false).
+		AddRow("version", "integer", false, true).
+		AddRow("notes", "text", true, false), "id")
+
+	// when
+	mismatches, err := v.Verify(context.Background(), verifierED, metadata.NewColumnMapper(verifierED, &VerifierTestEntity{}))
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "schema is not read")
+	assert.Empty(t, mismatches, "no mismatch expected")
+}
+
+func TestVerify_Mismatches(t *testing.T) {
+	// given
+	v, mock := newVerifier(t)
+	expectSchema(mock, schemaColumns().
+		AddRow("id", "bigint", false, true).
+		AddRow("name", "character varying", true, false).
+		AddRow("description", "text", true, false).
+		AddRow("size", "text", false, false).
+		AddRow("owner", "bigint", false, false), "name")
+
+	// when
+	mismatches, err := v.Verify(context.Background(), verifierED, metadata.NewColumnMapper(verifierED, &VerifierTestEntity{}))
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	columns := make([]string, len(mismatches))
+	for i, m := range mismatches {
+		columns[i] = m.Column
+	}
+	assert.Equal(t, []string{"name", "size", "version", "", "owner"}, columns, "mismatches are not correct")
+	assert.Equal(t, "schema.VerifierTestEntity (data.verified.version): column of field Version does not exist", mismatches[2].String(),
+		"mismatch is not described")
+}
+
+func TestVerify_NoTable(t *testing.T) {
+	// given
+	v, mock := newVerifier(t)
+	mock.ExpectQuery("select column_name, data_type.* from information_schema.columns").
+		WithArgs("data", "verified").
+		WillReturnRows(schemaColumns())
+
+	// when
+	mismatches, err := v.Verify(context.Background(), verifierED, metadata.NewColumnMapper(verifierED, &VerifierTestEntity{}))
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, []Mismatch{{Entity: "schema.VerifierTestEntity", Table: "data.verified", Reason: "table does not exist"}}, mismatches,
+		"missing table is not reported")
+}
+
+func TestSupported_SQLite_NotSupported(t *testing.T) {
+	// given
+	dialect.Use(dialect.SQLite())
+	t.Cleanup(func() {
+		dialect.Use(dialect.Postgres())
+	})
+
+	// when
+	supported := Supported()
+
+	// then
+	assert.False(t, supported, "sqlite has no information_schema to verify")
+}
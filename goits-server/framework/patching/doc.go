@@ -0,0 +1,4 @@
+// The package patching provides partial modification of entities via json patch documents.
+// Both JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902) formats are supported.
+// Patches are applied to the json representation of an entity and the result is bound back to it.
+package patching
@@ -0,0 +1,330 @@
+package patching
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	opAdd     = "add"
+	opRemove  = "remove"
+	opReplace = "replace"
+	opMove    = "move"
+	opCopy    = "copy"
+	opTest    = "test"
+)
+
+// JSONPatch is a JSON Patch as defined in RFC 6902, which is a list of operations applied in order.
+// The patch is applied as a whole, so a failing operation fails the entire patch.
+type JSONPatch []operation
+
+// operation is a single parsed operation of a JSON Patch.
+type operation struct {
+	op    string
+	path  string
+	from  string
+	value interface{}
+}
+
+// rawOperation is the json representation of an operation, where pointers tell whether a member is given.
+type rawOperation struct {
+	Op    *string         `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// NewJSONPatch parses and validates the operations of the patch document.
+func NewJSONPatch(data []byte) (JSONPatch, error) {
+	var raw []rawOperation
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("binding: invalid json patch: %s", err.Error())
+	}
+
+	patch := make(JSONPatch, len(raw))
+	for i, r := range raw {
+		op, err := parseOperation(r)
+		if err != nil {
+			return nil, fmt.Errorf("binding: invalid json patch operation %d: %s", i, err.Error())
+		}
+		patch[i] = op
+	}
+	return patch, nil
+}
+
+func parseOperation(r rawOperation) (operation, error) {
+	var op operation
+	if r.Op == nil {
+		return op, errors.New("op is missing")
+	}
+	if r.Path == nil {
+		return op, errors.New("path is missing")
+	}
+	op.op, op.path = *r.Op, *r.Path
+	if _, err := parsePointer(op.path); err != nil {
+		return op, err
+	}
+
+	switch op.op {
+	case opAdd, opReplace, opTest:
+		// an explicit null value is given as a raw null, an absent value is nil
+		if r.Value == nil {
+			return op, errors.New("value is missing")
+		}
+		v, err := decodeJSON(r.Value)
+		if err != nil {
+			return op, err
+		}
+		op.value = v
+	case opMove, opCopy:
+		if r.From == nil {
+			return op, errors.New("from is missing")
+		}
+		op.from = *r.From
+		if _, err := parsePointer(op.from); err != nil {
+			return op, err
+		}
+		if op.op == opMove && strings.HasPrefix(op.path+"/", op.from+"/") && op.path != op.from {
+			return op, errors.New("a value can not be moved into itself")
+		}
+	case opRemove:
+	default:
+		return op, fmt.Errorf("unknown op %s", op.op)
+	}
+	return op, nil
+}
+
+func (this JSONPatch) Apply(doc []byte) ([]byte, error) {
+	root, err := decodeJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range this {
+		if root, err = op.apply(root); err != nil {
+			return nil, PatchError{op.op, op.path, err.Error()}
+		}
+	}
+	return json.Marshal(root)
+}
+
+func (this operation) apply(root interface{}) (interface{}, error) {
+	// pointers are validated while parsing
+	path, _ := parsePointer(this.path)
+
+	switch this.op {
+	case opAdd:
+		return addValue(root, path, this.value)
+	case opRemove:
+		root, _, err := removeValue(root, path)
+		return root, err
+	case opReplace:
+		return replaceValue(root, path, this.value)
+	case opMove:
+		from, _ := parsePointer(this.from)
+		root, v, err := removeValue(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(root, path, v)
+	case opCopy:
+		from, _ := parsePointer(this.from)
+		v, err := getValue(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(root, path, copyValue(v))
+	case opTest:
+		v, err := getValue(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalValues(v, this.value) {
+			return nil, errors.New("value does not match")
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown op %s", this.op)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("pointer %s does not start with /", p)
+	}
+
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		if strings.Contains(strings.NewReplacer("~0", "", "~1", "").Replace(t), "~") {
+			return nil, fmt.Errorf("pointer %s has invalid escape", p)
+		}
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func getValue(node interface{}, path []string) (interface{}, error) {
+	for _, key := range path {
+		var err error
+		if node, err = child(node, key); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func addValue(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return modify(root, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			if key == "-" {
+				return append(p, value), nil
+			}
+			i, err := arrayIndex(key, len(p)+1)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		}
+		return nil, fmt.Errorf("can not add %s to a value that is not a container", key)
+	})
+}
+
+func removeValue(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("document root can not be removed")
+	}
+
+	var removed interface{}
+	root, err := modify(root, path, func(parent interface{}, key string) (interface{}, error) {
+		v, err := child(parent, key)
+		if err != nil {
+			return nil, err
+		}
+		removed = v
+
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			delete(p, key)
+			return p, nil
+		case []interface{}:
+			// child has already validated the index
+			i, _ := strconv.Atoi(key)
+			return append(p[:i], p[i+1:]...), nil
+		}
+		return parent, nil
+	})
+	return root, removed, err
+}
+
+func replaceValue(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return modify(root, path, func(parent interface{}, key string) (interface{}, error) {
+		if _, err := child(parent, key); err != nil {
+			return nil, err
+		}
+
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			i, _ := strconv.Atoi(key)
+			p[i] = value
+			return p, nil
+		}
+		return parent, nil
+	})
+}
+
+// modify walks down to the parent of the location the path points to, and replaces the parent with the result of fn.
+// Containers on the path are replaced bottom up, since modifying an array may create a new one.
+func modify(node interface{}, path []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+
+	c, err := child(node, path[0])
+	if err != nil {
+		return nil, err
+	}
+	c, err = modify(c, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		n[path[0]] = c
+	case []interface{}:
+		i, _ := strconv.Atoi(path[0])
+		n[i] = c
+	}
+	return node, nil
+}
+
+func child(node interface{}, key string) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		v, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("member %s does not exist", key)
+		}
+		return v, nil
+	case []interface{}:
+		i, err := arrayIndex(key, len(n))
+		if err != nil {
+			return nil, err
+		}
+		return n[i], nil
+	}
+	return nil, fmt.Errorf("member %s does not exist", key)
+}
+
+// arrayIndex parses the array index in the token, which must be less than the given limit.
+func arrayIndex(key string, limit int) (int, error) {
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || (len(key) > 1 && key[0] == '0') {
+		return 0, fmt.Errorf("%s is not a valid array index", key)
+	}
+	if i >= limit {
+		return 0, fmt.Errorf("array index %s is out of bounds", key)
+	}
+	return i, nil
+}
+
+func copyValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = copyValue(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, e := range t {
+			a[i] = copyValue(e)
+		}
+		return a
+	default:
+		return v
+	}
+}
@@ -0,0 +1,49 @@
+package patching
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatch is a JSON Merge Patch as defined in RFC 7396.
+// Members of the patch replace the ones in the document, where null values remove them.
+type MergePatch struct {
+	patch interface{}
+}
+
+// NewMergePatch parses the merge patch document.
+func NewMergePatch(data []byte) (MergePatch, error) {
+	p, err := decodeJSON(data)
+	if err != nil {
+		return MergePatch{}, fmt.Errorf("binding: invalid merge patch: %s", err.Error())
+	}
+	return MergePatch{p}, nil
+}
+
+func (this MergePatch) Apply(doc []byte) ([]byte, error) {
+	target, err := decodeJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(target, this.patch))
+}
+
+func mergeValue(target interface{}, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergeValue(t[k], v)
+		}
+	}
+	return t
+}
@@ -0,0 +1,210 @@
+package patching
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+const (
+	ContentTypeMergePatch = "application/merge-patch+json"
+	ContentTypeJSONPatch  = "application/json-patch+json"
+)
+
+var ErrUnsupportedPatch = errors.New("binding: unsupported patch content type")
+
+// Patch is a set of changes to be applied on a json document.
+type Patch interface {
+	// Apply applies the changes on the given document and returns the patched document.
+	Apply(doc []byte) ([]byte, error)
+}
+
+// PatchError is returned when a patch can not be applied to the current state of the document.
+type PatchError struct {
+	Op     string
+	Path   string
+	Reason string
+}
+
+func (this PatchError) Error() string {
+	return fmt.Sprintf("conflict: could not apply patch operation %s on %s: %s", this.Op, this.Path, this.Reason)
+}
+
+func (this PatchError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"op":     this.Op,
+		"path":   this.Path,
+		"reason": this.Reason,
+	}
+}
+
+// Parse creates the patch in the format given by the content type from the patch document.
+func Parse(contentType string, data []byte) (Patch, error) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedPatch
+	}
+
+	switch mt {
+	case ContentTypeMergePatch:
+		return NewMergePatch(data)
+	case ContentTypeJSONPatch:
+		return NewJSONPatch(data)
+	default:
+		return nil, ErrUnsupportedPatch
+	}
+}
+
+// ApplyTo applies the patch to the json representation of target, and binds the changed members back to it.
+// Members removed by the patch are reset to their zero values, while fields hidden from json stay as they are.
+func ApplyTo(p Patch, target interface{}) error {
+	doc, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+
+	patched, err := p.Apply(doc)
+	if err != nil {
+		return err
+	}
+
+	var before, after map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &before); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return PatchError{"apply", "", "patched document is not an object"}
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(target))
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			if f, found := fieldByJSONName(v, name); found {
+				f.Set(reflect.Zero(f.Type()))
+			}
+		}
+	}
+
+	changes := make(map[string]json.RawMessage)
+	for name, value := range after {
+		if old, ok := before[name]; !ok || !equalJSON(old, value) {
+			changes[name] = value
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("binding: %s", err.Error())
+	}
+	return nil
+}
+
+// fieldByJSONName finds the struct field that is serialized with the given name, looking into embedded structs too.
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		jsonName := strings.Split(tag, ",")[0]
+
+		if sf.Anonymous && jsonName == "" && sf.Type.Kind() == reflect.Struct {
+			if f, found := fieldByJSONName(v.Field(i), name); found {
+				return f, true
+			}
+			continue
+		}
+		if sf.PkgPath != "" || jsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = sf.Name
+		}
+		if jsonName == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// decodeJSON decodes the json document keeping the numbers as they are given.
+func decodeJSON(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, errors.New("unexpected data after json value")
+	}
+	return v, nil
+}
+
+func equalJSON(a, b json.RawMessage) bool {
+	va, err := decodeJSON(a)
+	if err != nil {
+		return false
+	}
+	vb, err := decodeJSON(b)
+	if err != nil {
+		return false
+	}
+	return equalValues(va, vb)
+}
+
+// equalValues compares decoded json values, treating numbers with the same value as equal.
+func equalValues(a, b interface{}) bool {
+	switch va := a.(type) {
+	case json.Number:
+		vb, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if va == vb {
+			return true
+		}
+		fa, erra := va.Float64()
+		fb, errb := vb.Float64()
+		return erra == nil && errb == nil && fa == fb
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for k, v := range va {
+			w, ok := vb[k]
+			if !ok || !equalValues(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !equalValues(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
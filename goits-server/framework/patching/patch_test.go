@@ -0,0 +1,208 @@
+package patching
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type PatchTestEmbedded struct {
+	Id uint64 `json:"id"`
+}
+
+type PatchTestEntity struct {
+	PatchTestEmbedded
+	Name   string   `json:"name"`
+	Tags   []string `json:"tags"`
+	Count  int      `json:"count"`
+	Secret string   `json:"-"`
+}
+
+func TestMergePatch_Apply(t *testing.T) {
+	cases := []struct {
+		doc    string
+		patch  string
+		result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+	}
+
+	for _, c := range cases {
+		// given
+		p, err := NewMergePatch([]byte(c.patch))
+		assert.Nil(t, err, "could not parse patch %s", c.patch)
+
+		// when
+		result, err := p.Apply([]byte(c.doc))
+
+		// then
+		assert.Nil(t, err, "no error expected")
+		assert.JSONEq(t, c.result, string(result), "result is not correct for %s", c.patch)
+	}
+}
+
+func TestJSONPatch_Apply(t *testing.T) {
+	cases := []struct {
+		doc    string
+		patch  string
+		result string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"baz"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			`{"foo":{"bar":1},"baz":{"bar":2}}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"replace","path":"/~01","value":11}]`, `{"/":9,"~1":11}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/foo","value":null}]`, `{"foo":null}`},
+	}
+
+	for _, c := range cases {
+		// given
+		p, err := NewJSONPatch([]byte(c.patch))
+		assert.Nil(t, err, "could not parse patch %s", c.patch)
+
+		// when
+		result, err := p.Apply([]byte(c.doc))
+
+		// then
+		assert.Nil(t, err, "no error expected for %s", c.patch)
+		assert.JSONEq(t, c.result, string(result), "result is not correct for %s", c.patch)
+	}
+}
+
+func TestJSONPatch_Apply_Error(t *testing.T) {
+	cases := []struct {
+		patch string
+		path  string
+	}{
+		{`[{"op":"test","path":"/baz","value":"bar"}]`, "/baz"},
+		{`[{"op":"remove","path":"/missing"}]`, "/missing"},
+		{`[{"op":"replace","path":"/foo/5","value":1}]`, "/foo/5"},
+		{`[{"op":"add","path":"/foo/01","value":1}]`, "/foo/01"},
+		{`[{"op":"add","path":"/missing/a","value":1}]`, "/missing/a"},
+		{`[{"op":"replace","path":"/baz","value":1},{"op":"remove","path":"/missing"}]`, "/missing"},
+	}
+
+	for _, c := range cases {
+		// given
+		p, err := NewJSONPatch([]byte(c.patch))
+		assert.Nil(t, err, "could not parse patch %s", c.patch)
+
+		// when
+		_, err = p.Apply([]byte(`{"baz":"qux","foo":["a"]}`))
+
+		// then
+		var pe PatchError
+		assert.True(t, errors.As(err, &pe), "patch error expected for %s", c.patch)
+		assert.Equal(t, c.path, pe.Path, "path of error is not correct")
+	}
+}
+
+func TestNewJSONPatch_Invalid_Error(t *testing.T) {
+	patches := []string{
+		`{"op":"add"}`,
+		`[{"path":"/a","value":1}]`,
+		`[{"op":"add","value":1}]`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"copy","path":"/a"}]`,
+		`[{"op":"move","from":"/a","path":"/a/b"}]`,
+		`[{"op":"unknown","path":"/a"}]`,
+		`[{"op":"remove","path":"a"}]`,
+		`[{"op":"remove","path":"/a~2"}]`,
+	}
+
+	for _, patch := range patches {
+		// when
+		_, err := NewJSONPatch([]byte(patch))
+
+		// then
+		assert.NotNil(t, err, "error expected for %s", patch)
+		assert.Contains(t, err.Error(), "binding:", "should be a binding error")
+	}
+}
+
+func TestParse(t *testing.T) {
+	// when
+	mp, err := Parse("application/merge-patch+json; charset=utf-8", []byte(`{}`))
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.IsType(t, MergePatch{}, mp, "merge patch expected")
+
+	// when
+	jp, err := Parse(ContentTypeJSONPatch, []byte(`[]`))
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.IsType(t, JSONPatch{}, jp, "json patch expected")
+
+	// when
+	_, err = Parse("application/json", []byte(`{}`))
+
+	// then
+	assert.Equal(t, ErrUnsupportedPatch, err, "json is not a patch format")
+}
+
+func TestApplyTo(t *testing.T) {
+	// given
+	target := &PatchTestEntity{PatchTestEmbedded{7}, "name", []string{"a"}, 3, "secret"}
+	p, err := NewMergePatch([]byte(`{"name":"patched","tags":null}`))
+	assert.Nil(t, err, "could not parse patch")
+
+	// when
+	err = ApplyTo(p, target)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, uint64(7), target.Id, "id should not change")
+	assert.Equal(t, "patched", target.Name, "name is not patched")
+	assert.Nil(t, target.Tags, "removed member should be reset")
+	assert.Equal(t, 3, target.Count, "count should not change")
+	assert.Equal(t, "secret", target.Secret, "field hidden from json should not change")
+}
+
+func TestApplyTo_RemoveEmbedded(t *testing.T) {
+	// given
+	target := &PatchTestEntity{PatchTestEmbedded{7}, "name", nil, 3, ""}
+	p, err := NewJSONPatch([]byte(`[{"op":"remove","path":"/id"},{"op":"replace","path":"/count","value":4}]`))
+	assert.Nil(t, err, "could not parse patch")
+
+	// when
+	err = ApplyTo(p, target)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Equal(t, uint64(0), target.Id, "removed embedded member should be reset")
+	assert.Equal(t, 4, target.Count, "count is not patched")
+}
+
+func TestApplyTo_TypeMismatch_Error(t *testing.T) {
+	// given
+	target := &PatchTestEntity{Name: "name"}
+	p, err := NewMergePatch([]byte(`{"count":"many"}`))
+	assert.Nil(t, err, "could not parse patch")
+
+	// when
+	err = ApplyTo(p, target)
+
+	// then
+	assert.NotNil(t, err, "error expected")
+	assert.Contains(t, err.Error(), "binding:", "should be a binding error")
+}
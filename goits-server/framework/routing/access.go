@@ -0,0 +1,73 @@
+package routing
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cpekyaman/goits/framework/security"
+)
+
+var errAuthenticationRequired = errors.New("unauthorized: authentication is required")
+
+// accessRules decide which operations of a resource or an endpoint can be called anonymously.
+// Operations are public unless they are declared as authenticated, individually or by default.
+type accessRules struct {
+	authenticated bool
+	operations    map[string]bool
+}
+
+// with returns a copy of the rules where the operations, or the default if no operation is given, require authentication or not.
+func (this accessRules) with(authenticated bool, operations []string) accessRules {
+	if len(operations) == 0 {
+		return accessRules{authenticated: authenticated, operations: this.operations}
+	}
+
+	ops := make(map[string]bool, len(this.operations)+len(operations))
+	for k, v := range this.operations {
+		ops[k] = v
+	}
+	for _, op := range operations {
+		ops[op] = authenticated
+	}
+	return accessRules{authenticated: this.authenticated, operations: ops}
+}
+
+// requiresAuthentication checks if the operation can only be called by an authenticated principal.
+func (this accessRules) requiresAuthentication(operation string) bool {
+	if authenticated, ok := this.operations[operation]; ok {
+		return authenticated
+	}
+	return this.authenticated
+}
+
+// guard wraps the handler of an operation, rejecting the requests without a principal if the operation requires one.
+// Principals can only read with safe methods if they lack the write scope.
+func (this accessRules) guard(res ApiResource, operation string, h http.HandlerFunc) http.HandlerFunc {
+	if !this.requiresAuthentication(operation) {
+		return h
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := security.PrincipalFromContext(r.Context())
+		if !ok {
+			res.errorResponse(w, r, "could not "+operation, errAuthenticationRequired)
+			return
+		}
+
+		scope := security.ScopeWrite
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			scope = security.ScopeRead
+		}
+		if !p.HasScope(scope) {
+			res.errorResponse(w, r, "could not "+operation, errors.New("forbidden: credential does not have "+scope+" scope"))
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+// guarded wraps the handler of an operation of the resource with the access rules of the resource.
+func (this ApiResource) guarded(operation string, h http.HandlerFunc) http.HandlerFunc {
+	return this.access.guard(this, operation, h)
+}
@@ -0,0 +1,72 @@
+package routing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"time"
+
+	"github.com/cpekyaman/goits/framework/orm/query"
+)
+
+var cursorKey []byte
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+func init() {
+	// cursor values are sent as interfaces, types other than the basic ones must be registered
+	gob.Register(time.Time{})
+
+	// a random key makes cursors valid only until restart, unless a key is configured
+	cursorKey = make([]byte, sha256.Size)
+	rand.Read(cursorKey)
+}
+
+// SetCursorKey sets the secret that is used to sign the pagination cursors given to clients.
+// All instances serving the same clients should use the same key.
+func SetCursorKey(key string) {
+	if key != "" {
+		cursorKey = []byte(key)
+	}
+}
+
+// encodeCursor serializes and signs the cursor as an opaque url safe string.
+func encodeCursor(c query.Cursor) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(c); err != nil {
+		return "", err
+	}
+
+	data := buf.Bytes()
+	return base64.RawURLEncoding.EncodeToString(append(data, signCursor(data)...)), nil
+}
+
+// decodeCursor verifies and deserializes a cursor created by encodeCursor.
+func decodeCursor(s string) (query.Cursor, error) {
+	var c query.Cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(raw) <= sha256.Size {
+		return c, errInvalidCursor
+	}
+
+	data, sig := raw[:len(raw)-sha256.Size], raw[len(raw)-sha256.Size:]
+	if !hmac.Equal(sig, signCursor(data)) {
+		return c, errInvalidCursor
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&c); err != nil {
+		return c, errInvalidCursor
+	}
+	return c, nil
+}
+
+func signCursor(data []byte) []byte {
+	mac := hmac.New(sha256.New, cursorKey)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
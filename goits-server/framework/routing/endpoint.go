@@ -0,0 +1,92 @@
+package routing
+
+import (
+	"net/http"
+
+	"github.com/cpekyaman/goits/framework/services"
+)
+
+// EndpointFunc performs an operation of an ApiEndpoint, binding the request body with bind if it needs one.
+// It can set headers and cookies of the response, and its result is rendered like a single resource.
+type EndpointFunc func(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error)
+
+// ApiEndpoint represents a group of rest operations that are not CRUD operations of an entity, such as /auth/login.
+// The operations render their results and errors in the same way as ApiResource does.
+type ApiEndpoint struct {
+	name       string
+	path       string
+	binder     RequestBinder
+	render     ResponseRenderer
+	operations []endpointOperation
+	access     accessRules
+}
+
+// endpointOperation is a single operation of an endpoint.
+type endpointOperation struct {
+	method string
+	path   string
+	name   string
+	fn     EndpointFunc
+}
+
+// NewApiEndpoint creates a new api endpoint by using engine provided defaults for binder and renderer.
+func NewApiEndpoint(name string, path string) ApiEndpoint {
+	return ApiEndpoint{name: name, path: path, binder: binder, render: renderer}
+}
+
+// NewCustomApiEndpoint creates a new api endpoint by using provided binder and renderer.
+func NewCustomApiEndpoint(name string, path string, b RequestBinder, r ResponseRenderer) ApiEndpoint {
+	return ApiEndpoint{name: name, path: path, binder: b, render: r}
+}
+
+// With adds an operation to the endpoint, where path is relative to the endpoint and name is used in monitoring.
+func (this ApiEndpoint) With(method string, path string, name string, fn EndpointFunc) ApiEndpoint {
+	operations := make([]endpointOperation, len(this.operations), len(this.operations)+1)
+	copy(operations, this.operations)
+	this.operations = append(operations, endpointOperation{method, path, name, fn})
+	return this
+}
+
+// WithAuthenticated makes the operations callable only by authenticated principals.
+// Without any operation it makes all operations that are not declared as public require authentication.
+func (this ApiEndpoint) WithAuthenticated(operations ...string) ApiEndpoint {
+	this.access = this.access.with(true, operations)
+	return this
+}
+
+// WithPublic makes the operations callable anonymously.
+// Without any operation it makes all operations that are not declared as authenticated public, which is the default.
+func (this ApiEndpoint) WithPublic(operations ...string) ApiEndpoint {
+	this.access = this.access.with(false, operations)
+	return this
+}
+
+// PathParam reads a path param of an operation, such as the id in /auth/tokens/{id}.
+func (this ApiEndpoint) PathParam(r *http.Request, key string) string {
+	return this.binder.PathParam(r, key)
+}
+
+// IdPathParam reads a path param of an operation as an id.
+func (this ApiEndpoint) IdPathParam(r *http.Request, key string) (uint64, error) {
+	return this.binder.IdPathParam(r, key)
+}
+
+// Register registers the api endpoint with routing engine making it available to be used via rest.
+func (this ApiEndpoint) Register() {
+	engine.RegisterEndpoint(this)
+}
+
+// handler creates the handler of the operation, which renders the result or the error of it.
+func (this ApiEndpoint) handler(op endpointOperation) http.HandlerFunc {
+	// rendering is shared with resources
+	res := ApiResource{name: this.name, path: this.path, binder: this.binder, render: this.render}
+
+	return this.access.guard(res, op.name, func(w http.ResponseWriter, r *http.Request) {
+		payload, err := op.fn(w, r, this.binder.BindFunc(r))
+		if err != nil {
+			res.errorResponse(w, r, "could not "+op.name, err)
+			return
+		}
+		res.successResponse(w, r, payload)
+	})
+}
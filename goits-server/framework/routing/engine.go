@@ -2,10 +2,12 @@ package routing
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/cpekyaman/goits/framework/patching"
 	"github.com/cpekyaman/goits/framework/services"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
@@ -22,8 +24,12 @@ func (this engineRequestBinder) PathParam(r *http.Request, key string) string {
 	return chi.URLParam(r, key)
 }
 
+func (this engineRequestBinder) Header(r *http.Request, key string) string {
+	return r.Header.Get(key)
+}
+
 func (this engineRequestBinder) IdPathParam(r *http.Request, key string) (uint64, error) {
-	idstr := this.PathParam(r, "id")
+	idstr := this.PathParam(r, key)
 	id, err := strconv.ParseUint(idstr, 10, 64)
 	if err != nil {
 		return 0, fmt.Errorf("binding: %s", err.Error())
@@ -44,6 +50,17 @@ func (this engineRequestBinder) BindFunc(r *http.Request) services.ObjectBinder
 	})
 }
 
+func (this engineRequestBinder) BindPatch(r *http.Request) (patching.Patch, error) {
+	if r == nil || r.Body == nil {
+		return nil, fmt.Errorf("binding: empty request")
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("binding: %s", err.Error())
+	}
+	return patching.Parse(r.Header.Get("Content-Type"), data)
+}
+
 type engineResponseRenderer struct{}
 
 func (this engineResponseRenderer) JSON(w http.ResponseWriter, r *http.Request, data interface{}) {
@@ -60,6 +77,8 @@ func InitRouting() {
 	r.Use(middleware.RealIP)
 	r.Use(Logger)
 	r.Use(middleware.Recoverer)
+	// after logging and recovery, so that rejected requests are logged and panics of authenticators are recovered
+	r.Use(Authenticate)
 	r.Use(render.SetContentType(render.ContentTypeJSON))
 
 	// Set a timeout value on the request context (ctx), that will signal
@@ -87,19 +106,44 @@ func (this RoutingEngine) Register(resource ApiResource) {
 	Register(this.router, resource)
 }
 
+func (this RoutingEngine) RegisterEndpoint(endpoint ApiEndpoint) {
+	RegisterEndpoint(this.router, endpoint)
+}
+
 func (this RoutingEngine) RegisterPath(path string, h http.Handler) {
 	this.router.Handle(path, h)
 }
 
 func Register(r *chi.Mux, resource ApiResource) {
 	r.Route("/"+resource.path, func(r chi.Router) {
-		r.Get("/", MonitoredHandler(resource.name, "getAll", resource.GetAll))
-		r.Post("/", MonitoredHandler(resource.name, "create", resource.Create))
+		r.Get("/", MonitoredHandler(resource.name, "getAll", resource.guarded("getAll", resource.GetAll)))
+		r.Post("/", MonitoredHandler(resource.name, "create", resource.guarded("create", resource.Create)))
 
 		r.Route("/{id}", func(r chi.Router) {
-			r.Get("/", MonitoredHandler(resource.name, "getById", resource.GetById))
-			r.Put("/", MonitoredHandler(resource.name, "update", resource.Update))
-			r.Delete("/", MonitoredHandler(resource.name, "delete", resource.Delete))
+			r.Get("/", MonitoredHandler(resource.name, "getById", resource.guarded("getById", resource.GetById)))
+			r.Put("/", MonitoredHandler(resource.name, "update", resource.guarded("update", resource.Update)))
+			r.Patch("/", MonitoredHandler(resource.name, "patch", resource.guarded("patch", resource.Patch)))
+			r.Delete("/", MonitoredHandler(resource.name, "delete", resource.guarded("delete", resource.Delete)))
+			r.Post("/restore", MonitoredHandler(resource.name, "restore", resource.guarded("restore", resource.Restore)))
+
+			for _, a := range resource.actions {
+				r.Method(a.method, a.path, MonitoredHandler(resource.name, a.name, resource.guarded(a.name, resource.action(a))))
+			}
+		})
+	})
+
+	if resource.parent != nil {
+		r.Route(fmt.Sprintf("/%s/{parentId}/%s", resource.parent.path, resource.path), func(r chi.Router) {
+			r.Get("/", MonitoredHandler(resource.name, "getAllOfParent", resource.guarded("getAllOfParent", resource.GetAllOfParent)))
+			r.Post("/", MonitoredHandler(resource.name, "createInParent", resource.guarded("createInParent", resource.CreateInParent)))
 		})
+	}
+}
+
+func RegisterEndpoint(r *chi.Mux, endpoint ApiEndpoint) {
+	r.Route("/"+endpoint.path, func(r chi.Router) {
+		for _, op := range endpoint.operations {
+			r.Method(op.method, op.path, MonitoredHandler(endpoint.name, op.name, endpoint.handler(op)))
+		}
 	})
 }
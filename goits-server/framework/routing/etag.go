@@ -0,0 +1,142 @@
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/patching"
+	"github.com/cpekyaman/goits/framework/services"
+)
+
+var errPreconditionFailed = errors.New("precondition: resource does not match the given entity tag")
+var errPreconditionRequired = errors.New("precondition: request must be made conditional with If-Match header")
+
+// entityTag creates the entity tag of the given entity from its id and version.
+// Only versioned entities have entity tags, since the version is what changes with every update.
+func entityTag(v interface{}) (string, bool) {
+	e, ok := v.(domain.Entity)
+	if !ok {
+		return "", false
+	}
+	ve, ok := v.(domain.Versioned)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(`"%d-%d"`, e.GetId(), ve.GetVersion()), true
+}
+
+// matchesETag checks if the entity tag is in the list of tags given in an If-Match or If-None-Match header.
+// Weak comparison ignores the weakness indicator of the listed tags, strong comparison never matches weak tags.
+func matchesETag(header string, etag string, weak bool) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" {
+			return true
+		}
+		if strings.HasPrefix(t, "W/") {
+			if !weak {
+				continue
+			}
+			t = t[2:]
+		}
+		if t == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchFailed turns the optimistic lock error of a conditional request into a failed precondition.
+// The entity matched the If-Match header when it was loaded, but it is modified by someone else before it is saved.
+func ifMatchFailed(ifMatch string, err error) error {
+	if ifMatch != "" && errors.Is(err, repository.ErrOptimisticLock) {
+		return errPreconditionFailed
+	}
+	return err
+}
+
+// ifMatchVersions returns the versions of the entity with the given id whose tags are listed in an If-Match header.
+// Only strong tags are considered, since If-Match uses strong comparison.
+func ifMatchVersions(header string, id uint64) []uint32 {
+	prefix := fmt.Sprintf(`"%d-`, id)
+	var versions []uint32
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if !strings.HasPrefix(t, prefix) || !strings.HasSuffix(t, `"`) || len(t) <= len(prefix) {
+			continue
+		}
+		if v, err := strconv.ParseUint(t[len(prefix):len(t)-1], 10, 32); err == nil {
+			versions = append(versions, uint32(v))
+		}
+	}
+	return versions
+}
+
+// checkIfMatch evaluates the If-Match header against the current state of the resource.
+// Any existing resource matches "*", otherwise only versioned entities can match.
+func checkIfMatch(ifMatch string, current interface{}) error {
+	if strings.TrimSpace(ifMatch) == "*" {
+		return nil
+	}
+	if etag, ok := entityTag(current); ok && matchesETag(ifMatch, etag, false) {
+		return nil
+	}
+	return errPreconditionFailed
+}
+
+// ifMatchBinder checks the If-Match header against the target before binding the request to it.
+// Services bind requests to the entity they have loaded, so the check is done against its current state.
+func ifMatchBinder(ifMatch string, ob services.ObjectBinder) services.ObjectBinder {
+	return services.ObjectBinderFunc(func(target interface{}) error {
+		if err := checkIfMatch(ifMatch, target); err != nil {
+			return err
+		}
+		return ob.BindTo(target)
+	})
+}
+
+// ifMatchPatch checks the If-Match header against the document before applying the wrapped patch to it.
+// Services apply patches to the entity they have loaded, so the check is done against its current state.
+type ifMatchPatch struct {
+	patching.Patch
+	ifMatch string
+}
+
+func (this ifMatchPatch) Apply(doc []byte) ([]byte, error) {
+	if strings.TrimSpace(this.ifMatch) != "*" {
+		// only the documents of versioned entities have a version to build the entity tag from
+		var current struct {
+			Id      uint64  `json:"id"`
+			Version *uint32 `json:"version"`
+		}
+		if err := json.Unmarshal(doc, &current); err != nil || current.Version == nil {
+			return nil, errPreconditionFailed
+		}
+		if !matchesETag(this.ifMatch, fmt.Sprintf(`"%d-%d"`, current.Id, *current.Version), false) {
+			return nil, errPreconditionFailed
+		}
+	}
+	return this.Patch.Apply(doc)
+}
+
+// ifMatch returns the If-Match header of the request, failing if it is missing while the resource requires it.
+func (this ApiResource) ifMatch(r *http.Request) (string, error) {
+	h := this.binder.Header(r, HDR_IfMatch)
+	if h == "" && this.requireIfMatch {
+		return "", errPreconditionRequired
+	}
+	return h, nil
+}
+
+// setETag adds the entity tag of the result to the response if it has one.
+func setETag(w http.ResponseWriter, result interface{}) {
+	if etag, ok := entityTag(result); ok {
+		w.Header().Set(HDR_ETag, etag)
+	}
+}
@@ -1,16 +1,19 @@
 package routing
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
 	"strconv"
+	"strings"
 
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/cpekyaman/goits/framework/orm/repository"
 	"github.com/cpekyaman/goits/framework/services"
 )
 
-const (
-	rowsPerPage = 20
-)
-
 type ApiHandler interface {
 	Handle(w http.ResponseWriter, r *http.Request)
 }
@@ -22,30 +25,97 @@ func (hf ApiHandlerFunc) Handle(w http.ResponseWriter, r *http.Request) {
 }
 
 func (this ApiResource) GetAll(w http.ResponseWriter, r *http.Request) {
+	if ss, ok := this.service.(services.SearcherService); ok {
+		if cm, found := this.columnMapper(); found {
+			this.search(w, r, ss, cm, nil)
+			return
+		}
+	}
+
 	si, ok := this.service.(services.ReaderService)
 	if !ok {
 		this.notImplementedResponse(w, r)
 		return
 	}
 
-	var payload interface{}
-	var err error
+	page, size, err := parsePaging(r.URL.Query())
+	if err != nil {
+		this.errorResponse(w, r, "invalid list request", err)
+		return
+	}
 
-	page, err := strconv.ParseUint(r.URL.Query().Get("page"), 10, 64)
+	opts, err := this.includeOptions(r)
+	if err != nil {
+		this.errorResponse(w, r, "invalid list request", err)
+		return
+	}
+
+	var payload interface{}
 	if page > 0 {
-		payload, err = si.GetAllPaged(r.Context(), rowsPerPage, (page-1)*rowsPerPage)
+		payload, err = si.GetAllPaged(r.Context(), size, (page-1)*uint64(size), opts...)
 	} else {
-		payload, err = si.GetAll(r.Context())
+		payload, err = si.GetAll(r.Context(), opts...)
 	}
 
 	if err != nil {
 		this.errorResponse(w, r, "could not list resource", err)
 	} else {
-		this.successResponse(w, r, payload)
+		this.listResponse(w, r, payload)
 	}
 }
 
+// search lists the resource by the filtering, sorting and paging parameters in the query string,
+// limited to the entities matching scope if it is given.
+func (this ApiResource) search(w http.ResponseWriter, r *http.Request, ss services.SearcherService, cm metadata.ColumnMapper, scope query.Criteria) {
+	lr, err := parseListRequest(cm, r.URL.Query())
+	if err != nil {
+		this.errorResponse(w, r, "invalid list request", err)
+		return
+	}
+	if scope != nil {
+		lr.criteria = query.And(scope, lr.criteria)
+	}
+
+	include, err := this.includeOptions(r)
+	if err != nil {
+		this.errorResponse(w, r, "invalid list request", err)
+		return
+	}
+
+	var payload interface{}
+	opts := append([]query.Option{query.OrderBy(lr.sort...)}, include...)
+	if lr.keyset {
+		payload, err = ss.FindAllByCursor(r.Context(), lr.criteria, lr.cursor, lr.size, opts...)
+	} else if lr.paged() {
+		payload, err = ss.FindAllByCriteriaPaged(r.Context(), lr.criteria, lr.size, lr.offset(), opts...)
+	} else {
+		payload, err = ss.FindAllByCriteria(r.Context(), lr.criteria, opts...)
+	}
+
+	if err != nil {
+		this.errorResponse(w, r, "could not list resource", err)
+	} else {
+		this.listResponse(w, r, payload)
+	}
+}
+
+// includeOptions returns the options to include the relations requested by the include query parameter.
+func (this ApiResource) includeOptions(r *http.Request) ([]query.Option, error) {
+	include, err := parseInclude(this.ed, r.URL.Query().Get(qpInclude))
+	if err != nil || len(include) == 0 {
+		return nil, err
+	}
+	return []query.Option{query.Include(include...)}, nil
+}
+
 func (this ApiResource) GetById(w http.ResponseWriter, r *http.Request) {
+	if this.keyField != "" {
+		if key := this.binder.PathParam(r, "id"); !isId(key) {
+			this.getByKey(w, r, key)
+			return
+		}
+	}
+
 	si, ok := this.service.(services.ReaderService)
 	if !ok {
 		this.notImplementedResponse(w, r)
@@ -58,14 +128,135 @@ func (this ApiResource) GetById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	payload, err := si.GetById(r.Context(), id)
+	opts, err := this.includeOptions(r)
+	if err != nil {
+		this.errorResponse(w, r, "invalid input", err)
+		return
+	}
+
+	payload, err := si.GetById(r.Context(), id, opts...)
+	if err != nil {
+		this.errorResponse(w, r, "could not get resource", err)
+		return
+	}
+	this.entityResponse(w, r, payload)
+}
+
+// getByKey finds the resource by its key field, relations can not be included when the resource is addressed by key.
+func (this ApiResource) getByKey(w http.ResponseWriter, r *http.Request, key string) {
+	ss, ok := this.service.(services.SearcherService)
+	if !ok {
+		this.notImplementedResponse(w, r)
+		return
+	}
+
+	payload, err := ss.FindOne(r.Context(), this.keyField, key)
 	if err != nil {
 		this.errorResponse(w, r, "could not get resource", err)
+		return
+	}
+	this.entityResponse(w, r, payload)
+}
+
+// entityResponse renders a single resource with its entity tag, or not modified if the client already has it.
+func (this ApiResource) entityResponse(w http.ResponseWriter, r *http.Request, payload interface{}) {
+	if etag, ok := entityTag(payload); ok {
+		w.Header().Set(HDR_ETag, etag)
+		if inm := this.binder.Header(r, HDR_IfNoneMatch); inm != "" && matchesETag(inm, etag, true) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	this.successResponse(w, r, payload)
+}
+
+// GetAllOfParent lists the resource limited to the entities of the parent given in the path.
+func (this ApiResource) GetAllOfParent(w http.ResponseWriter, r *http.Request) {
+	ss, ok := this.service.(services.SearcherService)
+	cm, found := this.columnMapper()
+	if !ok || !found || this.parent == nil {
+		this.notImplementedResponse(w, r)
+		return
+	}
+
+	parentId, err := this.parentId(r)
+	if err != nil {
+		this.errorResponse(w, r, "invalid list request", err)
+		return
+	}
+
+	this.search(w, r, ss, cm, query.Eq(this.parent.field, parentId))
+}
+
+// CreateInParent creates the resource as an entity of the parent given in the path.
+func (this ApiResource) CreateInParent(w http.ResponseWriter, r *http.Request) {
+	si, ok := this.service.(services.CreatorService)
+	if !ok || this.parent == nil {
+		this.notImplementedResponse(w, r)
+		return
+	}
+
+	parentId, err := this.parentId(r)
+	if err != nil {
+		this.errorResponse(w, r, "invalid input", err)
+		return
+	}
+
+	payload, err := si.Create(r.Context(), parentBinder(this.parent.field, parentId, this.binder.BindFunc(r)))
+	if err != nil {
+		this.errorResponse(w, r, "could not create resource", err)
 	} else {
+		this.createdResponse(w, r, payload)
+	}
+}
+
+// parentId reads the id of the parent from the path, resolving it if the parent is addressed by reference.
+func (this ApiResource) parentId(r *http.Request) (uint64, error) {
+	if this.parent.resolve != nil {
+		return this.parent.resolve(r.Context(), this.binder.PathParam(r, "parentId"))
+	}
+	return this.binder.IdPathParam(r, "parentId")
+}
+
+// parentBinder binds the request to the target and then assigns it to the parent, which can not be overridden by the request.
+func parentBinder(field string, parentId uint64, ob services.ObjectBinder) services.ObjectBinder {
+	return services.ObjectBinderFunc(func(target interface{}) error {
+		if err := ob.BindTo(target); err != nil {
+			return err
+		}
+
+		f := reflect.Indirect(reflect.ValueOf(target)).FieldByName(field)
+		if !f.IsValid() || !f.CanSet() || f.Kind() != reflect.Uint64 {
+			return fmt.Errorf("binding: %s is not a parent id field", field)
+		}
+		f.SetUint(parentId)
+		return nil
+	})
+}
+
+// action creates the handler of the custom operation, which renders its result like a single resource.
+func (this ApiResource) action(a resourceAction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		param := func(key string) string {
+			return this.binder.PathParam(r, key)
+		}
+
+		payload, err := a.fn(r.Context(), param("id"), param)
+		if err != nil {
+			this.errorResponse(w, r, "could not "+a.name+" resource", err)
+			return
+		}
+		setETag(w, payload)
 		this.successResponse(w, r, payload)
 	}
 }
 
+// isId checks if a path segment is a numeric id rather than a key.
+func isId(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
 func (this ApiResource) Create(w http.ResponseWriter, r *http.Request) {
 	si, ok := this.service.(services.CreatorService)
 	if !ok {
@@ -74,11 +265,11 @@ func (this ApiResource) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ob := this.binder.BindFunc(r)
-	err := si.Create(r.Context(), ob)
+	payload, err := si.Create(r.Context(), ob)
 	if err != nil {
 		this.errorResponse(w, r, "could not create resource", err)
 	} else {
-		this.successResponse(w, r, nil)
+		this.createdResponse(w, r, payload)
 	}
 }
 
@@ -95,12 +286,60 @@ func (this ApiResource) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ob := this.binder.BindFunc(r)
-	err = si.Update(r.Context(), id, ob)
+	ifMatch, err := this.ifMatch(r)
 	if err != nil {
 		this.errorResponse(w, r, "could not update resource", err)
+		return
+	}
+
+	ob := this.binder.BindFunc(r)
+	if ifMatch != "" {
+		ob = ifMatchBinder(ifMatch, ob)
+	}
+
+	payload, err := si.Update(r.Context(), id, ob)
+	if err != nil {
+		this.errorResponse(w, r, "could not update resource", ifMatchFailed(ifMatch, err))
 	} else {
-		this.successResponse(w, r, nil)
+		setETag(w, payload)
+		this.successResponse(w, r, payload)
+	}
+}
+
+func (this ApiResource) Patch(w http.ResponseWriter, r *http.Request) {
+	si, ok := this.service.(services.PatcherService)
+	if !ok {
+		this.notImplementedResponse(w, r)
+		return
+	}
+
+	id, err := this.binder.IdPathParam(r, "id")
+	if err != nil {
+		this.errorResponse(w, r, "invalid input", err)
+		return
+	}
+
+	ifMatch, err := this.ifMatch(r)
+	if err != nil {
+		this.errorResponse(w, r, "could not patch resource", err)
+		return
+	}
+
+	patch, err := this.binder.BindPatch(r)
+	if err != nil {
+		this.errorResponse(w, r, "invalid patch", err)
+		return
+	}
+	if ifMatch != "" {
+		patch = ifMatchPatch{patch, ifMatch}
+	}
+
+	payload, err := si.Patch(r.Context(), id, patch)
+	if err != nil {
+		this.errorResponse(w, r, "could not patch resource", ifMatchFailed(ifMatch, err))
+	} else {
+		setETag(w, payload)
+		this.successResponse(w, r, payload)
 	}
 }
 
@@ -117,9 +356,61 @@ func (this ApiResource) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = si.Delete(r.Context(), id)
+	ifMatch, err := this.ifMatch(r)
 	if err != nil {
 		this.errorResponse(w, r, "could not delete resource", err)
+		return
+	}
+
+	if ifMatch == "" || strings.TrimSpace(ifMatch) == "*" {
+		err = si.Delete(r.Context(), id)
+	} else {
+		err = this.deleteIfMatch(r, id, ifMatch)
+	}
+	if err != nil {
+		this.errorResponse(w, r, "could not delete resource", err)
+	} else {
+		this.successResponse(w, r, nil)
+	}
+}
+
+// deleteIfMatch deletes the resource by one of the versions listed in the If-Match header,
+// so that the resource is only deleted if it still has that version when the delete statement runs.
+// Only versioned entities can match, like in checkIfMatch.
+func (this ApiResource) deleteIfMatch(r *http.Request, id uint64, ifMatch string) error {
+	si, ok := this.service.(services.VersionedDeleterService)
+	if !ok {
+		return errPreconditionFailed
+	}
+
+	for _, version := range ifMatchVersions(ifMatch, id) {
+		err := si.DeleteVersion(r.Context(), id, version)
+		if errors.Is(err, repository.ErrVersionNotSupported) {
+			break
+		}
+		if !errors.Is(err, repository.ErrOptimisticLock) {
+			return err
+		}
+	}
+	return errPreconditionFailed
+}
+
+func (this ApiResource) Restore(w http.ResponseWriter, r *http.Request) {
+	si, ok := this.service.(services.RestorerService)
+	if !ok {
+		this.notImplementedResponse(w, r)
+		return
+	}
+
+	id, err := this.binder.IdPathParam(r, "id")
+	if err != nil {
+		this.errorResponse(w, r, "invalid input", err)
+		return
+	}
+
+	err = si.Restore(r.Context(), id)
+	if err != nil {
+		this.errorResponse(w, r, "could not restore resource", err)
 	} else {
 		this.successResponse(w, r, nil)
 	}
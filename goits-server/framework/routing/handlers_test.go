@@ -9,8 +9,15 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/cpekyaman/goits/framework/commons"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/patching"
+	"github.com/cpekyaman/goits/framework/security"
 	"github.com/cpekyaman/goits/framework/services"
 	"github.com/cpekyaman/goits/framework/testlib/mocking"
 	"github.com/cpekyaman/goits/framework/testlib/matchers"
@@ -32,6 +39,30 @@ type TestEntity struct {
 	Name string `json:"name"`
 }
 
+type CreateTestEntity struct {
+	domain.DomainEntity
+	Name string `json:"name"`
+}
+
+type VersionedTestEntity struct {
+	domain.VersionedEntity
+	Name string `json:"name"`
+}
+
+type SearchTestEntity struct {
+	Id         uint64    `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	Status     uint64    `json:"status" db:"status"`
+	CreateTime time.Time `json:"createdAt" db:"create_time"`
+}
+
+var searchTestED = metadata.NewEntityDef("routing.SearchTestEntity", "test", "search_test", "id", "id", false,
+	metadata.RelationDef{Name: "Status", Kind: metadata.ManyToOne, Target: "routing.StatusTestEntity", ForeignKey: "Status", Field: "StatusRef"})
+
+func init() {
+	metadata.NewColumnMapper(searchTestED, &SearchTestEntity{})
+}
+
 type ApiResponse struct {
 	Success bool
 	Error   commons.AppError
@@ -53,7 +84,7 @@ func TestGetAll_Error(t *testing.T) {
 
 func TestGetAll_Paged_Error(t *testing.T) {
 	getAllVariant_Error(t, rootUrl+"/test?page=2", func(m *mocking.MockReaderService) {
-		m.EXPECT().GetAllPaged(matchers.GoContext(), uint(20), uint64(20)).Return(nil, fmt.Errorf("service error"))
+		m.EXPECT().GetAllPaged(matchers.GoContext(), uint(20), uint64(20)).Return(services.Page{}, fmt.Errorf("service error"))
 	})
 }
 
@@ -84,9 +115,74 @@ func TestGetAll_Success(t *testing.T) {
 }
 
 func TestGetAll_Paged_Success(t *testing.T) {
-	getAllVariant_Success(t, rootUrl+"/test?page=1", func(m *mocking.MockReaderService, expectedList interface{}) {
-		m.EXPECT().GetAllPaged(matchers.GoContext(), uint(20), uint64(0)).Return(expectedList, nil)
-	})
+	// given
+	ctrl := gomock.NewController(t)
+
+	expectedList := []TestEntity{
+		{Id: 21, Name: "First"},
+		{Id: 22, Name: "Second"},
+	}
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test?page=2&size=20", nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockReaderService(ctrl)
+	svc.EXPECT().GetAllPaged(matchers.GoContext(), uint(20), uint64(20)).
+		Return(services.NewPage(expectedList, 20, 20, 62), nil)
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status should be success")
+
+	response := ApiResponse{}
+	err = json.Unmarshal(rw.Body.Bytes(), &response)
+	assert.Nil(t, err, "error in unmarshal response")
+
+	actual := struct {
+		Items      []TestEntity
+		Page       uint64
+		Size       uint
+		Total      uint64
+		TotalPages uint64
+	}{}
+	err = json.Unmarshal(response.Data, &actual)
+	assert.Nil(t, err, "error in unmarshal data")
+	assert.Equal(t, expectedList, actual.Items, "items are not correct")
+	assert.Equal(t, uint64(2), actual.Page, "page is not correct")
+	assert.Equal(t, uint(20), actual.Size, "size is not correct")
+	assert.Equal(t, uint64(62), actual.Total, "total is not correct")
+	assert.Equal(t, uint64(4), actual.TotalPages, "total pages is not correct")
+
+	assert.Equal(t, `</test?page=1&size=20>; rel="first", `+
+		`</test?page=1&size=20>; rel="prev", `+
+		`</test?page=3&size=20>; rel="next", `+
+		`</test?page=4&size=20>; rel="last"`, rw.Header().Get(HDR_Link), "links are not correct")
+}
+
+func TestGetAll_Paged_FirstPage_NoPrevLink(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test?page=1", nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockReaderService(ctrl)
+	svc.EXPECT().GetAllPaged(matchers.GoContext(), uint(20), uint64(0)).
+		Return(services.NewPage([]TestEntity{}, 20, 0, 0), nil)
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status should be success")
+	assert.Equal(t, `</test?page=1&size=20>; rel="first", </test?page=1&size=20>; rel="last"`,
+		rw.Header().Get(HDR_Link), "links are not correct")
 }
 
 func getAllVariant_Success(t *testing.T, url string, mocker func(*mocking.MockReaderService, interface{})) {
@@ -131,6 +227,164 @@ func getAllVariant_Success(t *testing.T, url string, mocker func(*mocking.MockRe
 	}
 }
 
+func TestGetAll_Search_Paged_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test?status=2&name~=foo&sort=-createdAt,name&page=3&size=50", nil)
+	assert.Nil(t, err, "could not create request")
+
+	expectedCriteria := query.And(query.ILike("Name", "%foo%"), query.Eq("Status", uint64(2)))
+	var actualOpts query.Options
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().FindAllByCriteriaPaged(matchers.GoContext(), expectedCriteria, uint(50), uint64(100), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, c query.Criteria, limit uint, offset uint64, opts ...query.Option) (interface{}, error) {
+			actualOpts = query.NewOptions(opts...)
+			return services.NewPage([]SearchTestEntity{}, limit, offset, 0), nil
+		})
+	_, r := newTestSearchApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status should be success")
+	assert.Equal(t, []query.SortField{query.Desc("CreateTime"), query.Asc("Name")}, actualOpts.Sort, "sort is not correct")
+	assert.Contains(t, rw.Header().Get(HDR_Link), `</test?name~=foo&page=1&size=50&sort=-createdAt%2Cname&status=2>; rel="first"`,
+		"links should keep the list parameters")
+}
+
+func TestGetAll_Search_Unpaged_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test?status[in]=1,2&name[null]=false", nil)
+	assert.Nil(t, err, "could not create request")
+
+	expectedCriteria := query.And(query.IsNotNull("Name"), query.In("Status", uint64(1), uint64(2)))
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().FindAllByCriteria(matchers.GoContext(), expectedCriteria, gomock.Any()).
+		Times(1).
+		Return([]SearchTestEntity{}, nil)
+	_, r := newTestSearchApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status should be success")
+}
+
+func TestGetAll_Search_Cursor_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	after := query.Cursor{Sort: []query.SortField{query.Asc("Id")}, Values: []interface{}{uint64(20)}}
+	next := query.Cursor{Sort: []query.SortField{query.Asc("Id")}, Values: []interface{}{uint64(30)}}
+	cursor, err := encodeCursor(after)
+	assert.Nil(t, err, "could not encode cursor")
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test?status=1&size=10&cursor="+cursor, nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().FindAllByCursor(matchers.GoContext(), query.And(query.Eq("Status", uint64(1))), after, uint(10), gomock.Any()).
+		Times(1).
+		Return(services.NewCursorPage([]SearchTestEntity{{Id: 30}}, 10, next), nil)
+	_, r := newTestSearchApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status should be success")
+
+	response := ApiResponse{}
+	err = json.Unmarshal(rw.Body.Bytes(), &response)
+	assert.Nil(t, err, "error in unmarshal response")
+
+	actual := struct {
+		Items      []SearchTestEntity
+		NextCursor string `json:"next_cursor"`
+	}{}
+	err = json.Unmarshal(response.Data, &actual)
+	assert.Nil(t, err, "error in unmarshal data")
+	assert.Equal(t, 1, len(actual.Items), "items are not correct")
+
+	decoded, err := decodeCursor(actual.NextCursor)
+	assert.Nil(t, err, "next cursor should be valid")
+	assert.Equal(t, next, decoded, "next cursor is not correct")
+	assert.Contains(t, rw.Header().Get(HDR_Link), "cursor="+actual.NextCursor, "link should point to the next page")
+}
+
+func TestGetAll_Search_Cursor_Tampered_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	cursor, err := encodeCursor(query.Cursor{Sort: []query.SortField{query.Asc("Id")}, Values: []interface{}{uint64(20)}})
+	assert.Nil(t, err, "could not encode cursor")
+	tampered := []byte(cursor)
+	tampered[5] ^= 1
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test?cursor="+string(tampered), nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	_, r := newTestSearchApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusBadRequest, rw.Result().StatusCode, "tampered cursor should be rejected")
+}
+
+func TestGetAll_Search_InvalidParams_Error(t *testing.T) {
+	cases := []struct {
+		query string
+		param string
+	}{
+		{"owner=1", "owner"},
+		{"status=abc", "status"},
+		{"sort=-owner", "sort"},
+		{"size=1000", "size"},
+		{"page=0", "page"},
+		{"page=2&cursor=", "cursor"},
+		{"=x", ""},
+	}
+
+	for _, c := range cases {
+		// given
+		ctrl := gomock.NewController(t)
+
+		rw := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", rootUrl+"/test?"+c.query, nil)
+		assert.Nil(t, err, "could not create request")
+
+		svc := mocking.NewMockCRUDService(ctrl)
+		_, r := newTestSearchApiResource(svc)
+
+		// when
+		r.ServeHTTP(rw, req)
+
+		// then
+		assert.Equal(t, http.StatusBadRequest, rw.Result().StatusCode, "status code is not correct for "+c.query)
+
+		response := ApiResponse{}
+		err = json.Unmarshal(rw.Body.Bytes(), &response)
+		assert.Nil(t, err, "error in unmarshal response")
+		assert.Equal(t, "invalid list request", response.Error.Message, "error message not correct")
+		assert.Equal(t, c.param, response.Error.Details["param"], "invalid param should be reported")
+	}
+}
+
 func TestGetById_NotImplemented(t *testing.T) {
 	assertNotImplemented(t, func(api ApiResource) ApiHandler {
 		return ApiHandlerFunc(api.GetById)
@@ -213,6 +467,54 @@ func TestGetById_Success(t *testing.T) {
 	assert.Equal(t, expected.Name, actual.Name, "name of element is not the same")
 }
 
+func TestGetById_Include_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test/5?include=status", nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockReaderService(ctrl)
+	svc.EXPECT().GetById(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uint64, opts ...query.Option) (interface{}, error) {
+			assert.Equal(t, []string{"Status"}, query.NewOptions(opts...).Include, "relation should be included")
+			return &SearchTestEntity{Id: id}, nil
+		})
+	_, r := newTestSearchApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status code is not correct")
+}
+
+func TestGetAll_Search_Include_UnknownRelation_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test?include=status,owner", nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().FindAllByCriteria(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	_, r := newTestSearchApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusBadRequest, rw.Result().StatusCode, "status code is not correct")
+
+	response := ApiResponse{}
+	err = json.Unmarshal(rw.Body.Bytes(), &response)
+	assert.Nil(t, err, "error in unmarshal response")
+	assert.Equal(t, "include", response.Error.Details["param"], "rejected parameter should be reported")
+}
+
 func TestCreate_NotImplemented(t *testing.T) {
 	assertNotImplemented(t, func(api ApiResource) ApiHandler {
 		return ApiHandlerFunc(api.Create)
@@ -232,8 +534,8 @@ func TestCreate_EmptyRequest_Error(t *testing.T) {
 	svc := mocking.NewMockCreatorService(ctrl)
 	svc.EXPECT().Create(matchers.GoContext(), gomock.Any()).
 		Times(1).
-		DoAndReturn(func(ctx context.Context, binding services.ObjectBinder) error {
-			return binding.BindTo(&te)
+		DoAndReturn(func(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
+			return nil, binding.BindTo(&te)
 		})
 	_, r := newTestApiResource(svc)
 
@@ -262,13 +564,17 @@ func TestCreate_Success(t *testing.T) {
 	req, err := http.NewRequest("POST", rootUrl+"/test", body)
 	assert.Nil(t, err, "could not create request")
 
-	var created TestEntity
+	var created CreateTestEntity
 
 	svc := mocking.NewMockCreatorService(ctrl)
 	svc.EXPECT().Create(matchers.GoContext(), gomock.Any()).
 		Times(1).
-		DoAndReturn(func(ctx context.Context, binding services.ObjectBinder) error {
-			return binding.BindTo(&created)
+		DoAndReturn(func(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
+			if err := binding.BindTo(&created); err != nil {
+				return nil, err
+			}
+			created.SetId(7)
+			return &created, nil
 		})
 	_, r := newTestApiResource(svc)
 
@@ -276,8 +582,16 @@ func TestCreate_Success(t *testing.T) {
 	r.ServeHTTP(rw, req)
 
 	// then
-	assert.Equal(t, http.StatusOK, rw.Result().StatusCode)
+	assert.Equal(t, http.StatusCreated, rw.Result().StatusCode)
+	assert.Equal(t, "/test/7", rw.Header().Get(HDR_Location), "location is not correct")
 	assert.Equal(t, reqEntity.Name, created.Name, "name is not bind")
+
+	response := ApiResponse{}
+	err = json.Unmarshal(rw.Body.Bytes(), &response)
+	assert.Nil(t, err, "error in unmarshal response")
+	var data CreateTestEntity
+	assert.Nil(t, json.Unmarshal(response.Data, &data), "error in unmarshal data")
+	assert.Equal(t, uint64(7), data.Id, "created entity should be returned")
 }
 
 func TestUpdate_NotImplemented(t *testing.T) {
@@ -319,8 +633,8 @@ func TestUpdate_EmptyRequest_Error(t *testing.T) {
 	svc := mocking.NewMockUpdaterService(ctrl)
 	svc.EXPECT().Update(matchers.GoContext(), gomock.Eq(id), gomock.Any()).
 		Times(1).
-		DoAndReturn(func(ctx context.Context, id uint64, binding services.ObjectBinder) error {
-			return binding.BindTo(&te)
+		DoAndReturn(func(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
+			return nil, binding.BindTo(&te)
 		})
 	_, r := newTestApiResource(svc)
 
@@ -358,8 +672,8 @@ func TestUpdate_Success(t *testing.T) {
 	svc := mocking.NewMockUpdaterService(ctrl)
 	svc.EXPECT().Update(matchers.GoContext(), gomock.Eq(id), gomock.Any()).
 		Times(1).
-		DoAndReturn(func(ctx context.Context, id uint64, binding services.ObjectBinder) error {
-			return binding.BindTo(&existing)
+		DoAndReturn(func(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
+			return &existing, binding.BindTo(&existing)
 		})
 	_, r := newTestApiResource(svc)
 
@@ -369,34 +683,869 @@ func TestUpdate_Success(t *testing.T) {
 	// then
 	assert.Equal(t, http.StatusOK, rw.Result().StatusCode)
 	assert.Equal(t, reqEntity.Name, existing.Name, "name is not bind")
+
+	response := ApiResponse{}
+	err = json.Unmarshal(rw.Body.Bytes(), &response)
+	assert.Nil(t, err, "error in unmarshal response")
+	var data TestEntity
+	assert.Nil(t, json.Unmarshal(response.Data, &data), "error in unmarshal data")
+	assert.Equal(t, reqEntity.Name, data.Name, "updated entity should be returned")
 }
 
-func assertNotImplemented(t *testing.T, handlerFunc func(ApiResource) ApiHandler) {
+func TestUpdate_StaleVersion_Conflict(t *testing.T) {
 	// given
+	ctrl := gomock.NewController(t)
+
+	var id uint64 = 5
+	body := bytes.NewReader([]byte(`{"id":5,"name":"Updated Name"}`))
+
 	rw := httptest.NewRecorder()
-	api := NewApiResource("Test", "test", NoOpService{})
-	req, err := http.NewRequest("GET", rootUrl+"/test", nil)
+	req, err := http.NewRequest("PUT", rootUrl+"/test/"+strconv.FormatUint(id, 10), body)
 	assert.Nil(t, err, "could not create request")
 
+	svc := mocking.NewMockUpdaterService(ctrl)
+	svc.EXPECT().Update(matchers.GoContext(), gomock.Eq(id), gomock.Any()).
+		Times(1).
+		Return(nil, repository.OptimisticLockError{Entity: "Test", Id: id, Version: 1, CurrentVersion: 2})
+	_, r := newTestApiResource(svc)
+
 	// when
-	handlerFunc(api).Handle(rw, req)
+	r.ServeHTTP(rw, req)
 
 	// then
-	assert.Equal(t, http.StatusNotImplemented, rw.Result().StatusCode)
-}
+	assert.Equal(t, http.StatusConflict, rw.Result().StatusCode, "status code is not correct")
 
-func assertErrorInResponse(t *testing.T, rw *httptest.ResponseRecorder, msg string, cause string) {
 	response := ApiResponse{}
-	err := json.Unmarshal(rw.Body.Bytes(), &response)
+	err = json.Unmarshal(rw.Body.Bytes(), &response)
 	assert.Nil(t, err, "error in unmarshal response")
+	assert.Equal(t, commons.ErrConflict, response.Error.ErrorType, "error type is not correct")
+	assert.Equal(t, float64(2), response.Error.Details["currentVersion"], "current version should be reported")
+}
 
-	assert.Equal(t, msg, response.Error.Message, "error message not correct")
-	assert.Equal(t, cause, response.Error.Cause, "error cause not correct")
+func TestUpdate_Missing_NotFound(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	var id uint64 = 5
+	body := bytes.NewReader([]byte(`{"id":5,"name":"Updated Name"}`))
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PUT", rootUrl+"/test/"+strconv.FormatUint(id, 10), body)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockUpdaterService(ctrl)
+	svc.EXPECT().Update(matchers.GoContext(), gomock.Eq(id), gomock.Any()).
+		Times(1).
+		Return(nil, repository.ErrNotFound)
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusNotFound, rw.Result().StatusCode, "status code is not correct")
 }
 
-func newTestApiResource(svc interface{}) (ApiResource, *chi.Mux) {
-	api := NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, svc)
-	r := chi.NewRouter()
-	Register(r, api)
-	return api, r
+func TestGetById_Versioned_ETag(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test/5", nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockReaderService(ctrl)
+	svc.EXPECT().GetById(matchers.GoContext(), uint64(5)).Times(1).Return(newVersionedTestEntity(5, 3), nil)
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode)
+	assert.Equal(t, `"5-3"`, rw.Header().Get(HDR_ETag), "etag is not correct")
+}
+
+func TestGetById_IfNoneMatch_NotModified(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test/5", nil)
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set(HDR_IfNoneMatch, `"5-2", W/"5-3"`)
+
+	svc := mocking.NewMockReaderService(ctrl)
+	svc.EXPECT().GetById(matchers.GoContext(), uint64(5)).Times(1).Return(newVersionedTestEntity(5, 3), nil)
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusNotModified, rw.Result().StatusCode)
+	assert.Equal(t, `"5-3"`, rw.Header().Get(HDR_ETag), "etag is not correct")
+	assert.Empty(t, rw.Body.Bytes(), "body should be empty")
+}
+
+func TestUpdate_IfMatch_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PUT", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Updated Name"}`)))
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set(HDR_IfMatch, `"5-3"`)
+
+	existing := newVersionedTestEntity(5, 3)
+
+	svc := mocking.NewMockUpdaterService(ctrl)
+	svc.EXPECT().Update(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
+			if err := binding.BindTo(existing); err != nil {
+				return nil, err
+			}
+			existing.Version++
+			return existing, nil
+		})
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode)
+	assert.Equal(t, "Updated Name", existing.Name, "name is not bind")
+	assert.Equal(t, `"5-4"`, rw.Header().Get(HDR_ETag), "etag of updated entity should be returned")
+}
+
+func TestUpdate_IfMatch_Mismatch_PreconditionFailed(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PUT", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Updated Name"}`)))
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set(HDR_IfMatch, `"5-2"`)
+
+	existing := newVersionedTestEntity(5, 3)
+
+	svc := mocking.NewMockUpdaterService(ctrl)
+	svc.EXPECT().Update(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
+			return nil, binding.BindTo(existing)
+		})
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusPreconditionFailed, rw.Result().StatusCode)
+	assert.Equal(t, "Name", existing.Name, "request should not be bind")
+}
+
+func TestUpdate_IfMatch_ConcurrentUpdate_PreconditionFailed(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PUT", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Updated Name"}`)))
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set(HDR_IfMatch, `"5-3"`)
+
+	existing := newVersionedTestEntity(5, 3)
+
+	svc := mocking.NewMockUpdaterService(ctrl)
+	svc.EXPECT().Update(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
+			if err := binding.BindTo(existing); err != nil {
+				return nil, err
+			}
+			// the entity is updated by someone else after it is loaded
+			return nil, repository.OptimisticLockError{Entity: "Test", Id: 5, Version: 3, CurrentVersion: 4}
+		})
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusPreconditionFailed, rw.Result().StatusCode)
+}
+
+func TestUpdate_ConcurrentUpdate_Conflict(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PUT", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Updated Name"}`)))
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockUpdaterService(ctrl)
+	svc.EXPECT().Update(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		Return(nil, repository.OptimisticLockError{Entity: "Test", Id: 5, Version: 3, CurrentVersion: 4})
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusConflict, rw.Result().StatusCode)
+}
+
+func TestUpdate_PreconditionRequired(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PUT", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Updated Name"}`)))
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockUpdaterService(ctrl)
+	svc.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	r := newTestConditionalApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusPreconditionRequired, rw.Result().StatusCode)
+}
+
+func TestDelete_IfMatch_Mismatch_PreconditionFailed(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("DELETE", rootUrl+"/test/5", nil)
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set(HDR_IfMatch, `"5-2"`)
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().DeleteVersion(matchers.GoContext(), uint64(5), uint32(2)).Times(1).
+		Return(repository.OptimisticLockError{Entity: "Test", Id: 5, Version: 2, CurrentVersion: 3})
+	svc.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(0)
+	r := newTestConditionalApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusPreconditionFailed, rw.Result().StatusCode)
+}
+
+func TestDelete_IfMatch_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("DELETE", rootUrl+"/test/5", nil)
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set(HDR_IfMatch, `"5-3"`)
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().DeleteVersion(matchers.GoContext(), uint64(5), uint32(3)).Times(1).Return(nil)
+	svc.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(0)
+	r := newTestConditionalApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode)
+}
+
+func TestDelete_IfMatch_NotVersioned_PreconditionFailed(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("DELETE", rootUrl+"/test/5", nil)
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set(HDR_IfMatch, `"5-3"`)
+
+	svc := mocking.NewMockDeleterService(ctrl)
+	svc.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(0)
+	r := newTestConditionalApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusPreconditionFailed, rw.Result().StatusCode)
+}
+
+func TestIfMatchVersions(t *testing.T) {
+	// when
+	versions := ifMatchVersions(`"4-1", W/"5-2", "5-3", "5-x", "5-", "15-4"`, 5)
+
+	// then
+	assert.Equal(t, []uint32{3}, versions, "only strong tags of the entity should be used")
+}
+
+func TestPatch_NotImplemented(t *testing.T) {
+	assertNotImplemented(t, func(api ApiResource) ApiHandler {
+		return ApiHandlerFunc(api.Patch)
+	})
+}
+
+func TestPatch_UnsupportedMediaType_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PATCH", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Patched"}`)))
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set("Content-Type", "application/json")
+
+	svc := mocking.NewMockPatcherService(ctrl)
+	svc.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusUnsupportedMediaType, rw.Result().StatusCode)
+}
+
+func TestPatch_Conflict_Error(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PATCH", rootUrl+"/test/5", bytes.NewReader([]byte(`[{"op":"remove","path":"/missing"}]`)))
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set("Content-Type", patching.ContentTypeJSONPatch)
+
+	svc := mocking.NewMockPatcherService(ctrl)
+	svc.EXPECT().Patch(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+			return nil, patching.ApplyTo(patch, newVersionedTestEntity(id, 1))
+		})
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusConflict, rw.Result().StatusCode)
+
+	response := ApiResponse{}
+	err = json.Unmarshal(rw.Body.Bytes(), &response)
+	assert.Nil(t, err, "error in unmarshal response")
+	assert.Equal(t, "/missing", response.Error.Details["path"], "path of failed operation should be reported")
+}
+
+func TestPatch_MergePatch_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PATCH", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Patched"}`)))
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set("Content-Type", patching.ContentTypeMergePatch)
+
+	existing := newVersionedTestEntity(5, 3)
+
+	svc := mocking.NewMockPatcherService(ctrl)
+	svc.EXPECT().Patch(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+			if err := patching.ApplyTo(patch, existing); err != nil {
+				return nil, err
+			}
+			existing.Version++
+			return existing, nil
+		})
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode)
+	assert.Equal(t, "Patched", existing.Name, "name is not patched")
+	assert.Equal(t, `"5-4"`, rw.Header().Get(HDR_ETag), "etag of patched entity should be returned")
+}
+
+func TestPatch_IfMatch_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PATCH", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Patched"}`)))
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set("Content-Type", patching.ContentTypeMergePatch)
+	req.Header.Set(HDR_IfMatch, `"5-3"`)
+
+	existing := newVersionedTestEntity(5, 3)
+
+	svc := mocking.NewMockPatcherService(ctrl)
+	svc.EXPECT().Patch(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+			if err := patching.ApplyTo(patch, existing); err != nil {
+				return nil, err
+			}
+			existing.Version++
+			return existing, nil
+		})
+	r := newTestConditionalApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode)
+	assert.Equal(t, "Patched", existing.Name, "name is not patched")
+	assert.Equal(t, `"5-4"`, rw.Header().Get(HDR_ETag), "etag of patched entity should be returned")
+}
+
+func TestPatch_IfMatch_Mismatch_PreconditionFailed(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PATCH", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Patched"}`)))
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set("Content-Type", patching.ContentTypeMergePatch)
+	req.Header.Set(HDR_IfMatch, `"5-2"`)
+
+	existing := newVersionedTestEntity(5, 3)
+
+	svc := mocking.NewMockPatcherService(ctrl)
+	svc.EXPECT().Patch(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+			return nil, patching.ApplyTo(patch, existing)
+		})
+	r := newTestConditionalApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusPreconditionFailed, rw.Result().StatusCode)
+	assert.Equal(t, "Name", existing.Name, "patch should not be applied")
+}
+
+func TestPatch_IfMatch_ConcurrentUpdate_PreconditionFailed(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("PATCH", rootUrl+"/test/5", bytes.NewReader([]byte(`{"name":"Patched"}`)))
+	assert.Nil(t, err, "could not create request")
+	req.Header.Set("Content-Type", patching.ContentTypeMergePatch)
+	req.Header.Set(HDR_IfMatch, `"5-3"`)
+
+	existing := newVersionedTestEntity(5, 3)
+
+	svc := mocking.NewMockPatcherService(ctrl)
+	svc.EXPECT().Patch(matchers.GoContext(), uint64(5), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+			if err := patching.ApplyTo(patch, existing); err != nil {
+				return nil, err
+			}
+			// the entity is updated by someone else after it is loaded
+			return nil, repository.OptimisticLockError{Entity: "Test", Id: 5, Version: 3, CurrentVersion: 4}
+		})
+	r := newTestConditionalApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusPreconditionFailed, rw.Result().StatusCode)
+}
+
+func TestRestore_NotImplemented(t *testing.T) {
+	assertNotImplemented(t, func(api ApiResource) ApiHandler {
+		return ApiHandlerFunc(api.Restore)
+	})
+}
+
+func TestRestore_NotDeleted_NotFound(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", rootUrl+"/test/5/restore", nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockRestorerService(ctrl)
+	svc.EXPECT().Restore(matchers.GoContext(), uint64(5)).Times(1).Return(repository.ErrNotFound)
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusNotFound, rw.Result().StatusCode, "status code is not correct")
+}
+
+func TestRestore_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", rootUrl+"/test/5/restore", nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockRestorerService(ctrl)
+	svc.EXPECT().Restore(matchers.GoContext(), uint64(5)).Times(1).Return(nil)
+	_, r := newTestApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status code is not correct")
+}
+
+func TestMatchesETag(t *testing.T) {
+	cases := []struct {
+		header string
+		weak   bool
+		match  bool
+	}{
+		{`"1-2"`, false, true},
+		{`"1-1", "1-2"`, false, true},
+		{`*`, false, true},
+		{`W/"1-2"`, false, false},
+		{`W/"1-2"`, true, true},
+		{`"1-3"`, true, false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.match, matchesETag(c.header, `"1-2"`, c.weak), "match is not correct for %s", c.header)
+	}
+}
+
+func assertNotImplemented(t *testing.T, handlerFunc func(ApiResource) ApiHandler) {
+	// given
+	rw := httptest.NewRecorder()
+	api := NewApiResource("Test", "test", NoOpService{})
+	req, err := http.NewRequest("GET", rootUrl+"/test", nil)
+	assert.Nil(t, err, "could not create request")
+
+	// when
+	handlerFunc(api).Handle(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusNotImplemented, rw.Result().StatusCode)
+}
+
+func assertErrorInResponse(t *testing.T, rw *httptest.ResponseRecorder, msg string, cause string) {
+	response := ApiResponse{}
+	err := json.Unmarshal(rw.Body.Bytes(), &response)
+	assert.Nil(t, err, "error in unmarshal response")
+
+	assert.Equal(t, msg, response.Error.Message, "error message not correct")
+	assert.Equal(t, cause, response.Error.Cause, "error cause not correct")
+}
+
+func newTestSearchApiResource(svc interface{}) (ApiResource, *chi.Mux) {
+	api := NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, svc).
+		WithEntity(searchTestED)
+	r := chi.NewRouter()
+	Register(r, api)
+	return api, r
+}
+
+func newTestConditionalApiResource(svc interface{}) *chi.Mux {
+	api := NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, svc).
+		WithPreconditionRequired()
+	r := chi.NewRouter()
+	Register(r, api)
+	return r
+}
+
+func newVersionedTestEntity(id uint64, version uint32) *VersionedTestEntity {
+	e := &VersionedTestEntity{Name: "Name"}
+	e.Id = id
+	e.Version = version
+	return e
+}
+
+func newTestApiResource(svc interface{}) (ApiResource, *chi.Mux) {
+	api := NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, svc)
+	r := chi.NewRouter()
+	Register(r, api)
+	return api, r
+}
+
+func TestGetById_Key_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test/TEST-5", nil)
+	assert.Nil(t, err, "could not create request")
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().FindOne(matchers.GoContext(), "Name", "TEST-5").Times(1).Return(TestEntity{Id: 5, Name: "TEST-5"}, nil)
+	svc.EXPECT().GetById(gomock.Any(), gomock.Any()).Times(0)
+
+	api := NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, svc).WithKey("Name")
+	r := chi.NewRouter()
+	Register(r, api)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status should be success")
+
+	response := ApiResponse{}
+	assert.Nil(t, json.Unmarshal(rw.Body.Bytes(), &response), "error in unmarshal response")
+	var actual TestEntity
+	assert.Nil(t, json.Unmarshal(response.Data, &actual), "error in unmarshal data")
+	assert.Equal(t, uint64(5), actual.Id, "entity is not found by key")
+}
+
+func TestGetAllOfParent_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/parent/3/test?name~=foo", nil)
+	assert.Nil(t, err, "could not create request")
+
+	expectedCriteria := query.And(query.Eq("Status", uint64(3)), query.And(query.ILike("Name", "%foo%")))
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().FindAllByCriteria(matchers.GoContext(), expectedCriteria, gomock.Any()).
+		Times(1).
+		Return([]SearchTestEntity{}, nil)
+	r := newTestNestedApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status should be success")
+}
+
+func TestCreateInParent_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", rootUrl+"/parent/3/test", bytes.NewReader([]byte(`{"name":"child","status":9}`)))
+	assert.Nil(t, err, "could not create request")
+
+	var created SearchTestEntity
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().Create(matchers.GoContext(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
+			return &created, binding.BindTo(&created)
+		})
+	r := newTestNestedApiResource(svc)
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusCreated, rw.Result().StatusCode, "status should be created")
+	assert.Equal(t, "child", created.Name, "name is not bind")
+	assert.Equal(t, uint64(3), created.Status, "entity is not assigned to parent")
+}
+
+func TestCreateInParent_ByKey_Success(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", rootUrl+"/parent/KEY-7/test", bytes.NewReader([]byte(`{"name":"child"}`)))
+	assert.Nil(t, err, "could not create request")
+
+	var created SearchTestEntity
+
+	svc := mocking.NewMockCRUDService(ctrl)
+	svc.EXPECT().Create(matchers.GoContext(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
+			return &created, binding.BindTo(&created)
+		})
+
+	r := chi.NewRouter()
+	Register(r, NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, svc).
+		WithEntity(searchTestED).
+		WithParent("parent", "Status").
+		WithParentResolver(func(ctx context.Context, ref string) (uint64, error) {
+			if ref != "KEY-7" {
+				return 0, fmt.Errorf("notfound: %s", ref)
+			}
+			return 7, nil
+		}))
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusCreated, rw.Result().StatusCode, "status should be created")
+	assert.Equal(t, uint64(7), created.Status, "entity is not assigned to resolved parent")
+}
+
+func newTestNestedApiResource(svc interface{}) *chi.Mux {
+	r := chi.NewRouter()
+	// the parent resource is registered as well to make sure the nested routes do not conflict with it
+	Register(r, NewCustomApiResource("Parent", "parent", engineRequestBinder{}, engineResponseRenderer{}, &NoOpService{}))
+	Register(r, NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, svc).
+		WithEntity(searchTestED).
+		WithParent("parent", "Status"))
+	return r
+}
+
+func TestAction_Success(t *testing.T) {
+	// given
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", rootUrl+"/test/KEY-1/transitions/close", nil)
+	assert.Nil(t, err, "could not create request")
+
+	var ref, name string
+	r := chi.NewRouter()
+	Register(r, NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, &NoOpService{}).
+		WithAction(http.MethodPost, "/transitions/{name}", "transition", func(ctx context.Context, id string, param func(string) string) (interface{}, error) {
+			ref, name = id, param("name")
+			return map[string]string{"status": "closed"}, nil
+		}))
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status should be success")
+	assert.Equal(t, "KEY-1", ref, "resource should be addressed by the path")
+	assert.Equal(t, "close", name, "path params of the action should be passed")
+}
+
+func TestAction_Error(t *testing.T) {
+	// given
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", rootUrl+"/test/1/transitions/close", nil)
+	assert.Nil(t, err, "could not create request")
+
+	r := chi.NewRouter()
+	Register(r, NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, &NoOpService{}).
+		WithAction(http.MethodPost, "/transitions/{name}", "transition", func(ctx context.Context, id string, param func(string) string) (interface{}, error) {
+			return nil, fmt.Errorf("conflict: not allowed")
+		}))
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusConflict, rw.Result().StatusCode, "status should be conflict")
+}
+
+func TestEndpoint_Success(t *testing.T) {
+	// given
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", rootUrl+"/auth/login", bytes.NewReader([]byte(`{"name":"someone"}`)))
+	assert.Nil(t, err, "could not create request")
+
+	r := chi.NewRouter()
+	RegisterEndpoint(r, NewCustomApiEndpoint("Auth", "auth", engineRequestBinder{}, engineResponseRenderer{}).
+		With(http.MethodPost, "/login", "login", func(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error) {
+			var body SearchTestEntity
+			if err := bind.BindTo(&body); err != nil {
+				return nil, err
+			}
+			return body.Name, nil
+		}))
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, rw.Result().StatusCode, "status should be success")
+
+	response := ApiResponse{}
+	err = json.Unmarshal(rw.Body.Bytes(), &response)
+	assert.Nil(t, err, "error in unmarshal response")
+	assert.Equal(t, `"someone"`, string(response.Data), "result should be rendered")
+}
+
+func TestEndpoint_Unauthorized_Error(t *testing.T) {
+	// given
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/auth/me", nil)
+	assert.Nil(t, err, "could not create request")
+
+	r := chi.NewRouter()
+	RegisterEndpoint(r, NewCustomApiEndpoint("Auth", "auth", engineRequestBinder{}, engineResponseRenderer{}).
+		With(http.MethodGet, "/me", "getCurrentUser", func(w http.ResponseWriter, r *http.Request, bind services.ObjectBinder) (interface{}, error) {
+			return nil, fmt.Errorf("unauthorized: not logged in")
+		}))
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusUnauthorized, rw.Result().StatusCode, "status should be unauthorized")
+}
+
+func TestAccess_Authenticated_Anonymous_Unauthorized(t *testing.T) {
+	// given
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", rootUrl+"/test", bytes.NewReader([]byte(`{"name":"test"}`)))
+	assert.Nil(t, err, "could not create request")
+
+	r := chi.NewRouter()
+	Register(r, NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, &NoOpService{}).
+		WithAuthenticated())
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusUnauthorized, rw.Result().StatusCode, "status should be unauthorized")
+}
+
+func TestAccess_Public_Anonymous_Allowed(t *testing.T) {
+	// given
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", rootUrl+"/test", nil)
+	assert.Nil(t, err, "could not create request")
+
+	r := chi.NewRouter()
+	Register(r, NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, &NoOpService{}).
+		WithAuthenticated().
+		WithPublic("getAll"))
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusNotImplemented, rw.Result().StatusCode, "public operation should be called")
+}
+
+func TestAccess_ReadScope_Write_Forbidden(t *testing.T) {
+	// given
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("DELETE", rootUrl+"/test/1", nil)
+	assert.Nil(t, err, "could not create request")
+	req = req.WithContext(security.WithPrincipal(req.Context(), security.Principal{UserId: 3, Subject: "ann", Scopes: []string{security.ScopeRead}}))
+
+	r := chi.NewRouter()
+	Register(r, NewCustomApiResource("Test", "test", engineRequestBinder{}, engineResponseRenderer{}, &NoOpService{}).
+		WithAuthenticated("delete"))
+
+	// when
+	r.ServeHTTP(rw, req)
+
+	// then
+	assert.Equal(t, http.StatusForbidden, rw.Result().StatusCode, "status should be forbidden")
 }
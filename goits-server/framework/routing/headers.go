@@ -3,4 +3,10 @@ package routing
 const (
 	HDR_CorrelationID = "x-correlation-id"
 	HDR_RequestID     = "x-request-id"
+	HDR_Link          = "Link"
+	HDR_Location      = "Location"
+	HDR_ETag          = "ETag"
+	HDR_IfMatch       = "If-Match"
+	HDR_IfNoneMatch   = "If-None-Match"
+	HDR_Authorization = "Authorization"
 )
@@ -0,0 +1,43 @@
+package routing
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cpekyaman/goits/framework/services"
+)
+
+// pageLinks builds the value of the Link header (RFC 8288) that points to first, previous, next and last pages.
+// The links keep all other query parameters of the request so that filtering and sorting is preserved.
+func pageLinks(u *url.URL, page services.Page) string {
+	links := []string{pageLink(u, page, 1, "first")}
+	if page.HasPrev() {
+		links = append(links, pageLink(u, page, page.Page-1, "prev"))
+	}
+	if page.HasNext() {
+		links = append(links, pageLink(u, page, page.Page+1, "next"))
+	}
+	links = append(links, pageLink(u, page, page.LastPage(), "last"))
+
+	return strings.Join(links, ", ")
+}
+
+func pageLink(u *url.URL, page services.Page, number uint64, rel string) string {
+	q := u.Query()
+	q.Set(qpPage, strconv.FormatUint(number, 10))
+	q.Set(qpSize, strconv.FormatUint(uint64(page.Size), 10))
+
+	target := url.URL{Path: u.Path, RawQuery: q.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
+}
+
+// cursorLink builds the value of the Link header that points to the next page of a keyset paged list.
+func cursorLink(u *url.URL, cursor string) string {
+	q := u.Query()
+	q.Set(qpCursor, cursor)
+
+	target := url.URL{Path: u.Path, RawQuery: q.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"next\"", target.String())
+}
@@ -0,0 +1,349 @@
+package routing
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/query"
+)
+
+const (
+	qpSort    = "sort"
+	qpPage    = "page"
+	qpSize    = "size"
+	qpCursor  = "cursor"
+	qpInclude = "include"
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var reservedParams = map[string]bool{
+	qpSort:    true,
+	qpPage:    true,
+	qpSize:    true,
+	qpCursor:  true,
+	qpInclude: true,
+}
+
+// shorthandOps are the operators that can be given as the last character of a filter key, e.g. name~=foo.
+var shorthandOps = map[byte]string{
+	'!': "ne",
+	'<': "le",
+	'>': "ge",
+	'~': "contains",
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// QueryParamError is returned when a query parameter of a request can not be used.
+type QueryParamError struct {
+	Param  string
+	Value  string
+	Reason string
+}
+
+func (this QueryParamError) Error() string {
+	return fmt.Sprintf("binding: invalid query parameter %s: %s", this.Param, this.Reason)
+}
+
+// Details returns the parameter and the reason it is rejected to be reported back to the client.
+func (this QueryParamError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"param":  this.Param,
+		"value":  this.Value,
+		"reason": this.Reason,
+	}
+}
+
+// listRequest is the parsed form of filtering, sorting and paging parameters of a list request.
+type listRequest struct {
+	criteria query.Criteria
+	sort     []query.SortField
+	page     uint64
+	size     uint
+
+	// keyset paging is used instead of page numbers when a cursor parameter is given, even if it is empty
+	keyset bool
+	cursor query.Cursor
+}
+
+func (this listRequest) paged() bool {
+	return this.page > 0
+}
+
+func (this listRequest) offset() uint64 {
+	return (this.page - 1) * uint64(this.size)
+}
+
+// parseListRequest parses the list parameters by resolving json field names to entity fields via cm.
+//
+// Any non reserved parameter is a filter in the form of field=value, where field can be suffixed
+// with a shorthand operator (!, <, >, ~) or a bracketed operator such as status[in]=1,2.
+// Sort is a comma separated list of fields, where a leading minus means descending order.
+// Paging is done either by page and size, or by cursor and size where an empty cursor starts from the beginning.
+func parseListRequest(cm metadata.ColumnMapper, values url.Values) (listRequest, error) {
+	lr := listRequest{}
+
+	criteria, err := parseFilters(cm, values)
+	if err != nil {
+		return lr, err
+	}
+	lr.criteria = criteria
+
+	if lr.sort, err = parseSort(cm, values.Get(qpSort)); err != nil {
+		return lr, err
+	}
+
+	if lr.page, lr.size, err = parsePaging(values); err != nil {
+		return lr, err
+	}
+
+	if cursor, ok := values[qpCursor]; ok {
+		if values.Get(qpPage) != "" {
+			return lr, QueryParamError{qpCursor, cursor[0], "can not be used together with page"}
+		}
+
+		lr.keyset = true
+		if cursor[0] != "" {
+			if lr.cursor, err = decodeCursor(cursor[0]); err != nil {
+				return lr, QueryParamError{qpCursor, cursor[0], err.Error()}
+			}
+		}
+	}
+
+	return lr, nil
+}
+
+func parseFilters(cm metadata.ColumnMapper, values url.Values) (query.Criteria, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if !reservedParams[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var criteria []query.Criteria
+	for _, k := range keys {
+		if k == "" {
+			return nil, QueryParamError{k, strings.Join(values[k], ","), "parameter name is missing"}
+		}
+		name, op := splitFilterKey(k)
+
+		field, ok := cm.FieldByJSONName(name)
+		if !ok || !cm.HasColumn(field) {
+			return nil, QueryParamError{k, strings.Join(values[k], ","), "unknown field"}
+		}
+
+		vals := values[k]
+		if op == "eq" && len(vals) > 1 {
+			// repeating the same field is a shorthand for in
+			op = "in"
+			vals = []string{strings.Join(vals, ",")}
+		}
+
+		for _, v := range vals {
+			c, err := newFilterCriteria(cm, field, op, v)
+			if err != nil {
+				return nil, QueryParamError{k, v, err.Error()}
+			}
+			criteria = append(criteria, c)
+		}
+	}
+
+	return query.And(criteria...), nil
+}
+
+// splitFilterKey splits a filter key into its json field name and operator parts.
+func splitFilterKey(key string) (string, string) {
+	if i := strings.Index(key, "["); i > 0 && strings.HasSuffix(key, "]") {
+		return key[:i], key[i+1 : len(key)-1]
+	}
+
+	if len(key) > 1 {
+		if op, ok := shorthandOps[key[len(key)-1]]; ok {
+			return key[:len(key)-1], op
+		}
+	}
+
+	return key, "eq"
+}
+
+func newFilterCriteria(cm metadata.ColumnMapper, field string, op string, raw string) (query.Criteria, error) {
+	ft := cm.FieldType(field)
+
+	switch op {
+	case "null":
+		isNull, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false")
+		}
+		if isNull {
+			return query.IsNull(field), nil
+		}
+		return query.IsNotNull(field), nil
+	case "like":
+		return query.Like(field, raw), nil
+	case "ilike":
+		return query.ILike(field, raw), nil
+	case "contains":
+		return query.ILike(field, "%"+raw+"%"), nil
+	case "in":
+		vals, err := convertValues(ft, strings.Split(raw, ","))
+		if err != nil {
+			return nil, err
+		}
+		return query.In(field, vals...), nil
+	case "between":
+		vals, err := convertValues(ft, strings.Split(raw, ","))
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) != 2 {
+			return nil, fmt.Errorf("expected two comma separated values")
+		}
+		return query.Between(field, vals[0], vals[1]), nil
+	}
+
+	v, err := convertValue(ft, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "eq":
+		return query.Eq(field, v), nil
+	case "ne":
+		return query.Ne(field, v), nil
+	case "lt":
+		return query.Lt(field, v), nil
+	case "le":
+		return query.Le(field, v), nil
+	case "gt":
+		return query.Gt(field, v), nil
+	case "ge":
+		return query.Ge(field, v), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
+
+// parseInclude parses the comma separated relation names to be included in the response.
+// Names are resolved to the relations of the entity if the entity is known, otherwise they are left to the repository.
+func parseInclude(ed metadata.EntityDef, raw string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	include := make([]string, 0, len(parts))
+	for _, p := range parts {
+		name := strings.TrimSpace(p)
+		if ed != nil {
+			rel, found := ed.Relation(name)
+			if !found {
+				return nil, QueryParamError{qpInclude, raw, fmt.Sprintf("unknown relation %s", name)}
+			}
+			name = rel.Name
+		}
+		include = append(include, name)
+	}
+	return include, nil
+}
+
+func parseSort(cm metadata.ColumnMapper, raw string) ([]query.SortField, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	sortFields := make([]query.SortField, 0, len(parts))
+	for _, p := range parts {
+		desc := strings.HasPrefix(p, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(p, "-"), "+")
+
+		field, ok := cm.FieldByJSONName(name)
+		if !ok || !cm.HasColumn(field) {
+			return nil, QueryParamError{qpSort, p, "unknown sort field"}
+		}
+		sortFields = append(sortFields, query.SortField{Field: field, Desc: desc})
+	}
+	return sortFields, nil
+}
+
+func parsePaging(values url.Values) (uint64, uint, error) {
+	var page uint64
+	var size uint64 = defaultPageSize
+	var err error
+
+	if raw := values.Get(qpSize); raw != "" {
+		size, err = strconv.ParseUint(raw, 10, 32)
+		if err != nil || size == 0 || size > maxPageSize {
+			return 0, 0, QueryParamError{qpSize, raw, fmt.Sprintf("expected a number between 1 and %d", maxPageSize)}
+		}
+		page = 1
+	}
+
+	if raw := values.Get(qpPage); raw != "" {
+		page, err = strconv.ParseUint(raw, 10, 64)
+		if err != nil || page == 0 {
+			return 0, 0, QueryParamError{qpPage, raw, "expected a positive number"}
+		}
+	}
+
+	return page, uint(size), nil
+}
+
+func convertValues(t reflect.Type, raw []string) ([]interface{}, error) {
+	vals := make([]interface{}, len(raw))
+	for i, r := range raw {
+		v, err := convertValue(t, r)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+	}
+	return vals, nil
+}
+
+// convertValue converts the raw query parameter value to the type of the field it is compared to.
+func convertValue(t reflect.Type, raw string) (interface{}, error) {
+	if t == nil {
+		return raw, nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var v interface{}
+	var err error
+	switch t.Kind() {
+	case reflect.Bool:
+		v, err = strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err = strconv.ParseInt(raw, 10, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err = strconv.ParseUint(raw, 10, t.Bits())
+	case reflect.Float32, reflect.Float64:
+		v, err = strconv.ParseFloat(raw, t.Bits())
+	case reflect.Struct:
+		if t == timeType {
+			v, err = time.Parse(time.RFC3339, raw)
+		} else {
+			v = raw
+		}
+	default:
+		v = raw
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid %s", raw, t.Name())
+	}
+	return v, nil
+}
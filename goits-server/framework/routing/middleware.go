@@ -3,11 +3,13 @@ package routing
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	uuid "github.com/satori/go.uuid"
 
 	"github.com/cpekyaman/goits/framework/monitoring"
+	"github.com/cpekyaman/goits/framework/security"
 	"github.com/go-chi/chi/middleware"
 )
 
@@ -42,6 +44,46 @@ func Monitor(next http.Handler) http.Handler {
 	return http.HandlerFunc(fn)
 }
 
+// Authenticate returns a handler that puts the principal of the credential of the request into the request context.
+// The credential is the bearer token of the authorization header, or the session cookie if there is no header.
+// Requests without a credential proceed anonymously, while the ones with an invalid bearer token are rejected.
+// An invalid session cookie is ignored instead, so that a stale cookie does not prevent logging in again.
+func Authenticate(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		credential, fromCookie := requestCredential(r)
+		if credential == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := security.Authenticate(r.Context(), credential)
+		if err != nil && fromCookie {
+			next.ServeHTTP(w, r)
+			return
+		} else if err != nil {
+			res := ApiResource{name: "Auth", render: engineResponseRenderer{}}
+			res.errorResponse(w, r, "could not authenticate", err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(security.WithPrincipal(r.Context(), p)))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// requestCredential reads the bearer token of the request, or the value of the session cookie if it has no bearer token.
+func requestCredential(r *http.Request) (string, bool) {
+	if h := r.Header.Get(HDR_Authorization); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer ")), false
+	}
+	if name := security.SessionCookie(); name != "" {
+		if c, err := r.Cookie(name); err == nil {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
 // Logger logs the response statistics by using the Logger from MonitoringContext.
 func Logger(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
@@ -1,11 +1,14 @@
 package routing
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/cpekyaman/goits/framework/monitoring"
+	"github.com/cpekyaman/goits/framework/security"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,6 +71,65 @@ func TestMonitoredHandler_ShouldSetResource(t *testing.T) {
 	assert.Equal(t, operation, mctx.Operation(), "operation is not set")
 }
 
+func TestAuthenticate_BearerToken_SetsPrincipal(t *testing.T) {
+	// given
+	req, rw := setup(t)
+
+	security.RegisterTokenSource("test", func(ctx context.Context, p security.Principal) error { return nil })
+	token, err := security.IssueToken(security.Principal{UserId: 3, Subject: "ann", Method: "test"}, time.Now(), time.Hour)
+	assert.Nil(t, err, "could not issue token")
+	req.Header.Set(HDR_Authorization, "Bearer "+token)
+
+	var updatedReq *http.Request
+	m := Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		updatedReq = r
+	}))
+
+	// when
+	m.ServeHTTP(rw, req)
+
+	// then
+	p, ok := security.PrincipalFromContext(updatedReq.Context())
+	assert.True(t, ok, "principal should be set")
+	assert.Equal(t, "ann", p.Subject, "principal is not correct")
+}
+
+func TestAuthenticate_NoCredential_Anonymous(t *testing.T) {
+	// given
+	req, rw := setup(t)
+
+	var updatedReq *http.Request
+	m := Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		updatedReq = r
+	}))
+
+	// when
+	m.ServeHTTP(rw, req)
+
+	// then
+	assert.NotNil(t, updatedReq, "request should proceed")
+	_, ok := security.PrincipalFromContext(updatedReq.Context())
+	assert.False(t, ok, "principal should not be set")
+}
+
+func TestAuthenticate_InvalidBearerToken_Unauthorized(t *testing.T) {
+	// given
+	req, rw := setup(t)
+	req.Header.Set(HDR_Authorization, "Bearer a.b.c")
+
+	called := false
+	m := Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	// when
+	m.ServeHTTP(rw, req)
+
+	// then
+	assert.False(t, called, "request should not proceed")
+	assert.Equal(t, http.StatusUnauthorized, rw.Result().StatusCode, "status should be unauthorized")
+}
+
 func setup(t *testing.T) (*http.Request, *httptest.ResponseRecorder) {
 	rw := httptest.NewRecorder()
 	req, err := http.NewRequest("GET", "http://localhost:8080/test", nil)
@@ -2,10 +2,16 @@
 package routing
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/cpekyaman/goits/framework/commons"
 	"github.com/cpekyaman/goits/framework/monitoring"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/patching"
 	"github.com/cpekyaman/goits/framework/services"
 )
 
@@ -17,7 +23,9 @@ var renderer ResponseRenderer
 type RequestBinder interface {
 	PathParam(r *http.Request, key string) string
 	IdPathParam(r *http.Request, key string) (uint64, error)
+	Header(r *http.Request, key string) string
 	BindFunc(r *http.Request) services.ObjectBinder
+	BindPatch(r *http.Request) (patching.Patch, error)
 }
 
 // ResponseRenderer represents response rendering functionality we explicitly use from actual routing engine.
@@ -33,16 +41,116 @@ type ApiResource struct {
 	service interface{}
 	binder  RequestBinder
 	render  ResponseRenderer
+	ed      metadata.EntityDef
+
+	requireIfMatch bool
+
+	// keyField is the unique field the resource can also be addressed by instead of id
+	keyField string
+	parent   *parentResource
+	actions  []resourceAction
+
+	access accessRules
+}
+
+// ActionFunc performs a custom operation on a single resource, such as a workflow transition of an issue.
+// The ref is the id or the key of the resource in the path, and param reads the other path params of the action.
+type ActionFunc func(ctx context.Context, ref string, param func(key string) string) (interface{}, error)
+
+// resourceAction is a custom operation routed under the path of a single resource.
+type resourceAction struct {
+	method string
+	path   string
+	name   string
+	fn     ActionFunc
+}
+
+// ParentResolver finds the id of the parent resource from its reference in the path, which is either its id or its key.
+type ParentResolver func(ctx context.Context, ref string) (uint64, error)
+
+// parentResource is the resource that the entities of a nested resource belong to.
+type parentResource struct {
+	path    string
+	field   string
+	resolve ParentResolver
 }
 
 // NewApiResource creates a new api resource by using engine provided defaults for binder and renderer.
 func NewApiResource(name string, path string, svc interface{}) ApiResource {
-	return ApiResource{name, path, svc, binder, renderer}
+	return ApiResource{name: name, path: path, service: svc, binder: binder, render: renderer}
 }
 
 // NewCustomApiResource creates a new api resource by using provided binder and renderer.
 func NewCustomApiResource(name string, path string, b RequestBinder, r ResponseRenderer, svc interface{}) ApiResource {
-	return ApiResource{name, path, svc, b, r}
+	return ApiResource{name: name, path: path, service: svc, binder: b, render: r}
+}
+
+// WithEntity associates the resource with the entity it serves.
+// Lists of a resource with an entity can be filtered and sorted by entity fields via query parameters.
+func (this ApiResource) WithEntity(ed metadata.EntityDef) ApiResource {
+	this.ed = ed
+	return this
+}
+
+// WithPreconditionRequired makes the resource reject updates and deletes that are not conditional on If-Match header.
+// It prevents clients from overwriting changes they have not seen.
+func (this ApiResource) WithPreconditionRequired() ApiResource {
+	this.requireIfMatch = true
+	return this
+}
+
+// WithKey makes the resource also addressable by a unique field of its entity, such as /issue/GOITS-1.
+// A path segment that is not a numeric id is looked up by the key field, which requires a SearcherService.
+func (this ApiResource) WithKey(field string) ApiResource {
+	this.keyField = field
+	return this
+}
+
+// WithParent nests the list and create operations of the resource under the resource its entities belong to,
+// such as /project/1/issue. Lists are limited to the entities of the parent and created entities are assigned to it,
+// where field is the entity field holding the id of the parent.
+func (this ApiResource) WithParent(parentPath string, field string) ApiResource {
+	this.parent = &parentResource{parentPath, field, nil}
+	return this
+}
+
+// WithParentResolver makes the parent of a nested resource addressable by its key too, such as /issue/GOITS-1/comments.
+// The parent reference in the path is given to resolve instead of being parsed as an id, so it must be used after WithParent.
+func (this ApiResource) WithParentResolver(resolve ParentResolver) ApiResource {
+	if this.parent != nil {
+		this.parent = &parentResource{this.parent.path, this.parent.field, resolve}
+	}
+	return this
+}
+
+// WithAction adds a custom operation under the path of a single resource, such as POST /issue/{id}/transitions/{name}.
+// The path is relative to the resource and name is the operation name used in monitoring.
+func (this ApiResource) WithAction(method string, path string, name string, fn ActionFunc) ApiResource {
+	actions := make([]resourceAction, len(this.actions), len(this.actions)+1)
+	copy(actions, this.actions)
+	this.actions = append(actions, resourceAction{method, path, name, fn})
+	return this
+}
+
+// WithAuthenticated makes the operations, such as create or an action name, callable only by authenticated principals.
+// Without any operation it makes all operations that are not declared as public require authentication.
+func (this ApiResource) WithAuthenticated(operations ...string) ApiResource {
+	this.access = this.access.with(true, operations)
+	return this
+}
+
+// WithPublic makes the operations, such as getAll or getById, callable anonymously.
+// Without any operation it makes all operations that are not declared as authenticated public, which is the default.
+func (this ApiResource) WithPublic(operations ...string) ApiResource {
+	this.access = this.access.with(false, operations)
+	return this
+}
+
+func (this ApiResource) columnMapper() (metadata.ColumnMapper, bool) {
+	if this.ed == nil {
+		return nil, false
+	}
+	return metadata.GetColumnMapper(this.ed)
 }
 
 // Register registers the api resource with routing engine making it available to be used via rest.
@@ -57,6 +165,47 @@ func (this ApiResource) successResponse(w http.ResponseWriter, r *http.Request,
 	})
 }
 
+// createdResponse renders the created resource with the location of it if it is an entity.
+func (this ApiResource) createdResponse(w http.ResponseWriter, r *http.Request, result interface{}) {
+	if e, ok := result.(domain.Entity); ok {
+		w.Header().Set(HDR_Location, fmt.Sprintf("/%s/%d", this.path, e.GetId()))
+	}
+	setETag(w, result)
+	w.WriteHeader(http.StatusCreated)
+	this.successResponse(w, r, result)
+}
+
+// listResponse renders the list result, adding navigation links to the response if the result is a page.
+func (this ApiResource) listResponse(w http.ResponseWriter, r *http.Request, result interface{}) {
+	switch page := result.(type) {
+	case services.Page:
+		w.Header().Set(HDR_Link, pageLinks(r.URL, page))
+	case services.CursorPage:
+		this.cursorPageResponse(w, r, page)
+		return
+	}
+	this.successResponse(w, r, result)
+}
+
+// cursorPageResponse renders the keyset paged result with the encoded cursor of the next page.
+func (this ApiResource) cursorPageResponse(w http.ResponseWriter, r *http.Request, page services.CursorPage) {
+	var next string
+	if page.HasNext() {
+		var err error
+		if next, err = encodeCursor(page.Next); err != nil {
+			this.errorResponse(w, r, "could not list resource", err)
+			return
+		}
+		w.Header().Set(HDR_Link, cursorLink(r.URL, next))
+	}
+
+	this.successResponse(w, r, map[string]interface{}{
+		"items":       page.Items,
+		"size":        page.Size,
+		"next_cursor": next,
+	})
+}
+
 func (this ApiResource) notImplementedResponse(w http.ResponseWriter, r *http.Request) {
 	appErr := commons.AppError{
 		ErrorType: commons.ErrClient,
@@ -72,10 +221,22 @@ func (this ApiResource) notImplementedResponse(w http.ResponseWriter, r *http.Re
 func (this ApiResource) errorResponse(w http.ResponseWriter, r *http.Request, msg string, err error) {
 	code := http.StatusInternalServerError
 	errType := commons.DetermineErrorType(err)
-	if errType == commons.ErrClient || errType == commons.ErrValidation {
+	if errors.Is(err, patching.ErrUnsupportedPatch) {
+		code = http.StatusUnsupportedMediaType
+	} else if errType == commons.ErrClient || errType == commons.ErrValidation {
 		code = http.StatusBadRequest
 	} else if errType == commons.ErrNotFound {
 		code = http.StatusNotFound
+	} else if errType == commons.ErrUnauthorized {
+		code = http.StatusUnauthorized
+	} else if errType == commons.ErrForbidden {
+		code = http.StatusForbidden
+	} else if errType == commons.ErrConflict {
+		code = http.StatusConflict
+	} else if errors.Is(err, errPreconditionRequired) {
+		code = http.StatusPreconditionRequired
+	} else if errType == commons.ErrPrecondition {
+		code = http.StatusPreconditionFailed
 	}
 
 	appErr := commons.AppError{
@@ -84,6 +245,11 @@ func (this ApiResource) errorResponse(w http.ResponseWriter, r *http.Request, ms
 		Cause:     err.Error(),
 	}
 
+	var de commons.DetailedError
+	if errors.As(err, &de) {
+		appErr.Details = de.Details()
+	}
+
 	monitoring.SetContextError(r.Context(), appErr)
 
 	w.WriteHeader(code)
@@ -0,0 +1,56 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var errUnknownCredential = errors.New("unauthorized: credential is not recognized")
+
+// Authenticator checks a kind of credential, such as an api token, and finds the principal it belongs to.
+type Authenticator interface {
+	// Accepts checks if the credential is of the kind the authenticator checks, without validating it.
+	Accepts(credential string) bool
+
+	// Authenticate validates the credential and returns its principal.
+	// Invalid credentials should result in unauthorized errors.
+	Authenticate(ctx context.Context, credential string) (Principal, error)
+}
+
+var authenticators = []Authenticator{jwtAuthenticator{}}
+var authLock sync.RWMutex
+
+var sessionCookie string
+
+// RegisterAuthenticator adds an authenticator for a kind of credential.
+// Authenticators are tried in the order they are registered, after the built in jwt authenticator.
+func RegisterAuthenticator(a Authenticator) {
+	authLock.Lock()
+	defer authLock.Unlock()
+
+	authenticators = append(authenticators, a)
+}
+
+// Authenticate finds the principal of the credential by using the first authenticator that accepts it.
+func Authenticate(ctx context.Context, credential string) (Principal, error) {
+	authLock.RLock()
+	defer authLock.RUnlock()
+
+	for _, a := range authenticators {
+		if a.Accepts(credential) {
+			return a.Authenticate(ctx, credential)
+		}
+	}
+	return Principal{}, errUnknownCredential
+}
+
+// SetSessionCookie sets the name of the cookie the credential is read from when the request has no bearer token.
+func SetSessionCookie(name string) {
+	sessionCookie = name
+}
+
+// SessionCookie returns the name of the cookie carrying the credential, which is empty if credentials are not read from cookies.
+func SessionCookie() string {
+	return sessionCookie
+}
@@ -0,0 +1,5 @@
+// Package security contains the authentication support shared by the api and the business packages.
+//
+// A Principal is the authenticated caller of a request, which is put into the request context by the routing layer.
+// Credentials are checked by registered authenticators, signed jwt tokens are verified by the package itself.
+package security
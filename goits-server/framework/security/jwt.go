@@ -0,0 +1,156 @@
+package security
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MethodJWT is the authentication method of the principals of jwt tokens.
+const MethodJWT = "jwt"
+
+var jwtKey []byte
+
+var errInvalidJWT = errors.New("unauthorized: token is not valid")
+
+// SourceVerifier checks that the credential a jwt token is issued from can still authenticate the principal of the token.
+type SourceVerifier func(ctx context.Context, p Principal) error
+
+var sourceVerifiers = make(map[string]SourceVerifier)
+var sourceLock sync.RWMutex
+
+// jwtHeader is the only header the tokens are signed with, which is also the only one accepted.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func init() {
+	// a random key makes tokens valid only until restart, unless a key is configured
+	jwtKey = make([]byte, sha256.Size)
+	rand.Read(jwtKey)
+}
+
+// SetTokenKey sets the secret that is used to sign the jwt tokens given to clients.
+// All instances serving the same clients should use the same key.
+func SetTokenKey(key string) {
+	if key != "" {
+		jwtKey = []byte(key)
+	}
+}
+
+// RegisterTokenSource allows jwt tokens to be issued from the credentials of the given method.
+// The tokens are only valid as long as the verifier accepts the credential they are issued from,
+// so that ending the credential also ends the tokens issued from it.
+func RegisterTokenSource(method string, verify SourceVerifier) {
+	sourceLock.Lock()
+	defer sourceLock.Unlock()
+
+	sourceVerifiers[method] = verify
+}
+
+// jwtClaims are the claims of the tokens, the registered ones and the ones carrying the rest of the principal.
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+	UserId    uint64   `json:"uid"`
+	Admin     bool     `json:"adm,omitempty"`
+	Scopes    []string `json:"scp"`
+	Source    string   `json:"src"`
+	SourceId  uint64   `json:"sid,omitempty"`
+}
+
+// IssueToken creates a jwt token signed with HS256 which authenticates the principal until it expires.
+// The token refers to the credential the principal is authenticated with, which must stay valid for the token to be accepted.
+func IssueToken(p Principal, now time.Time, ttl time.Duration) (string, error) {
+	claims := jwtClaims{
+		Subject:   p.Subject,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+		UserId:    p.UserId,
+		Admin:     p.Admin,
+		Scopes:    p.Scopes,
+		Source:    p.Method,
+		SourceId:  p.CredentialId,
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + signJWT(signed), nil
+}
+
+// VerifyToken checks the signature and the expiry of a token created by IssueToken and returns its principal.
+// It does not check the credential the token is issued from, which is done when the token authenticates a request.
+func VerifyToken(token string, now time.Time) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return Principal{}, errInvalidJWT
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signJWT(parts[0]+"."+parts[1]))) {
+		return Principal{}, errInvalidJWT
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Principal{}, errInvalidJWT
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Principal{}, errInvalidJWT
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return Principal{}, errors.New("unauthorized: token has expired")
+	}
+
+	return Principal{
+		UserId:       claims.UserId,
+		Subject:      claims.Subject,
+		Admin:        claims.Admin,
+		Scopes:       claims.Scopes,
+		Method:       MethodJWT,
+		CredentialId: claims.SourceId,
+		Source:       claims.Source,
+	}, nil
+}
+
+func signJWT(signed string) string {
+	mac := hmac.New(sha256.New, jwtKey)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// jwtAuthenticator verifies the credentials that look like jwt tokens, which are three dot separated parts.
+type jwtAuthenticator struct{}
+
+func (this jwtAuthenticator) Accepts(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+// Authenticate verifies the token, then checks that the credential it is issued from is still valid.
+// Tokens issued from credentials of an unregistered method are not accepted.
+func (this jwtAuthenticator) Authenticate(ctx context.Context, credential string) (Principal, error) {
+	p, err := VerifyToken(credential, time.Now())
+	if err != nil {
+		return Principal{}, err
+	}
+
+	sourceLock.RLock()
+	verify, ok := sourceVerifiers[p.Source]
+	sourceLock.RUnlock()
+	if !ok {
+		return Principal{}, errInvalidJWT
+	}
+	if err := verify(ctx, p); err != nil {
+		return Principal{}, err
+	}
+	return p, nil
+}
@@ -0,0 +1,127 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cpekyaman/goits/framework/commons"
+)
+
+var now = time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+
+func TestVerifyToken_Success(t *testing.T) {
+	// given
+	p := Principal{UserId: 3, Subject: "ann", Scopes: []string{ScopeRead}}
+	token, err := IssueToken(p, now, time.Hour)
+	assert.Nil(t, err, "could not issue token")
+
+	// when
+	verified, err := VerifyToken(token, now.Add(59*time.Minute))
+
+	// then
+	assert.Nil(t, err, "token should be valid")
+	assert.Equal(t, uint64(3), verified.UserId, "user id is not correct")
+	assert.Equal(t, "ann", verified.Subject, "subject is not correct")
+	assert.Equal(t, []string{ScopeRead}, verified.Scopes, "scopes are not correct")
+	assert.Equal(t, MethodJWT, verified.Method, "method is not correct")
+}
+
+func TestVerifyToken_Expired_Error(t *testing.T) {
+	// given
+	token, _ := IssueToken(Principal{UserId: 3, Subject: "ann"}, now, time.Hour)
+
+	// when
+	_, err := VerifyToken(token, now.Add(time.Hour))
+
+	// then
+	assert.NotNil(t, err, "expired token should not be valid")
+	assert.True(t, strings.HasPrefix(err.Error(), "unauthorized:"), "should be an unauthorized error")
+}
+
+func TestVerifyToken_Tampered_Error(t *testing.T) {
+	// given
+	token, _ := IssueToken(Principal{UserId: 3, Subject: "ann", Scopes: []string{ScopeRead}}, now, time.Hour)
+	forged, _ := IssueToken(Principal{UserId: 1, Subject: "admin", Scopes: AllScopes}, now, time.Hour)
+
+	parts := strings.Split(token, ".")
+	forgedParts := strings.Split(forged, ".")
+
+	// when
+	_, err := VerifyToken(parts[0]+"."+forgedParts[1]+"."+parts[2], now)
+
+	// then
+	assert.NotNil(t, err, "token with changed claims should not be valid")
+}
+
+func TestJWTAuthenticator_SourceEnded_Error(t *testing.T) {
+	// given
+	RegisterTokenSource("ended", func(ctx context.Context, p Principal) error {
+		return errors.New("unauthorized: credential has ended")
+	})
+	token, _ := IssueToken(Principal{UserId: 3, Subject: "ann", Method: "ended", CredentialId: 7}, time.Now(), time.Hour)
+
+	// when
+	_, err := jwtAuthenticator{}.Authenticate(context.Background(), token)
+
+	// then
+	assert.NotNil(t, err, "token of an ended credential should not be valid")
+	assert.True(t, strings.HasPrefix(err.Error(), "unauthorized:"), "should be an unauthorized error")
+}
+
+func TestJWTAuthenticator_UnknownSource_Error(t *testing.T) {
+	// given
+	token, _ := IssueToken(Principal{UserId: 3, Subject: "ann", Method: "unknown"}, time.Now(), time.Hour)
+
+	// when
+	_, err := jwtAuthenticator{}.Authenticate(context.Background(), token)
+
+	// then
+	assert.Equal(t, errInvalidJWT, err, "token of an unregistered source should not be valid")
+}
+
+func TestJWTAuthenticator_Success(t *testing.T) {
+	// given
+	var verified Principal
+	RegisterTokenSource("valid", func(ctx context.Context, p Principal) error {
+		verified = p
+		return nil
+	})
+	token, _ := IssueToken(Principal{UserId: 3, Subject: "ann", Method: "valid", CredentialId: 7}, time.Now(), time.Hour)
+
+	// when
+	p, err := jwtAuthenticator{}.Authenticate(context.Background(), token)
+
+	// then
+	assert.Nil(t, err, "token should be valid")
+	assert.Equal(t, MethodJWT, p.Method, "method is not correct")
+	assert.Equal(t, "valid", verified.Source, "source should be verified")
+	assert.Equal(t, uint64(7), verified.CredentialId, "source credential is not correct")
+}
+
+func TestAuthenticate_Unknown_Error(t *testing.T) {
+	// when
+	_, err := Authenticate(context.Background(), "not-a-jwt")
+
+	// then
+	assert.NotNil(t, err, "credential no authenticator accepts should not be valid")
+	assert.True(t, strings.HasPrefix(err.Error(), "unauthorized:"), "should be an unauthorized error")
+}
+
+func TestWithPrincipal_SetsActor(t *testing.T) {
+	// when
+	ctx := WithPrincipal(context.Background(), Principal{UserId: 3, Subject: "ann"})
+
+	// then
+	p, ok := PrincipalFromContext(ctx)
+	assert.True(t, ok, "principal should be in context")
+	assert.Equal(t, "ann", p.Subject, "principal is not correct")
+
+	actor, ok := commons.ActorFromContext(ctx)
+	assert.True(t, ok, "actor should be in context")
+	assert.Equal(t, "ann", actor, "actor should be the subject")
+}
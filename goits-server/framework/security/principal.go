@@ -0,0 +1,56 @@
+package security
+
+import (
+	"context"
+
+	"github.com/cpekyaman/goits/framework/commons"
+)
+
+const (
+	// ScopeRead allows reading resources.
+	ScopeRead = "read"
+	// ScopeWrite allows creating, changing and deleting resources.
+	ScopeWrite = "write"
+)
+
+// AllScopes are the scopes of a principal that is not limited by its credential, such as a login session.
+var AllScopes = []string{ScopeRead, ScopeWrite}
+
+// Principal is the authenticated caller of a request.
+// Scopes limit what the caller can do, which depend on the credential it is authenticated with.
+type Principal struct {
+	UserId  uint64   `json:"userId"`
+	Subject string   `json:"subject"`
+	Admin   bool     `json:"admin"`
+	Scopes  []string `json:"scopes"`
+	// Method is the kind of the credential, such as jwt or token.
+	Method string `json:"method"`
+	// CredentialId is the id of the credential the principal is authenticated with, if the credential is kept with an id.
+	// For jwt tokens, it is the id of the credential the token is issued from.
+	CredentialId uint64 `json:"-"`
+	// Source is the method of the credential a jwt token is issued from, it is empty for the other methods.
+	Source string `json:"-"`
+}
+
+// HasScope checks if the principal is granted the scope.
+func (this Principal) HasScope(scope string) bool {
+	for _, s := range this.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalCtxKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries the principal, whose subject is also the actor of the operations.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return commons.WithActor(context.WithValue(ctx, principalCtxKey{}, p), p.Subject)
+}
+
+// PrincipalFromContext returns the authenticated caller, if ctx carries one.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalCtxKey{}).(Principal)
+	return p, ok
+}
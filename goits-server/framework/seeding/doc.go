@@ -0,0 +1,3 @@
+// Package seeding loads fixture files into the database through the repositories of entities.
+// Rows are upserted by the unique keys of entities, so seeding the same files again updates the existing rows.
+package seeding
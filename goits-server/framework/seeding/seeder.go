@@ -0,0 +1,250 @@
+package seeding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"gopkg.in/yaml.v2"
+)
+
+// refPrefix marks a value referring to the id of an entity seeded before, as ref:<entity>/<unique key value>.
+const refPrefix = "ref:"
+
+// Upserter is the part of a repository that seeding needs, which inserts or updates an entity by its unique key.
+type Upserter interface {
+	Upsert(ctx context.Context, entity domain.Entity) error
+}
+
+var upserters map[string]func() Upserter
+
+func init() {
+	upserters = make(map[string]func() Upserter)
+}
+
+// Register registers the factory of the repository that seeds the entity with the given fully qualified type name.
+// The repository is created only when seeding, after the db is initialized.
+func Register(name string, factory func() Upserter) {
+	upserters[name] = factory
+}
+
+// Fixture is the data of a single entity type in a seed file.
+type Fixture struct {
+	Entity string                   `json:"entity"`
+	Rows   []map[string]interface{} `json:"rows"`
+}
+
+// Result is the number of seeded rows by entity name.
+type Result map[string]int
+
+// Seeder loads the fixtures of seed sets and upserts them through the repositories of entities.
+// Each seed set is a directory with one <module>.seed.yaml or <module>.seed.json file per module.
+type Seeder struct {
+	dir     string
+	ids     map[string]map[string]uint64
+	results Result
+}
+
+// NewSeeder creates a seeder that reads the seed sets under the given directory.
+func NewSeeder(dir string) *Seeder {
+	return &Seeder{dir: dir, ids: make(map[string]map[string]uint64), results: make(Result)}
+}
+
+// Seed upserts the fixtures of the given sets in order, limited to the given modules unless no module is given.
+// The files of a set are seeded in the order of their names, and the fixtures of a file in the order they are listed,
+// so that rows can refer to the ids of the ones seeded before them.
+func (this *Seeder) Seed(ctx context.Context, sets []string, modules []string) (Result, error) {
+	for _, set := range sets {
+		files, err := this.seedFiles(set, modules)
+		if err != nil {
+			return this.results, err
+		}
+
+		for _, f := range files {
+			fixtures, err := readFixtures(f)
+			if err != nil {
+				return this.results, err
+			}
+			for _, fx := range fixtures {
+				if err := this.seedFixture(ctx, fx); err != nil {
+					return this.results, fmt.Errorf("could not seed %s from %s: %v", fx.Entity, f, err)
+				}
+			}
+		}
+	}
+	return this.results, nil
+}
+
+func (this *Seeder) seedFiles(set string, modules []string) ([]string, error) {
+	var files []string
+	for _, ext := range []string{"yaml", "json"} {
+		found, err := filepath.Glob(filepath.Join(this.dir, set, "*.seed."+ext))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	sort.Strings(files)
+
+	if len(modules) == 0 {
+		return files, nil
+	}
+
+	var selected []string
+	for _, f := range files {
+		module := strings.SplitN(filepath.Base(f), ".", 2)[0]
+		for _, m := range modules {
+			if m == module {
+				selected = append(selected, f)
+			}
+		}
+	}
+	return selected, nil
+}
+
+func (this *Seeder) seedFixture(ctx context.Context, fx Fixture) error {
+	factory, found := upserters[fx.Entity]
+	if !found {
+		return fmt.Errorf("entity has no registered repository")
+	}
+	ed := domain.EntityDefByName(fx.Entity)
+	if ed == nil || len(ed.UniqueKey()) == 0 {
+		return fmt.Errorf("entity has no unique key to be seeded by")
+	}
+
+	repo := factory()
+	for _, row := range fx.Rows {
+		entity, err := this.newEntity(fx.Entity, row)
+		if err != nil {
+			return err
+		}
+		if err := repo.Upsert(ctx, entity); err != nil {
+			return err
+		}
+
+		if key, found := uniqueKeyOf(ed, entity); found {
+			if this.ids[fx.Entity] == nil {
+				this.ids[fx.Entity] = make(map[string]uint64)
+			}
+			this.ids[fx.Entity][key] = entity.GetId()
+		}
+		this.results[fx.Entity]++
+	}
+	return nil
+}
+
+// newEntity creates the entity from the json representation of a row, after resolving the references in the row.
+func (this *Seeder) newEntity(name string, row map[string]interface{}) (domain.Entity, error) {
+	entity, ok := domain.NewEntityOf(name).(domain.Entity)
+	if !ok {
+		return nil, fmt.Errorf("entity type is not registered")
+	}
+
+	resolved := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		s, isString := v.(string)
+		if !isString || !strings.HasPrefix(s, refPrefix) {
+			resolved[k] = v
+			continue
+		}
+
+		id, err := this.resolve(strings.TrimPrefix(s, refPrefix))
+		if err != nil {
+			return nil, err
+		}
+		resolved[k] = id
+	}
+
+	content, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// resolve finds the id of an entity seeded before by a reference in <entity>/<unique key value> form.
+func (this *Seeder) resolve(ref string) (uint64, error) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("reference %s is not in <entity>/<key> form", ref)
+	}
+
+	id, found := this.ids[parts[0]][parts[1]]
+	if !found {
+		return 0, fmt.Errorf("reference %s is not seeded before", ref)
+	}
+	return id, nil
+}
+
+// uniqueKeyOf renders the values of the unique key of the entity, joined by commas if the key has multiple columns.
+func uniqueKeyOf(ed metadata.EntityDef, entity domain.Entity) (string, bool) {
+	cm, found := metadata.GetColumnMapper(ed)
+	if !found {
+		return "", false
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	values := make([]string, 0, len(ed.UniqueKey()))
+	for _, col := range ed.UniqueKey() {
+		for _, f := range cm.Fields() {
+			if cm.Column(f) == col {
+				values = append(values, fmt.Sprint(v.FieldByName(f).Interface()))
+			}
+		}
+	}
+	return strings.Join(values, ","), len(values) == len(ed.UniqueKey())
+}
+
+// readFixtures reads the fixtures of a seed file, which is either yaml or json.
+func readFixtures(file string) ([]Fixture, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// yaml is converted to json, so that entities are bound by their json names in both formats
+	if strings.HasSuffix(file, ".yaml") {
+		var raw interface{}
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %v", file, err)
+		}
+		if content, err = json.Marshal(jsonCompatible(raw)); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %v", file, err)
+		}
+	}
+
+	var fixtures []Fixture
+	if err := json.Unmarshal(content, &fixtures); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", file, err)
+	}
+	return fixtures, nil
+}
+
+// jsonCompatible converts the maps decoded from yaml, which can have keys of any type, into maps with string keys.
+func jsonCompatible(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[fmt.Sprint(k)] = jsonCompatible(e)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, e := range t {
+			l[i] = jsonCompatible(e)
+		}
+		return l
+	}
+	return v
+}
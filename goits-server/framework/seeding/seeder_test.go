@@ -0,0 +1,123 @@
+package seeding
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type SeedTestKind struct {
+	domain.DomainEntity
+	Name string `json:"name" db:"name"`
+}
+
+type SeedTestItem struct {
+	domain.DomainEntity
+	Name string `json:"name" db:"name"`
+	Kind uint64 `json:"kind" db:"kind"`
+}
+
+func init() {
+	kindED := metadata.WithUniqueKey(metadata.NewEntityDef("seeding.SeedTestKind", "config", "kind", "id", "name asc", false), "name")
+	itemED := metadata.WithUniqueKey(metadata.NewEntityDef("seeding.SeedTestItem", "data", "item", "id", "name asc", false), "name")
+	domain.RegisterEntityDef(kindED)
+	domain.RegisterEntityDef(itemED)
+	domain.RegisterEntityType(kindED.Name(), &SeedTestKind{})
+	domain.RegisterEntityType(itemED.Name(), &SeedTestItem{})
+
+	Register(kindED.Name(), func() Upserter { return repository.NewRepository(kindED, &SeedTestKind{}) })
+	Register(itemED.Name(), func() Upserter { return repository.NewRepository(itemED, &SeedTestItem{}) })
+}
+
+func newSeedDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "seed")
+	assert.Nil(t, err, "could not create seed dir")
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755), "could not create seed set")
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644), "could not write seed file")
+	}
+	return dir
+}
+
+func newSeedDB(t *testing.T) sqlmock.Sqlmock {
+	mockDB, mock, err := sqlmock.New()
+	assert.Nil(t, err, "could not create mock db")
+	db.WithDB(mockDB, "sqlmock")
+	t.Cleanup(func() {
+		mockDB.Close()
+	})
+	return mock
+}
+
+func TestSeed_ResolvesReferences(t *testing.T) {
+	// given
+	dir := newSeedDir(t, map[string]string{
+		"reference/test.seed.yaml": `
+- entity: "seeding.SeedTestKind"
+  rows:
+    - name: "first"
+    - name: "second"`,
+		"demo/test.seed.json": `[{"entity": "seeding.SeedTestItem", "rows": [{"name": "item", "kind": "ref:seeding.SeedTestKind/second"}]}]`,
+	})
+	mock := newSeedDB(t)
+	kindUpsert := regexp.QuoteMeta("insert into config.kind(name) values(?) on conflict (name) do update set name=excluded.name returning id")
+	mock.ExpectQuery(kindUpsert).WithArgs("first").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(kindUpsert).WithArgs("second").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta("insert into data.item(kind, name) values(?, ?) on conflict (name) do update set kind=excluded.kind returning id")).
+		WithArgs(2, "item").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+
+	// when
+	result, err := NewSeeder(dir).Seed(context.Background(), []string{"reference", "demo"}, nil)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "fixtures are not upserted")
+	assert.Equal(t, Result{"seeding.SeedTestKind": 2, "seeding.SeedTestItem": 1}, result, "result is not correct")
+}
+
+func TestSeed_OnlyGivenModules(t *testing.T) {
+	// given
+	dir := newSeedDir(t, map[string]string{
+		"reference/other.seed.yaml": `[{"entity": "seeding.SeedTestKind", "rows": [{"name": "other"}]}]`,
+	})
+	mock := newSeedDB(t)
+
+	// when
+	result, err := NewSeeder(dir).Seed(context.Background(), []string{"reference"}, []string{"test"})
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "other modules should not be seeded")
+	assert.Empty(t, result, "nothing should be seeded")
+}
+
+func TestSeed_UnknownReference_Error(t *testing.T) {
+	// given
+	dir := newSeedDir(t, map[string]string{
+		"demo/test.seed.yaml": `[{"entity": "seeding.SeedTestItem", "rows": [{"name": "item", "kind": "ref:seeding.SeedTestKind/missing"}]}]`,
+	})
+	newSeedDB(t)
+
+	// when
+	_, err := NewSeeder(dir).Seed(context.Background(), []string{"demo"}, nil)
+
+	// then
+	assert.NotNil(t, err, "error expected")
+	assert.Contains(t, err.Error(), "reference seeding.SeedTestKind/missing is not seeded before", "error is not correct")
+}
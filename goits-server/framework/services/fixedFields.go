@@ -0,0 +1,69 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/cpekyaman/goits/framework/patching"
+)
+
+// FixedFields guards the fields of an entity that are set by the service and can not be changed by an update or a patch.
+type FixedFields struct {
+	// Entity names the entity in the errors, like "an issue".
+	Entity string
+	// Fields are the json names of the fixed fields.
+	Fields []string
+	// Values gets the values of the fixed fields of the target in their json form, numbers being float64.
+	Values func(target interface{}) map[string]interface{}
+}
+
+// Binder wraps the binding so that it fails if it changes any of the fixed fields of the target.
+func (this FixedFields) Binder(binding ObjectBinder) ObjectBinder {
+	return ObjectBinderFunc(func(target interface{}) error {
+		before := this.Values(target)
+		if err := binding.BindTo(target); err != nil {
+			return err
+		}
+		return this.verify(before, this.Values(target))
+	})
+}
+
+// Patch wraps the patch so that it fails if it changes any of the fixed fields of the document.
+func (this FixedFields) Patch(patch patching.Patch) patching.Patch {
+	return fixedPatch{Patch: patch, fixed: this}
+}
+
+func (this FixedFields) verify(before map[string]interface{}, after map[string]interface{}) error {
+	for _, f := range this.Fields {
+		if !reflect.DeepEqual(before[f], after[f]) {
+			return fmt.Errorf("validation: %s of %s can not be changed", f, this.Entity)
+		}
+	}
+	return nil
+}
+
+// fixedPatch is the patch that fails if the wrapped patch changes any of the fixed fields.
+type fixedPatch struct {
+	patching.Patch
+	fixed FixedFields
+}
+
+func (this fixedPatch) Apply(doc []byte) ([]byte, error) {
+	patched, err := this.Patch.Apply(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var before, after map[string]interface{}
+	if err := json.Unmarshal(doc, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return nil, patching.PatchError{Op: "apply", Reason: "patched document is not an object"}
+	}
+	if err := this.fixed.verify(before, after); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
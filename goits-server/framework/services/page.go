@@ -0,0 +1,72 @@
+package services
+
+import (
+	"reflect"
+
+	"github.com/cpekyaman/goits/framework/orm/query"
+)
+
+// Page is a single page of a list result along with the information required to navigate to other pages.
+type Page struct {
+	Items      interface{} `json:"items"`
+	Page       uint64      `json:"page"`
+	Size       uint        `json:"size"`
+	Total      uint64      `json:"total"`
+	TotalPages uint64      `json:"totalPages"`
+}
+
+// derefItems returns the result slice if items is a pointer to it.
+func derefItems(items interface{}) interface{} {
+	v := reflect.ValueOf(items)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		return v.Elem().Interface()
+	}
+	return items
+}
+
+// NewPage creates the page of items that is fetched by using limit and offset out of total items.
+// Items can be given as a pointer to the result slice, in which case the slice itself is used.
+func NewPage(items interface{}, limit uint, offset uint64, total uint64) Page {
+	p := Page{Items: derefItems(items), Size: limit, Total: total, Page: 1}
+	if limit > 0 {
+		p.Page = offset/uint64(limit) + 1
+		p.TotalPages = (total + uint64(limit) - 1) / uint64(limit)
+	}
+	return p
+}
+
+// HasNext checks if there is a page after this one.
+func (this Page) HasNext() bool {
+	return this.Page < this.TotalPages
+}
+
+// HasPrev checks if there is a page before this one.
+func (this Page) HasPrev() bool {
+	return this.Page > 1
+}
+
+// LastPage returns the number of the last page, which is the first page if there are no items at all.
+func (this Page) LastPage() uint64 {
+	if this.TotalPages == 0 {
+		return 1
+	}
+	return this.TotalPages
+}
+
+// CursorPage is a single page of a keyset paged list result.
+type CursorPage struct {
+	Items interface{}
+	Size  uint
+	Next  query.Cursor
+}
+
+// NewCursorPage creates the page of items that is fetched by using a cursor, where next points to the following page.
+// Items can be given as a pointer to the result slice, in which case the slice itself is used.
+func NewCursorPage(items interface{}, limit uint, next query.Cursor) CursorPage {
+	return CursorPage{derefItems(items), limit, next}
+}
+
+// HasNext checks if there is a page after this one.
+func (this CursorPage) HasNext() bool {
+	return !this.Next.IsZero()
+}
@@ -5,7 +5,10 @@ import (
 	"context"
 
 	"github.com/cpekyaman/goits/framework/caching"
+	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/query"
 	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/patching"
 	"github.com/cpekyaman/goits/framework/validation"
 )
 
@@ -13,14 +16,18 @@ import (
 type SearcherService interface {
 	FindOne(ctx context.Context, attr string, attrValue interface{}) (interface{}, error)
 	FindAll(ctx context.Context, attrs map[string]interface{}) (interface{}, error)
-	FindAllPaged(ctx context.Context, attrs map[string]interface{}, limit uint, offset uint64) (interface{}, error)
+	FindAllPaged(ctx context.Context, attrs map[string]interface{}, limit uint, offset uint64) (Page, error)
+	FindAllByCriteria(ctx context.Context, c query.Criteria, opts ...query.Option) (interface{}, error)
+	FindAllByCriteriaPaged(ctx context.Context, c query.Criteria, limit uint, offset uint64, opts ...query.Option) (Page, error)
+	FindAllByCursor(ctx context.Context, c query.Criteria, after query.Cursor, limit uint, opts ...query.Option) (CursorPage, error)
 }
 
 // ReaderService defines methods that are about fetching existing data.
+// Options can be used to include the relations of the fetched entities.
 type ReaderService interface {
-	GetAll(ctx context.Context) (interface{}, error)
-	GetAllPaged(ctx context.Context, limit uint, offset uint64) (interface{}, error)
-	GetById(ctx context.Context, id uint64) (interface{}, error)
+	GetAll(ctx context.Context, opts ...query.Option) (interface{}, error)
+	GetAllPaged(ctx context.Context, limit uint, offset uint64, opts ...query.Option) (Page, error)
+	GetById(ctx context.Context, id uint64, opts ...query.Option) (interface{}, error)
 }
 
 // ObjectBinder is used to bind input data (e.g. from an http request) to a target entity.
@@ -35,14 +42,19 @@ func (obf ObjectBinderFunc) BindTo(target interface{}) error {
 	return obf(target)
 }
 
-// CreatorService defines the method to create a new entity.
+// CreatorService defines the method to create a new entity, which returns the created entity.
 type CreatorService interface {
-	Create(ctx context.Context, binding ObjectBinder) error
+	Create(ctx context.Context, binding ObjectBinder) (interface{}, error)
 }
 
-// UpdaterService defines the method to update an existing entity.
+// UpdaterService defines the method to update an existing entity, which returns the updated entity.
 type UpdaterService interface {
-	Update(ctx context.Context, id uint64, binding ObjectBinder) error
+	Update(ctx context.Context, id uint64, binding ObjectBinder) (interface{}, error)
+}
+
+// PatcherService defines the method to partially update an existing entity, which returns the updated entity.
+type PatcherService interface {
+	Patch(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error)
 }
 
 // DeleterService defines the method to delete an existing entity.
@@ -50,10 +62,21 @@ type DeleterService interface {
 	Delete(ctx context.Context, id uint64) error
 }
 
-// WriterService combines create and update methods into a single interface.
+// VersionedDeleterService defines the method to delete an existing entity only if it still has the expected version.
+type VersionedDeleterService interface {
+	DeleteVersion(ctx context.Context, id uint64, version uint32) error
+}
+
+// RestorerService defines the method to restore a soft deleted entity.
+type RestorerService interface {
+	Restore(ctx context.Context, id uint64) error
+}
+
+// WriterService combines create, update and patch methods into a single interface.
 type WriterService interface {
 	CreatorService
 	UpdaterService
+	PatcherService
 }
 
 // ReaderWriterService combines read and write operations (except delete) into a single interface.
@@ -67,9 +90,17 @@ type CRUDService interface {
 	ReaderWriterService
 	SearcherService
 	DeleterService
+	VersionedDeleterService
+	RestorerService
 }
 
 // NewCRUDService creates a new CRUDServiceImpl that uses the provided repository for db operations.
+// Write operations of the created service run inside transactions of the default TxManager.
 func NewCRUDService(repo repository.Repository, cache caching.Cache, vp validation.ValidationProvider) CRUDServiceImpl {
-	return CRUDServiceImpl{repo, cache, vp}
+	return NewTxCRUDService(repo, cache, vp, db.NewTxManager())
+}
+
+// NewTxCRUDService creates a new CRUDServiceImpl that runs its write operations within the given TxManager.
+func NewTxCRUDService(repo repository.Repository, cache caching.Cache, vp validation.ValidationProvider, txm db.TxManager) CRUDServiceImpl {
+	return CRUDServiceImpl{repo, cache, vp, txm}
 }
@@ -4,8 +4,11 @@ import (
 	"context"
 
 	"github.com/cpekyaman/goits/framework/caching"
-	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/orm/db"
 	"github.com/cpekyaman/goits/framework/orm/domain"
+	"github.com/cpekyaman/goits/framework/orm/query"
+	"github.com/cpekyaman/goits/framework/orm/repository"
+	"github.com/cpekyaman/goits/framework/patching"
 	"github.com/cpekyaman/goits/framework/validation"
 )
 
@@ -14,43 +17,167 @@ type CRUDServiceImpl struct {
 	crudRepo repository.Repository
 	cache    caching.Cache
 	vp       validation.ValidationProvider
+	txm      db.TxManager
 }
 
 func (this CRUDServiceImpl) Cache() caching.Cache {
 	return this.cache
 }
 
+// TxManager returns the transaction manager that the default operations run within.
+// Services can use it to run their own multi step operations atomically.
+func (this CRUDServiceImpl) TxManager() db.TxManager {
+	return this.txm
+}
+
+// GetAllPaged loads the requested page of all entities into dest and returns it along with the total count.
+func (this CRUDServiceImpl) GetAllPaged(ctx context.Context, dest interface{}, limit uint, offset uint64, opts ...query.Option) (Page, error) {
+	if err := this.crudRepo.FindAllPaged(ctx, dest, limit, offset, opts...); err != nil {
+		return Page{}, err
+	}
+
+	total, err := this.crudRepo.CountByCriteria(ctx, nil, opts...)
+	if err != nil {
+		return Page{}, err
+	}
+	return NewPage(dest, limit, offset, total), nil
+}
+
+// FindAllPaged loads the requested page of entities matching attrs into dest and returns it along with the total count.
+func (this CRUDServiceImpl) FindAllPaged(ctx context.Context, dest interface{}, attrs map[string]interface{}, limit uint, offset uint64) (Page, error) {
+	return this.FindAllByCriteriaPaged(ctx, dest, query.AttributesCriteria(attrs), limit, offset)
+}
+
+// FindAllByCriteriaPaged loads the requested page of entities matching c into dest and returns it along with the total count.
+func (this CRUDServiceImpl) FindAllByCriteriaPaged(ctx context.Context, dest interface{}, c query.Criteria, limit uint, offset uint64, opts ...query.Option) (Page, error) {
+	if err := this.crudRepo.FindAllByCriteriaPaged(ctx, dest, c, limit, offset, opts...); err != nil {
+		return Page{}, err
+	}
+
+	total, err := this.crudRepo.CountByCriteria(ctx, c, opts...)
+	if err != nil {
+		return Page{}, err
+	}
+	return NewPage(dest, limit, offset, total), nil
+}
+
+// FindAllByCursor loads up to limit entities matching c that come after the cursor into dest and returns them as a page.
+func (this CRUDServiceImpl) FindAllByCursor(ctx context.Context, dest interface{}, c query.Criteria, after query.Cursor, limit uint, opts ...query.Option) (CursorPage, error) {
+	next, err := this.crudRepo.FindAllByCursor(ctx, dest, c, after, limit, opts...)
+	if err != nil {
+		return CursorPage{}, err
+	}
+	return NewCursorPage(dest, limit, next), nil
+}
+
 // Create binds input data to target entity by using provided binding, performs validations and saves the new entity.
-func (this CRUDServiceImpl) Create(ctx context.Context, binding ObjectBinder, fullTypeName string, target domain.Entity) error {
+// The saved target is returned with its db generated values.
+func (this CRUDServiceImpl) Create(ctx context.Context, binding ObjectBinder, fullTypeName string, target domain.Entity) (domain.Entity, error) {
 	err := binding.BindTo(target)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := this.vp.ValidateStruct(fullTypeName, target); err != nil {
-		return err
+		return nil, err
 	}
 
-	return this.crudRepo.Save(ctx, target)
+	err = this.txm.WithinTx(ctx, func(ctx context.Context) error {
+		return this.crudRepo.Save(ctx, target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
 }
 
-// Update binds input data to target entity by using provided binding, performs validations and saves the updated entity.
-func (this CRUDServiceImpl) Update(ctx context.Context, id uint64, binding ObjectBinder, fullTypeName string, target domain.Entity) error {
-	err := this.crudRepo.FindOneById(ctx, target, id)
+// Update binds input data to target entity by using provided binding, performs validations and saves the changed fields.
+// The saved target is returned with its db generated values.
+func (this CRUDServiceImpl) Update(ctx context.Context, id uint64, binding ObjectBinder, fullTypeName string, target domain.Entity) (domain.Entity, error) {
+	err := this.txm.WithinTx(ctx, func(ctx context.Context) error {
+		err := this.crudRepo.FindOneById(ctx, target, id)
+		if err != nil {
+			return err
+		}
+		snapshot := domain.Snapshot(target)
+
+		err = binding.BindTo(target)
+		if err != nil {
+			return err
+		}
+		// the request can not move the entity to another row
+		target.SetId(id)
+
+		if err := this.vp.ValidateStruct(fullTypeName, target); err != nil {
+			return err
+		}
+
+		return this.crudRepo.SaveChanges(ctx, target, snapshot)
+	})
+
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = binding.BindTo(target)
+	if this.cache != nil {
+		this.cache.Invalidate(caching.IdToKey(id))
+	}
+	return target, nil
+}
+
+// Patch applies the patch to the target entity loaded by id, performs validations and saves the changed fields.
+// The saved target is returned with its db generated values.
+func (this CRUDServiceImpl) Patch(ctx context.Context, id uint64, patch patching.Patch, fullTypeName string, target domain.Entity) (domain.Entity, error) {
+	err := this.txm.WithinTx(ctx, func(ctx context.Context) error {
+		err := this.crudRepo.FindOneById(ctx, target, id)
+		if err != nil {
+			return err
+		}
+
+		snapshot := domain.Snapshot(target)
+
+		if err := patching.ApplyTo(patch, target); err != nil {
+			return err
+		}
+		// the patch can not move the entity to another row
+		target.SetId(id)
+
+		if err := this.vp.ValidateStruct(fullTypeName, target); err != nil {
+			return err
+		}
+
+		return this.crudRepo.SaveChanges(ctx, target, snapshot)
+	})
+
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := this.vp.ValidateStruct(fullTypeName, target); err != nil {
-		return err
+	if this.cache != nil {
+		this.cache.Invalidate(caching.IdToKey(id))
+	}
+	return target, nil
+}
+
+// GetById loads the entity with the given id into dest through the cache, unless relations are to be included.
+// Entities with relations are not cached, since the cache is not invalidated when their related entities change.
+func (this CRUDServiceImpl) GetById(ctx context.Context, dest interface{}, id uint64, opts ...query.Option) (interface{}, error) {
+	load := func() (interface{}, error) {
+		err := this.crudRepo.FindOneById(ctx, dest, id, opts...)
+		return dest, err
 	}
 
-	err = this.crudRepo.Save(ctx, target)
+	if this.cache == nil || len(query.NewOptions(opts...).Include) > 0 {
+		return load()
+	}
+	return this.cache.GetOrCompute(caching.IdToKey(id), load)
+}
+
+// Restore brings back the soft deleted entity represented by the given id.
+func (this CRUDServiceImpl) Restore(ctx context.Context, id uint64) error {
+	err := this.txm.WithinTx(ctx, func(ctx context.Context) error {
+		return this.crudRepo.Restore(ctx, id)
+	})
 	if err == nil && this.cache != nil {
 		this.cache.Invalidate(caching.IdToKey(id))
 	}
@@ -59,9 +186,23 @@ func (this CRUDServiceImpl) Update(ctx context.Context, id uint64, binding Objec
 
 // Delete simply deletes the entity represented by the given id.
 func (this CRUDServiceImpl) Delete(ctx context.Context, id uint64) error {
-	err := this.crudRepo.Delete(ctx, id)
+	err := this.txm.WithinTx(ctx, func(ctx context.Context) error {
+		return this.crudRepo.Delete(ctx, id)
+	})
 	if err == nil && this.cache != nil {
 		this.cache.Invalidate(caching.IdToKey(id))
 	}
 	return err
-}
\ No newline at end of file
+}
+
+// DeleteVersion deletes the entity by a statement conditioned on its version,
+// so that it is not deleted if it is modified since the caller has seen it.
+func (this CRUDServiceImpl) DeleteVersion(ctx context.Context, id uint64, version uint32) error {
+	err := this.txm.WithinTx(ctx, func(ctx context.Context) error {
+		return this.crudRepo.DeleteVersion(ctx, id, version)
+	})
+	if err == nil && this.cache != nil {
+		this.cache.Invalidate(caching.IdToKey(id))
+	}
+	return err
+}
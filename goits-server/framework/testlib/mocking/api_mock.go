@@ -5,6 +5,7 @@
 package mocking
 
 import (
+	patching "github.com/cpekyaman/goits/framework/patching"
 	services "github.com/cpekyaman/goits/framework/services"
 	gomock "github.com/golang/mock/gomock"
 	http "net/http"
@@ -48,6 +49,35 @@ func (mr *MockRequestBinderMockRecorder) PathParam(r, key interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PathParam", reflect.TypeOf((*MockRequestBinder)(nil).PathParam), r, key)
 }
 
+// IdPathParam mocks base method
+func (m *MockRequestBinder) IdPathParam(r *http.Request, key string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdPathParam", r, key)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IdPathParam indicates an expected call of IdPathParam
+func (mr *MockRequestBinderMockRecorder) IdPathParam(r, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdPathParam", reflect.TypeOf((*MockRequestBinder)(nil).IdPathParam), r, key)
+}
+
+// Header mocks base method
+func (m *MockRequestBinder) Header(r *http.Request, key string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header", r, key)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Header indicates an expected call of Header
+func (mr *MockRequestBinderMockRecorder) Header(r, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockRequestBinder)(nil).Header), r, key)
+}
+
 // BindFunc mocks base method
 func (m *MockRequestBinder) BindFunc(r *http.Request) services.ObjectBinder {
 	m.ctrl.T.Helper()
@@ -62,6 +92,21 @@ func (mr *MockRequestBinderMockRecorder) BindFunc(r interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindFunc", reflect.TypeOf((*MockRequestBinder)(nil).BindFunc), r)
 }
 
+// BindPatch mocks base method
+func (m *MockRequestBinder) BindPatch(r *http.Request) (patching.Patch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindPatch", r)
+	ret0, _ := ret[0].(patching.Patch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BindPatch indicates an expected call of BindPatch
+func (mr *MockRequestBinderMockRecorder) BindPatch(r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindPatch", reflect.TypeOf((*MockRequestBinder)(nil).BindPatch), r)
+}
+
 // MockResponseRenderer is a mock of ResponseRenderer interface
 type MockResponseRenderer struct {
 	ctrl     *gomock.Controller
@@ -6,6 +6,8 @@ package mocking
 
 import (
 	context "context"
+	query "github.com/cpekyaman/goits/framework/orm/query"
+	patching "github.com/cpekyaman/goits/framework/patching"
 	services "github.com/cpekyaman/goits/framework/services"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -65,10 +67,10 @@ func (mr *MockSearcherServiceMockRecorder) FindAll(ctx, attrs interface{}) *gomo
 }
 
 // FindAllPaged mocks base method
-func (m *MockSearcherService) FindAllPaged(ctx context.Context, attrs map[string]interface{}, limit uint, offset uint64) (interface{}, error) {
+func (m *MockSearcherService) FindAllPaged(ctx context.Context, attrs map[string]interface{}, limit uint, offset uint64) (services.Page, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindAllPaged", ctx, attrs, limit, offset)
-	ret0, _ := ret[0].(interface{})
+	ret0, _ := ret[0].(services.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -79,6 +81,66 @@ func (mr *MockSearcherServiceMockRecorder) FindAllPaged(ctx, attrs, limit, offse
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllPaged", reflect.TypeOf((*MockSearcherService)(nil).FindAllPaged), ctx, attrs, limit, offset)
 }
 
+// FindAllByCriteria mocks base method
+func (m *MockSearcherService) FindAllByCriteria(ctx context.Context, c query.Criteria, opts ...query.Option) (interface{}, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, c}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindAllByCriteria", varargs...)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllByCriteria indicates an expected call of FindAllByCriteria
+func (mr *MockSearcherServiceMockRecorder) FindAllByCriteria(ctx, c interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, c}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByCriteria", reflect.TypeOf((*MockSearcherService)(nil).FindAllByCriteria), varargs...)
+}
+
+// FindAllByCriteriaPaged mocks base method
+func (m *MockSearcherService) FindAllByCriteriaPaged(ctx context.Context, c query.Criteria, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, c, limit, offset}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindAllByCriteriaPaged", varargs...)
+	ret0, _ := ret[0].(services.Page)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllByCriteriaPaged indicates an expected call of FindAllByCriteriaPaged
+func (mr *MockSearcherServiceMockRecorder) FindAllByCriteriaPaged(ctx, c, limit, offset interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, c, limit, offset}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByCriteriaPaged", reflect.TypeOf((*MockSearcherService)(nil).FindAllByCriteriaPaged), varargs...)
+}
+
+// FindAllByCursor mocks base method
+func (m *MockSearcherService) FindAllByCursor(ctx context.Context, c query.Criteria, after query.Cursor, limit uint, opts ...query.Option) (services.CursorPage, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, c, after, limit}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindAllByCursor", varargs...)
+	ret0, _ := ret[0].(services.CursorPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllByCursor indicates an expected call of FindAllByCursor
+func (mr *MockSearcherServiceMockRecorder) FindAllByCursor(ctx, c, after, limit interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, c, after, limit}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByCursor", reflect.TypeOf((*MockSearcherService)(nil).FindAllByCursor), varargs...)
+}
+
 // MockReaderService is a mock of ReaderService interface
 type MockReaderService struct {
 	ctrl     *gomock.Controller
@@ -103,48 +165,63 @@ func (m *MockReaderService) EXPECT() *MockReaderServiceMockRecorder {
 }
 
 // GetAll mocks base method
-func (m *MockReaderService) GetAll(ctx context.Context) (interface{}, error) {
+func (m *MockReaderService) GetAll(ctx context.Context, opts ...query.Option) (interface{}, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", ctx)
+	varargs := []interface{}{ctx}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetAll", varargs...)
 	ret0, _ := ret[0].(interface{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll
-func (mr *MockReaderServiceMockRecorder) GetAll(ctx interface{}) *gomock.Call {
+func (mr *MockReaderServiceMockRecorder) GetAll(ctx interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockReaderService)(nil).GetAll), ctx)
+	varargs := append([]interface{}{ctx}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockReaderService)(nil).GetAll), varargs...)
 }
 
 // GetAllPaged mocks base method
-func (m *MockReaderService) GetAllPaged(ctx context.Context, limit uint, offset uint64) (interface{}, error) {
+func (m *MockReaderService) GetAllPaged(ctx context.Context, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllPaged", ctx, limit, offset)
-	ret0, _ := ret[0].(interface{})
+	varargs := []interface{}{ctx, limit, offset}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetAllPaged", varargs...)
+	ret0, _ := ret[0].(services.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllPaged indicates an expected call of GetAllPaged
-func (mr *MockReaderServiceMockRecorder) GetAllPaged(ctx, limit, offset interface{}) *gomock.Call {
+func (mr *MockReaderServiceMockRecorder) GetAllPaged(ctx, limit, offset interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPaged", reflect.TypeOf((*MockReaderService)(nil).GetAllPaged), ctx, limit, offset)
+	varargs := append([]interface{}{ctx, limit, offset}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPaged", reflect.TypeOf((*MockReaderService)(nil).GetAllPaged), varargs...)
 }
 
 // GetById mocks base method
-func (m *MockReaderService) GetById(ctx context.Context, id uint64) (interface{}, error) {
+func (m *MockReaderService) GetById(ctx context.Context, id uint64, opts ...query.Option) (interface{}, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", ctx, id)
+	varargs := []interface{}{ctx, id}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetById", varargs...)
 	ret0, _ := ret[0].(interface{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById
-func (mr *MockReaderServiceMockRecorder) GetById(ctx, id interface{}) *gomock.Call {
+func (mr *MockReaderServiceMockRecorder) GetById(ctx, id interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockReaderService)(nil).GetById), ctx, id)
+	varargs := append([]interface{}{ctx, id}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockReaderService)(nil).GetById), varargs...)
 }
 
 // MockObjectBinder is a mock of ObjectBinder interface
@@ -208,11 +285,12 @@ func (m *MockCreatorService) EXPECT() *MockCreatorServiceMockRecorder {
 }
 
 // Create mocks base method
-func (m *MockCreatorService) Create(ctx context.Context, binding services.ObjectBinder) error {
+func (m *MockCreatorService) Create(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, binding)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Create indicates an expected call of Create
@@ -245,11 +323,12 @@ func (m *MockUpdaterService) EXPECT() *MockUpdaterServiceMockRecorder {
 }
 
 // Update mocks base method
-func (m *MockUpdaterService) Update(ctx context.Context, id uint64, binding services.ObjectBinder) error {
+func (m *MockUpdaterService) Update(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Update", ctx, id, binding)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Update indicates an expected call of Update
@@ -258,6 +337,44 @@ func (mr *MockUpdaterServiceMockRecorder) Update(ctx, id, binding interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUpdaterService)(nil).Update), ctx, id, binding)
 }
 
+// MockPatcherService is a mock of PatcherService interface
+type MockPatcherService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPatcherServiceMockRecorder
+}
+
+// MockPatcherServiceMockRecorder is the mock recorder for MockPatcherService
+type MockPatcherServiceMockRecorder struct {
+	mock *MockPatcherService
+}
+
+// NewMockPatcherService creates a new mock instance
+func NewMockPatcherService(ctrl *gomock.Controller) *MockPatcherService {
+	mock := &MockPatcherService{ctrl: ctrl}
+	mock.recorder = &MockPatcherServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPatcherService) EXPECT() *MockPatcherServiceMockRecorder {
+	return m.recorder
+}
+
+// Patch mocks base method
+func (m *MockPatcherService) Patch(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, patch)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch
+func (mr *MockPatcherServiceMockRecorder) Patch(ctx, id, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockPatcherService)(nil).Patch), ctx, id, patch)
+}
+
 // MockDeleterService is a mock of DeleterService interface
 type MockDeleterService struct {
 	ctrl     *gomock.Controller
@@ -295,6 +412,80 @@ func (mr *MockDeleterServiceMockRecorder) Delete(ctx, id interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDeleterService)(nil).Delete), ctx, id)
 }
 
+// MockVersionedDeleterService is a mock of VersionedDeleterService interface
+type MockVersionedDeleterService struct {
+	ctrl     *gomock.Controller
+	recorder *MockVersionedDeleterServiceMockRecorder
+}
+
+// MockVersionedDeleterServiceMockRecorder is the mock recorder for MockVersionedDeleterService
+type MockVersionedDeleterServiceMockRecorder struct {
+	mock *MockVersionedDeleterService
+}
+
+// NewMockVersionedDeleterService creates a new mock instance
+func NewMockVersionedDeleterService(ctrl *gomock.Controller) *MockVersionedDeleterService {
+	mock := &MockVersionedDeleterService{ctrl: ctrl}
+	mock.recorder = &MockVersionedDeleterServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVersionedDeleterService) EXPECT() *MockVersionedDeleterServiceMockRecorder {
+	return m.recorder
+}
+
+// DeleteVersion mocks base method
+func (m *MockVersionedDeleterService) DeleteVersion(ctx context.Context, id uint64, version uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVersion", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVersion indicates an expected call of DeleteVersion
+func (mr *MockVersionedDeleterServiceMockRecorder) DeleteVersion(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVersion", reflect.TypeOf((*MockVersionedDeleterService)(nil).DeleteVersion), ctx, id, version)
+}
+
+// MockRestorerService is a mock of RestorerService interface
+type MockRestorerService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRestorerServiceMockRecorder
+}
+
+// MockRestorerServiceMockRecorder is the mock recorder for MockRestorerService
+type MockRestorerServiceMockRecorder struct {
+	mock *MockRestorerService
+}
+
+// NewMockRestorerService creates a new mock instance
+func NewMockRestorerService(ctrl *gomock.Controller) *MockRestorerService {
+	mock := &MockRestorerService{ctrl: ctrl}
+	mock.recorder = &MockRestorerServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRestorerService) EXPECT() *MockRestorerServiceMockRecorder {
+	return m.recorder
+}
+
+// Restore mocks base method
+func (m *MockRestorerService) Restore(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore
+func (mr *MockRestorerServiceMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockRestorerService)(nil).Restore), ctx, id)
+}
+
 // MockWriterService is a mock of WriterService interface
 type MockWriterService struct {
 	ctrl     *gomock.Controller
@@ -319,11 +510,12 @@ func (m *MockWriterService) EXPECT() *MockWriterServiceMockRecorder {
 }
 
 // Create mocks base method
-func (m *MockWriterService) Create(ctx context.Context, binding services.ObjectBinder) error {
+func (m *MockWriterService) Create(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, binding)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Create indicates an expected call of Create
@@ -333,11 +525,12 @@ func (mr *MockWriterServiceMockRecorder) Create(ctx, binding interface{}) *gomoc
 }
 
 // Update mocks base method
-func (m *MockWriterService) Update(ctx context.Context, id uint64, binding services.ObjectBinder) error {
+func (m *MockWriterService) Update(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Update", ctx, id, binding)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Update indicates an expected call of Update
@@ -346,6 +539,21 @@ func (mr *MockWriterServiceMockRecorder) Update(ctx, id, binding interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWriterService)(nil).Update), ctx, id, binding)
 }
 
+// Patch mocks base method
+func (m *MockWriterService) Patch(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, patch)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch
+func (mr *MockWriterServiceMockRecorder) Patch(ctx, id, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockWriterService)(nil).Patch), ctx, id, patch)
+}
+
 // MockReaderWriterService is a mock of ReaderWriterService interface
 type MockReaderWriterService struct {
 	ctrl     *gomock.Controller
@@ -370,56 +578,72 @@ func (m *MockReaderWriterService) EXPECT() *MockReaderWriterServiceMockRecorder
 }
 
 // GetAll mocks base method
-func (m *MockReaderWriterService) GetAll(ctx context.Context) (interface{}, error) {
+func (m *MockReaderWriterService) GetAll(ctx context.Context, opts ...query.Option) (interface{}, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", ctx)
+	varargs := []interface{}{ctx}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetAll", varargs...)
 	ret0, _ := ret[0].(interface{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll
-func (mr *MockReaderWriterServiceMockRecorder) GetAll(ctx interface{}) *gomock.Call {
+func (mr *MockReaderWriterServiceMockRecorder) GetAll(ctx interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockReaderWriterService)(nil).GetAll), ctx)
+	varargs := append([]interface{}{ctx}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockReaderWriterService)(nil).GetAll), varargs...)
 }
 
 // GetAllPaged mocks base method
-func (m *MockReaderWriterService) GetAllPaged(ctx context.Context, limit uint, offset uint64) (interface{}, error) {
+func (m *MockReaderWriterService) GetAllPaged(ctx context.Context, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllPaged", ctx, limit, offset)
-	ret0, _ := ret[0].(interface{})
+	varargs := []interface{}{ctx, limit, offset}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetAllPaged", varargs...)
+	ret0, _ := ret[0].(services.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllPaged indicates an expected call of GetAllPaged
-func (mr *MockReaderWriterServiceMockRecorder) GetAllPaged(ctx, limit, offset interface{}) *gomock.Call {
+func (mr *MockReaderWriterServiceMockRecorder) GetAllPaged(ctx, limit, offset interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPaged", reflect.TypeOf((*MockReaderWriterService)(nil).GetAllPaged), ctx, limit, offset)
+	varargs := append([]interface{}{ctx, limit, offset}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPaged", reflect.TypeOf((*MockReaderWriterService)(nil).GetAllPaged), varargs...)
 }
 
 // GetById mocks base method
-func (m *MockReaderWriterService) GetById(ctx context.Context, id uint64) (interface{}, error) {
+func (m *MockReaderWriterService) GetById(ctx context.Context, id uint64, opts ...query.Option) (interface{}, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", ctx, id)
+	varargs := []interface{}{ctx, id}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetById", varargs...)
 	ret0, _ := ret[0].(interface{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById
-func (mr *MockReaderWriterServiceMockRecorder) GetById(ctx, id interface{}) *gomock.Call {
+func (mr *MockReaderWriterServiceMockRecorder) GetById(ctx, id interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockReaderWriterService)(nil).GetById), ctx, id)
+	varargs := append([]interface{}{ctx, id}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockReaderWriterService)(nil).GetById), varargs...)
 }
 
 // Create mocks base method
-func (m *MockReaderWriterService) Create(ctx context.Context, binding services.ObjectBinder) error {
+func (m *MockReaderWriterService) Create(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, binding)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Create indicates an expected call of Create
@@ -429,11 +653,12 @@ func (mr *MockReaderWriterServiceMockRecorder) Create(ctx, binding interface{})
 }
 
 // Update mocks base method
-func (m *MockReaderWriterService) Update(ctx context.Context, id uint64, binding services.ObjectBinder) error {
+func (m *MockReaderWriterService) Update(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Update", ctx, id, binding)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Update indicates an expected call of Update
@@ -442,6 +667,21 @@ func (mr *MockReaderWriterServiceMockRecorder) Update(ctx, id, binding interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockReaderWriterService)(nil).Update), ctx, id, binding)
 }
 
+// Patch mocks base method
+func (m *MockReaderWriterService) Patch(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, patch)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch
+func (mr *MockReaderWriterServiceMockRecorder) Patch(ctx, id, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockReaderWriterService)(nil).Patch), ctx, id, patch)
+}
+
 // MockCRUDService is a mock of CRUDService interface
 type MockCRUDService struct {
 	ctrl     *gomock.Controller
@@ -466,56 +706,72 @@ func (m *MockCRUDService) EXPECT() *MockCRUDServiceMockRecorder {
 }
 
 // GetAll mocks base method
-func (m *MockCRUDService) GetAll(ctx context.Context) (interface{}, error) {
+func (m *MockCRUDService) GetAll(ctx context.Context, opts ...query.Option) (interface{}, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", ctx)
+	varargs := []interface{}{ctx}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetAll", varargs...)
 	ret0, _ := ret[0].(interface{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll
-func (mr *MockCRUDServiceMockRecorder) GetAll(ctx interface{}) *gomock.Call {
+func (mr *MockCRUDServiceMockRecorder) GetAll(ctx interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockCRUDService)(nil).GetAll), ctx)
+	varargs := append([]interface{}{ctx}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockCRUDService)(nil).GetAll), varargs...)
 }
 
 // GetAllPaged mocks base method
-func (m *MockCRUDService) GetAllPaged(ctx context.Context, limit uint, offset uint64) (interface{}, error) {
+func (m *MockCRUDService) GetAllPaged(ctx context.Context, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllPaged", ctx, limit, offset)
-	ret0, _ := ret[0].(interface{})
+	varargs := []interface{}{ctx, limit, offset}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetAllPaged", varargs...)
+	ret0, _ := ret[0].(services.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllPaged indicates an expected call of GetAllPaged
-func (mr *MockCRUDServiceMockRecorder) GetAllPaged(ctx, limit, offset interface{}) *gomock.Call {
+func (mr *MockCRUDServiceMockRecorder) GetAllPaged(ctx, limit, offset interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPaged", reflect.TypeOf((*MockCRUDService)(nil).GetAllPaged), ctx, limit, offset)
+	varargs := append([]interface{}{ctx, limit, offset}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPaged", reflect.TypeOf((*MockCRUDService)(nil).GetAllPaged), varargs...)
 }
 
 // GetById mocks base method
-func (m *MockCRUDService) GetById(ctx context.Context, id uint64) (interface{}, error) {
+func (m *MockCRUDService) GetById(ctx context.Context, id uint64, opts ...query.Option) (interface{}, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", ctx, id)
+	varargs := []interface{}{ctx, id}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetById", varargs...)
 	ret0, _ := ret[0].(interface{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById
-func (mr *MockCRUDServiceMockRecorder) GetById(ctx, id interface{}) *gomock.Call {
+func (mr *MockCRUDServiceMockRecorder) GetById(ctx, id interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockCRUDService)(nil).GetById), ctx, id)
+	varargs := append([]interface{}{ctx, id}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockCRUDService)(nil).GetById), varargs...)
 }
 
 // Create mocks base method
-func (m *MockCRUDService) Create(ctx context.Context, binding services.ObjectBinder) error {
+func (m *MockCRUDService) Create(ctx context.Context, binding services.ObjectBinder) (interface{}, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, binding)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Create indicates an expected call of Create
@@ -525,11 +781,12 @@ func (mr *MockCRUDServiceMockRecorder) Create(ctx, binding interface{}) *gomock.
 }
 
 // Update mocks base method
-func (m *MockCRUDService) Update(ctx context.Context, id uint64, binding services.ObjectBinder) error {
+func (m *MockCRUDService) Update(ctx context.Context, id uint64, binding services.ObjectBinder) (interface{}, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Update", ctx, id, binding)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Update indicates an expected call of Update
@@ -538,6 +795,126 @@ func (mr *MockCRUDServiceMockRecorder) Update(ctx, id, binding interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCRUDService)(nil).Update), ctx, id, binding)
 }
 
+// Patch mocks base method
+func (m *MockCRUDService) Patch(ctx context.Context, id uint64, patch patching.Patch) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, patch)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch
+func (mr *MockCRUDServiceMockRecorder) Patch(ctx, id, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockCRUDService)(nil).Patch), ctx, id, patch)
+}
+
+// FindOne mocks base method
+func (m *MockCRUDService) FindOne(ctx context.Context, attr string, attrValue interface{}) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOne", ctx, attr, attrValue)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindOne indicates an expected call of FindOne
+func (mr *MockCRUDServiceMockRecorder) FindOne(ctx, attr, attrValue interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOne", reflect.TypeOf((*MockCRUDService)(nil).FindOne), ctx, attr, attrValue)
+}
+
+// FindAll mocks base method
+func (m *MockCRUDService) FindAll(ctx context.Context, attrs map[string]interface{}) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAll", ctx, attrs)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAll indicates an expected call of FindAll
+func (mr *MockCRUDServiceMockRecorder) FindAll(ctx, attrs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockCRUDService)(nil).FindAll), ctx, attrs)
+}
+
+// FindAllPaged mocks base method
+func (m *MockCRUDService) FindAllPaged(ctx context.Context, attrs map[string]interface{}, limit uint, offset uint64) (services.Page, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllPaged", ctx, attrs, limit, offset)
+	ret0, _ := ret[0].(services.Page)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllPaged indicates an expected call of FindAllPaged
+func (mr *MockCRUDServiceMockRecorder) FindAllPaged(ctx, attrs, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllPaged", reflect.TypeOf((*MockCRUDService)(nil).FindAllPaged), ctx, attrs, limit, offset)
+}
+
+// FindAllByCriteria mocks base method
+func (m *MockCRUDService) FindAllByCriteria(ctx context.Context, c query.Criteria, opts ...query.Option) (interface{}, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, c}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindAllByCriteria", varargs...)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllByCriteria indicates an expected call of FindAllByCriteria
+func (mr *MockCRUDServiceMockRecorder) FindAllByCriteria(ctx, c interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, c}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByCriteria", reflect.TypeOf((*MockCRUDService)(nil).FindAllByCriteria), varargs...)
+}
+
+// FindAllByCriteriaPaged mocks base method
+func (m *MockCRUDService) FindAllByCriteriaPaged(ctx context.Context, c query.Criteria, limit uint, offset uint64, opts ...query.Option) (services.Page, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, c, limit, offset}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindAllByCriteriaPaged", varargs...)
+	ret0, _ := ret[0].(services.Page)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllByCriteriaPaged indicates an expected call of FindAllByCriteriaPaged
+func (mr *MockCRUDServiceMockRecorder) FindAllByCriteriaPaged(ctx, c, limit, offset interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, c, limit, offset}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByCriteriaPaged", reflect.TypeOf((*MockCRUDService)(nil).FindAllByCriteriaPaged), varargs...)
+}
+
+// FindAllByCursor mocks base method
+func (m *MockCRUDService) FindAllByCursor(ctx context.Context, c query.Criteria, after query.Cursor, limit uint, opts ...query.Option) (services.CursorPage, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, c, after, limit}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindAllByCursor", varargs...)
+	ret0, _ := ret[0].(services.CursorPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllByCursor indicates an expected call of FindAllByCursor
+func (mr *MockCRUDServiceMockRecorder) FindAllByCursor(ctx, c, after, limit interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, c, after, limit}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByCursor", reflect.TypeOf((*MockCRUDService)(nil).FindAllByCursor), varargs...)
+}
+
 // Delete mocks base method
 func (m *MockCRUDService) Delete(ctx context.Context, id uint64) error {
 	m.ctrl.T.Helper()
@@ -551,3 +928,31 @@ func (mr *MockCRUDServiceMockRecorder) Delete(ctx, id interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCRUDService)(nil).Delete), ctx, id)
 }
+
+// DeleteVersion mocks base method
+func (m *MockCRUDService) DeleteVersion(ctx context.Context, id uint64, version uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVersion", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVersion indicates an expected call of DeleteVersion
+func (mr *MockCRUDServiceMockRecorder) DeleteVersion(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVersion", reflect.TypeOf((*MockCRUDService)(nil).DeleteVersion), ctx, id, version)
+}
+
+// Restore mocks base method
+func (m *MockCRUDService) Restore(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore
+func (mr *MockCRUDServiceMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockCRUDService)(nil).Restore), ctx, id)
+}
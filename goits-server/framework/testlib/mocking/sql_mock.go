@@ -1,7 +1,9 @@
 package mocking
 
 import (
+	"database/sql/driver"
 	"errors"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -27,6 +29,15 @@ func NewSqlMock(t *testing.T) sqlmock.Sqlmock {
 	return mock
 }
 
+// DriverValues converts query parameters to driver values to be used as expected arguments.
+func DriverValues(params []interface{}) []driver.Value {
+	args := make([]driver.Value, len(params))
+	for i, p := range params {
+		args[i] = p
+	}
+	return args
+}
+
 // NewQueryMocker returns a new QueryMocker instance for the given entity.
 func NewQueryMocker(ed metadata.EntityDef) QueryMocker {
 	return QueryMocker{ed}
@@ -51,25 +62,87 @@ func (this QueryMocker) ExpectFindOneByAttr(mock sqlmock.Sqlmock, attr string) *
 	return mock.ExpectQuery("select .* from " + this.ed.FullTableName() + " where " + cm.Column(attr) + " = \\$1")
 }
 
-// ExpectFindAll creates an ExpectedQuery that expects a select all with default ordering.
+// ExpectFindAll creates an ExpectedQuery that expects a select all with default ordering, skipping deleted rows of soft deleted entities.
 func (this QueryMocker) ExpectFindAll(mock sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
-	return mock.ExpectQuery("select .* from " + this.ed.FullTableName() + " order by " + this.ed.DefaultSort())
+	return mock.ExpectQuery("select .* from " + this.ed.FullTableName() + " (where deleted = false )?order by " + this.ed.DefaultSort())
 }
 
 // ExpectFindAllByAttributes creates and ExpectedQuery that expects a select by using given attributes as criteria.
 func (this QueryMocker) ExpectFindAllByAttributes(mock sqlmock.Sqlmock, attrs map[string]interface{}) (*sqlmock.ExpectedQuery, []interface{}) {
+	return this.ExpectFindAllByCriteria(mock, query.AttributesCriteria(attrs))
+}
+
+// ExpectFindAllByCriteria creates and ExpectedQuery that expects a select by using given criteria.
+func (this QueryMocker) ExpectFindAllByCriteria(mock sqlmock.Sqlmock, c query.Criteria) (*sqlmock.ExpectedQuery, []interface{}) {
+	cm, found := metadata.GetColumnMapper(this.ed)
+	if !found {
+		return nil, nil
+	}
+
+	qd, found := query.GetQueryDef(this.ed)
+	if !found {
+		return nil, nil
+	}
+
+	q, params, err := query.BuildQueryByCriteria(this.ed, qd, cm, c, 0, 0)
+	if err != nil {
+		return nil, nil
+	}
+	return mock.ExpectQuery(regexp.QuoteMeta(q)), params
+}
+
+// ExpectFindAllByCursor creates an ExpectedQuery that expects a keyset paged select after the given cursor.
+// The query is expected to fetch one more row than the limit to find out if there is a next page.
+func (this QueryMocker) ExpectFindAllByCursor(mock sqlmock.Sqlmock, after query.Cursor, limit uint) (*sqlmock.ExpectedQuery, []interface{}) {
 	cm, found := metadata.GetColumnMapper(this.ed)
 	if !found {
 		return nil, nil
 	}
 
 	qd, found := query.GetQueryDef(this.ed)
-	if ! found {
+	if !found {
+		return nil, nil
+	}
+
+	q, params, _, err := query.BuildQueryByCursor(this.ed, qd, cm, nil, after, limit+1)
+	if err != nil {
 		return nil, nil
 	}
+	return mock.ExpectQuery(regexp.QuoteMeta(q)), params
+}
+
+// ExpectFindAllPaged creates an ExpectedQuery that expects a select all with default ordering for the given page.
+func (this QueryMocker) ExpectFindAllPaged(mock sqlmock.Sqlmock, limit uint, offset uint64) *sqlmock.ExpectedQuery {
+	qd, found := query.GetQueryDef(this.ed)
+	if !found {
+		return nil
+	}
+	return mock.ExpectQuery(regexp.QuoteMeta(query.BuildFindAllPagedQuery(this.ed, qd, limit, offset)))
+}
+
+// ExpectCount creates an ExpectedQuery that expects a count of all rows and returns the given total.
+func (this QueryMocker) ExpectCount(mock sqlmock.Sqlmock, total uint64) *sqlmock.ExpectedQuery {
+	return this.ExpectCountByCriteria(mock, nil, total)
+}
+
+// ExpectCountByCriteria creates an ExpectedQuery that expects a count of rows matching the criteria and returns the given total.
+func (this QueryMocker) ExpectCountByCriteria(mock sqlmock.Sqlmock, c query.Criteria, total uint64) *sqlmock.ExpectedQuery {
+	cm, found := metadata.GetColumnMapper(this.ed)
+	if !found {
+		return nil
+	}
+
+	q, params, err := query.BuildCountQueryByCriteria(this.ed, cm, c)
+	if err != nil {
+		return nil
+	}
 
-	where, params := query.BuildCriteria(this.ed, qd, cm, attrs)
-	return mock.ExpectQuery("select .* from " + this.ed.FullTableName() + " " + where + " order by " + this.ed.DefaultSort()), params
+	eq := mock.ExpectQuery(regexp.QuoteMeta(q)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(total))
+	if len(params) > 0 {
+		eq.WithArgs(DriverValues(params)...)
+	}
+	return eq
 }
 
 // ExpectQueryWithRows gets an existing ExpectedQuery and prepares it to return rows with given column structure.
@@ -79,31 +152,53 @@ func (this QueryMocker) ExpectQueryWithRows(eq *sqlmock.ExpectedQuery, columns [
 	return q, rows
 }
 
-// ExpectInsert creates an ExpectedExec that expects the default insert statement and completes successfully.
-func (this QueryMocker) ExpectInsert(mock sqlmock.Sqlmock, expectedId int64) *sqlmock.ExpectedExec {
-	return this.insertMock(mock).WillReturnResult(sqlmock.NewResult(expectedId, 1))
+// ExpectInsert creates an ExpectedQuery that expects the default insert statement and returns the generated id.
+func (this QueryMocker) ExpectInsert(mock sqlmock.Sqlmock, expectedId int64) *sqlmock.ExpectedQuery {
+	return this.insertMock(mock).WillReturnRows(sqlmock.NewRows([]string{this.ed.PKColumn()}).AddRow(expectedId))
 }
 
-// ExpectInsertError creates an ExpectedExec that expects the default insert statement and fails with error.
+// ExpectInsertError creates an ExpectedQuery that expects the default insert statement and fails with error.
 func (this QueryMocker) ExpectInsertError(mock sqlmock.Sqlmock) {
 	this.insertMock(mock).WillReturnError(SqlError)
 }
 
-func (this QueryMocker) insertMock(mock sqlmock.Sqlmock) *sqlmock.ExpectedExec {
-	return mock.ExpectExec("insert into " + this.ed.FullTableName() + "(.*) values(.*)")
+func (this QueryMocker) insertMock(mock sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery("insert into " + this.ed.FullTableName() + "(.*) values(.*) returning .*")
+}
+
+// ExpectUpdate creates an ExpectedQuery that expects the default update statement and returns the updated row.
+func (this QueryMocker) ExpectUpdate(mock sqlmock.Sqlmock, value interface{}) *sqlmock.ExpectedQuery {
+	rows := sqlmock.NewRows([]string{this.ed.PKColumn()})
+	if e, ok := value.(domain.Entity); ok {
+		rows.AddRow(e.GetId())
+	}
+	return this.updateMock(mock, value).WillReturnRows(rows)
+}
+
+// ExpectUpdateNoRows creates an ExpectedQuery that expects the default update statement and affects no rows.
+func (this QueryMocker) ExpectUpdateNoRows(mock sqlmock.Sqlmock, value interface{}) *sqlmock.ExpectedQuery {
+	return this.updateMock(mock, value).WillReturnRows(sqlmock.NewRows([]string{this.ed.PKColumn()}))
 }
 
-// ExpectUpdate creates an ExpectedExec that expects the default update statement and completes successfully.
-func (this QueryMocker) ExpectUpdate(mock sqlmock.Sqlmock, value interface{}) *sqlmock.ExpectedExec {
-	return this.updateMock(mock, value).WillReturnResult(sqlmock.NewResult(0, 1))
+// ExpectCurrentVersion creates an ExpectedQuery that expects the version query by id and returns the given version.
+// No rows are returned if version is nil.
+func (this QueryMocker) ExpectCurrentVersion(mock sqlmock.Sqlmock, id uint64, version interface{}) *sqlmock.ExpectedQuery {
+	rows := sqlmock.NewRows([]string{"version"})
+	if version != nil {
+		rows.AddRow(version)
+	}
+
+	return mock.ExpectQuery("select version from " + this.ed.FullTableName() + " where " + this.ed.PKColumn() + " = \\$1").
+		WithArgs(id).
+		WillReturnRows(rows)
 }
 
-// ExpectUpdateError creates an ExpectedExec that expects the default update statement and fails with error.
+// ExpectUpdateError creates an ExpectedQuery that expects the default update statement and fails with error.
 func (this QueryMocker) ExpectUpdateError(mock sqlmock.Sqlmock, value interface{}) {
 	this.updateMock(mock, value).WillReturnError(SqlError)
 }
 
-func (this QueryMocker) updateMock(mock sqlmock.Sqlmock, value interface{}) *sqlmock.ExpectedExec {
+func (this QueryMocker) updateMock(mock sqlmock.Sqlmock, value interface{}) *sqlmock.ExpectedQuery {
 	var q string
 	_, ok := value.(domain.Versioned)
 	if ok {
@@ -112,5 +207,6 @@ func (this QueryMocker) updateMock(mock sqlmock.Sqlmock, value interface{}) *sql
 		q = "update " + this.ed.FullTableName() + " set .* where id=\\?"
 	}
 
-	return mock.ExpectExec(q)
+	// rows of soft deleted entities are only updated if they are not deleted
+	return mock.ExpectQuery(q + "( AND deleted = false)? returning .*")
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/orm/query"
 	"github.com/cpekyaman/goits/framework/orm/repository"
 	"github.com/cpekyaman/goits/framework/testlib/assertions"
 	"github.com/cpekyaman/goits/framework/testlib/mocking"
@@ -100,6 +101,49 @@ func (this ReaderRepositoryTest) FindById_Error(t *testing.T, tc *TestContext) {
 	assert.Equal(t, mocking.SqlError, err, "unexpected error")
 }
 
+// RelationMock is the expected query of the related entities that are loaded when a relation is included.
+type RelationMock struct {
+	Target    metadata.EntityDef
+	Criteria  query.Criteria
+	Columns   []string
+	RowMocker func(rows *sqlmock.Rows)
+}
+
+func (this ReaderRepositoryTest) FindById_Include_DataFound(t *testing.T, tc *TestContext, relation string, rm RelationMock) {
+	// given
+	repo, mock := this.NewRepoWithMock(t)
+
+	findOneId := uint64(4)
+	_, rows := this.MockFindOneWithRows(findOneId, mock)
+	tc.rowMocker.Mock(rows)
+
+	eq, params := mocking.NewQueryMocker(rm.Target).ExpectFindAllByCriteria(mock, rm.Criteria)
+	eq, relatedRows := this.mocker.ExpectQueryWithRows(eq, rm.Columns)
+	eq.WithArgs(mocking.DriverValues(params)...)
+	rm.RowMocker(relatedRows)
+
+	// when
+	err := repo.FindOneById(context.Background(), tc.valueHolder, findOneId, query.Include(relation))
+
+	// then
+	assert.Nil(t, err, err)
+	assert.Nil(t, mock.ExpectationsWereMet(), "related entities should be queried")
+	tc.asserter.Assert(t, TestResult{tc.valueHolder, err})
+}
+
+func (this ReaderRepositoryTest) FindById_Include_UnknownRelation(t *testing.T, valueHolder interface{}, relation string) {
+	// given
+	repo, mock := this.NewRepoWithMock(t)
+
+	// when
+	err := repo.FindOneById(context.Background(), valueHolder, uint64(4), query.Include(relation))
+
+	// then
+	assert.NotNil(t, err, "should get back an error")
+	assert.IsType(t, query.UnknownRelationError{}, err, fmt.Sprintf("unexpected error: %v", err))
+	assert.Nil(t, mock.ExpectationsWereMet(), "no query should be executed")
+}
+
 //////////////////////
 // Tests For FindOneByAttribute
 //////////////////////
@@ -202,21 +246,92 @@ func (this ReaderRepositoryTest) FindAll_Error(t *testing.T, tc *TestContext) {
 // Tests For FindAllByAttributes
 //////////////////////
 
-func (this ReaderRepositoryTest) FindAllByAttributes_DataFound(t *testing.T, tc *TestContext) {
+func (this ReaderRepositoryTest) FindAllByAttributes_DataFound(t *testing.T, tc *TestContext, attrs map[string]interface{}) {
 	// given
 	repo, mock := this.NewRepoWithMock(t)
 
-	_, rows := this.MockFindAllWithRows(mock)
+	_, rows := this.MockFindAllByAttributesWithRows(attrs, mock)
 	tc.rowMocker.Mock(rows)
 
 	// when
-	err := repo.FindAll(context.Background(), tc.valueHolder)
+	err := repo.FindAllByAttributes(context.Background(), tc.valueHolder, attrs)
 
 	// then
 	assert.Nil(t, err, "should not get error")
 	tc.asserter.Assert(t, TestResult{tc.valueHolder, nil})
 }
 
+//////////////////////
+// Tests For FindAllByCriteria
+//////////////////////
+
+func (this ReaderRepositoryTest) FindAllByCriteria_DataFound(t *testing.T, tc *TestContext, c query.Criteria) {
+	// given
+	repo, mock := this.NewRepoWithMock(t)
+
+	_, rows := this.MockFindAllByCriteriaWithRows(c, mock)
+	tc.rowMocker.Mock(rows)
+
+	// when
+	err := repo.FindAllByCriteria(context.Background(), tc.valueHolder, c)
+
+	// then
+	assert.Nil(t, err, "should not get error")
+	tc.asserter.Assert(t, TestResult{tc.valueHolder, nil})
+}
+
+func (this ReaderRepositoryTest) FindAllByCriteria_UnknownField(t *testing.T, valueHolder interface{}, c query.Criteria) {
+	// given
+	repo, _ := this.NewRepoWithMock(t)
+
+	// when
+	err := repo.FindAllByCriteria(context.Background(), valueHolder, c)
+
+	// then
+	assert.NotNil(t, err, "should get back an error")
+	assert.IsType(t, query.UnknownFieldError{}, err, fmt.Sprintf("unexpected error: %v", err))
+	assertions.ArrayEmpty(t, valueHolder)
+}
+
+//////////////////////
+// Tests For FindAllByCursor
+//////////////////////
+
+func (this ReaderRepositoryTest) FindAllByCursor_DataFound(t *testing.T, tc *TestContext, after query.Cursor, limit uint, expectedNext query.Cursor) {
+	// given
+	repo, mock := this.NewRepoWithMock(t)
+
+	_, rows := this.MockFindAllByCursorWithRows(after, limit, mock)
+	tc.rowMocker.Mock(rows)
+
+	// when
+	next, err := repo.FindAllByCursor(context.Background(), tc.valueHolder, nil, after, limit)
+
+	// then
+	assert.Nil(t, err, "should not get error")
+	assert.Equal(t, expectedNext, next, "next cursor is not correct")
+	tc.asserter.Assert(t, TestResult{tc.valueHolder, nil})
+}
+
+//////////////////////
+// Tests For Count
+//////////////////////
+
+func (this ReaderRepositoryTest) CountByCriteria_Success(t *testing.T, c query.Criteria, total uint64) {
+	// given
+	repo, mock := this.NewRepoWithMock(t)
+
+	this.mocker.ExpectCountByCriteria(mock, c, total)
+
+	// when
+	count, err := repo.CountByCriteria(context.Background(), c)
+
+	// then
+	assert.Nil(t, err, "should not get error")
+	assert.Equal(t, total, count, "count is not correct")
+	assert.Nil(t, mock.ExpectationsWereMet(), "count query should be executed")
+}
+
 //////////////////////
 // Mock Helpers
 //////////////////////
@@ -241,7 +356,23 @@ func (this ReaderRepositoryTest) MockFindOneByAttributeWithRows(attr string, att
 func (this ReaderRepositoryTest) MockFindAllByAttributesWithRows(attrs map[string]interface{}, mock sqlmock.Sqlmock) (*sqlmock.ExpectedQuery, *sqlmock.Rows) {
 	eq, params := this.mocker.ExpectFindAllByAttributes(mock, attrs)
 	eq, rows := this.mocker.ExpectQueryWithRows(eq, this.metaData.Columns)
-	eq.WithArgs(params...)
+	eq.WithArgs(mocking.DriverValues(params)...)
+	return eq, rows
+}
+
+func (this ReaderRepositoryTest) MockFindAllByCriteriaWithRows(c query.Criteria, mock sqlmock.Sqlmock) (*sqlmock.ExpectedQuery, *sqlmock.Rows) {
+	eq, params := this.mocker.ExpectFindAllByCriteria(mock, c)
+	eq, rows := this.mocker.ExpectQueryWithRows(eq, this.metaData.Columns)
+	eq.WithArgs(mocking.DriverValues(params)...)
+	return eq, rows
+}
+
+func (this ReaderRepositoryTest) MockFindAllByCursorWithRows(after query.Cursor, limit uint, mock sqlmock.Sqlmock) (*sqlmock.ExpectedQuery, *sqlmock.Rows) {
+	eq, params := this.mocker.ExpectFindAllByCursor(mock, after, limit)
+	eq, rows := this.mocker.ExpectQueryWithRows(eq, this.metaData.Columns)
+	if len(params) > 0 {
+		eq.WithArgs(mocking.DriverValues(params)...)
+	}
 	return eq, rows
 }
 
@@ -9,7 +9,9 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/cpekyaman/goits/framework/orm/db"
+	"github.com/cpekyaman/goits/framework/orm/domain"
 	"github.com/cpekyaman/goits/framework/orm/metadata"
+	"github.com/cpekyaman/goits/framework/patching"
 	"github.com/cpekyaman/goits/framework/testlib/mocking"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -117,6 +119,28 @@ func (this ServiceTest) GetAll_Error(t *testing.T) {
 	assert.Nil(t, rawResult, "should not get a result")
 }
 
+// Tests and verifies GetAllPaged method of service for success path.
+func (this ServiceTest) GetAllPaged_Success(t *testing.T, tc *TestContext, limit uint, offset uint64, total uint64) {
+	// given
+	mc := this.NewReaderTestContext(t, gomock.NewController(t))
+
+	_, rows := this.MockFindAllPagedWithRows(mc.mock, limit, offset)
+	tc.rowMocker.Mock(rows)
+	this.qm.ExpectCount(mc.mock, total)
+
+	// when
+	page, err := mc.svc.GetAllPaged(context.Background(), limit, offset)
+
+	// then
+	assert.Nil(t, err, "should not return error")
+	assert.Equal(t, limit, page.Size, "page size is not correct")
+	assert.Equal(t, total, page.Total, "total is not correct")
+	assert.Equal(t, offset/uint64(limit)+1, page.Page, "page number is not correct")
+
+	result := TestResult{page.Items, err}
+	tc.asserter.Assert(t, result)
+}
+
 //////////////////////
 // Tests For GetById
 //////////////////////
@@ -201,7 +225,7 @@ func (this ServiceTest) Create_Binding_Error(t *testing.T) {
 	expectedErr := errors.New("binding: error")
 
 	// when
-	err := mc.svc.Create(context.Background(), this.errObjectBinder(expectedErr))
+	_, err := mc.svc.Create(context.Background(), this.errObjectBinder(expectedErr))
 
 	// then
 	assert.Equal(t, expectedErr, err, "should have returned binding error")
@@ -216,7 +240,7 @@ func (this ServiceTest) Create_Validation_Error(t *testing.T, tc *TestContext) {
 	mc.vp.EXPECT().ValidateStruct(gomock.Eq(this.name), gomock.Any()).Return(expectedErr)
 
 	// when
-	err := mc.svc.Create(context.Background(), services.ObjectBinderFunc(tc.valueBinder))
+	_, err := mc.svc.Create(context.Background(), services.ObjectBinderFunc(tc.valueBinder))
 
 	// then
 	assert.Equal(t, expectedErr, err, "should have returned validation error")
@@ -229,10 +253,12 @@ func (this ServiceTest) Create_Db_Error(t *testing.T) {
 
 	mc.vp.EXPECT().ValidateStruct(gomock.Eq(this.name), gomock.Any()).Return(nil)
 
+	mc.mock.ExpectBegin()
 	this.qm.ExpectInsertError(mc.mock)
+	mc.mock.ExpectRollback()
 
 	// when
-	err := mc.svc.Create(context.Background(), this.noopObjectBinder())
+	_, err := mc.svc.Create(context.Background(), this.noopObjectBinder())
 
 	// then
 	assert.Equal(t, mocking.SqlError, err, "should have returned db error")
@@ -243,16 +269,21 @@ func (this ServiceTest) Create_Success(t *testing.T, tc *TestContext) {
 	ctrl := gomock.NewController(t)
 	mc := this.NewWriterTestContext(t, ctrl)
 
+	mc.mock.ExpectBegin()
 	exec := this.qm.ExpectInsert(mc.mock, 1)
 	tc.execMocker.Mock(exec)
+	mc.mock.ExpectCommit()
 
 	mc.vp.EXPECT().ValidateStruct(gomock.Eq(this.name), gomock.Any()).Return(nil)
 
 	// when
-	err := mc.svc.Create(context.Background(), services.ObjectBinderFunc(tc.valueBinder))
+	result, err := mc.svc.Create(context.Background(), services.ObjectBinderFunc(tc.valueBinder))
 
 	// then
 	assert.Nil(t, err, "create should be successfull")
+	entity, ok := result.(domain.Entity)
+	assert.True(t, ok, "created entity should be returned")
+	assert.Equal(t, uint64(1), entity.GetId(), "generated id should be set")
 }
 
 //////////////////////
@@ -265,10 +296,12 @@ func (this ServiceTest) Update_Find_Error(t *testing.T) {
 	mc := this.NewWriterTestContext(t, ctrl)
 
 	id := uint64(1)
+	mc.mock.ExpectBegin()
 	this.qm.ExpectFindOne(mc.mock).WillReturnError(mocking.SqlError)
+	mc.mock.ExpectRollback()
 
 	// when
-	err := mc.svc.Update(context.Background(), id, this.noopObjectBinder())
+	_, err := mc.svc.Update(context.Background(), id, this.noopObjectBinder())
 
 	// then
 	assert.Equal(t, mocking.SqlError, err, "should have returned sql error")
@@ -280,10 +313,12 @@ func (this ServiceTest) Update_NoDataFound_Error(t *testing.T) {
 	mc := this.NewWriterTestContext(t, ctrl)
 
 	id := uint64(1)
+	mc.mock.ExpectBegin()
 	this.MockFindOneWithRows(id, mc.mock)
+	mc.mock.ExpectRollback()
 
 	// when
-	err := mc.svc.Update(context.Background(), id, this.noopObjectBinder())
+	_, err := mc.svc.Update(context.Background(), id, this.noopObjectBinder())
 
 	// then
 	assert.NotNil(t, err, "should get back an error")
@@ -296,13 +331,15 @@ func (this ServiceTest) Update_Binding_Error(t *testing.T, tc *TestContext) {
 	mc := this.NewWriterTestContext(t, ctrl)
 
 	id := uint64(1)
+	mc.mock.ExpectBegin()
 	_, rows := this.MockFindOneWithRows(id, mc.mock)
 	tc.rowMocker.Mock(rows)
+	mc.mock.ExpectRollback()
 
 	expectedErr := errors.New("binding: error")
 
 	// when
-	err := mc.svc.Update(context.Background(), id, this.errObjectBinder(expectedErr))
+	_, err := mc.svc.Update(context.Background(), id, this.errObjectBinder(expectedErr))
 
 	// then
 	assert.Equal(t, expectedErr, err, "should have returned binding error")
@@ -314,14 +351,16 @@ func (this ServiceTest) Update_Validation_Error(t *testing.T, tc *TestContext) {
 	mc := this.NewWriterTestContext(t, ctrl)
 
 	id := uint64(1)
+	mc.mock.ExpectBegin()
 	_, rows := this.MockFindOneWithRows(id, mc.mock)
 	tc.rowMocker.Mock(rows)
+	mc.mock.ExpectRollback()
 
 	expectedErr := errors.New("validation: error")
 	mc.vp.EXPECT().ValidateStruct(gomock.Eq(this.name), gomock.Any()).Return(expectedErr)
 
 	// when
-	err := mc.svc.Update(context.Background(), id, services.ObjectBinderFunc(tc.valueBinder))
+	_, err := mc.svc.Update(context.Background(), id, services.ObjectBinderFunc(tc.valueBinder))
 
 	// then
 	assert.Equal(t, expectedErr, err, "should have returned validation error")
@@ -335,10 +374,14 @@ func (this ServiceTest) Update_Db_Error(t *testing.T, tc *TestContext) {
 	mc.vp.EXPECT().ValidateStruct(gomock.Eq(this.name), gomock.Any()).Return(nil)
 
 	id := uint64(1)
+	mc.mock.ExpectBegin()
+	_, rows := this.MockFindOneWithRows(id, mc.mock)
+	tc.rowMocker.Mock(rows)
 	this.qm.ExpectUpdateError(mc.mock, tc.valueHolder)
+	mc.mock.ExpectRollback()
 
 	// when
-	err := mc.svc.Update(context.Background(), id, this.noopObjectBinder())
+	_, err := mc.svc.Update(context.Background(), id, services.ObjectBinderFunc(tc.valueBinder))
 
 	// then
 	assert.Equal(t, mocking.SqlError, err, "should have returned db error")
@@ -352,19 +395,102 @@ func (this ServiceTest) Update_Success(t *testing.T, tc *TestContext) {
 	mc.vp.EXPECT().ValidateStruct(gomock.Eq(this.name), gomock.Any()).Return(nil)
 
 	id := uint64(1)
+	mc.mock.ExpectBegin()
 	_, rows := this.MockFindOneWithRows(id, mc.mock)
 	tc.rowMocker.Mock(rows)
 
 	exec := this.qm.ExpectUpdate(mc.mock, tc.valueHolder)
 	tc.execMocker.Mock(exec)
+	mc.mock.ExpectCommit()
 
 	mc.c.EXPECT().Invalidate(gomock.Eq(caching.IdToKey(id)))
 
 	// when
-	err := mc.svc.Update(context.Background(), id, services.ObjectBinderFunc(tc.valueBinder))
+	result, err := mc.svc.Update(context.Background(), id, services.ObjectBinderFunc(tc.valueBinder))
 
 	// then
 	assert.Nil(t, err, "update should be successfull")
+	assert.NotNil(t, result, "updated entity should be returned")
+}
+
+// Tests and verifies Update method of service when the request does not change the entity, so nothing is written.
+// If the entity is versioned, its version is still checked against concurrent modifications.
+func (this ServiceTest) Update_NoChange_Success(t *testing.T, tc *TestContext) {
+	// given
+	ctrl := gomock.NewController(t)
+	mc := this.NewWriterTestContext(t, ctrl)
+
+	mc.vp.EXPECT().ValidateStruct(gomock.Eq(this.name), gomock.Any()).Return(nil)
+
+	id := uint64(1)
+	mc.mock.ExpectBegin()
+	_, rows := this.MockFindOneWithRows(id, mc.mock)
+	tc.rowMocker.Mock(rows)
+	if _, versioned := tc.valueHolder.(domain.Versioned); versioned {
+		this.qm.ExpectCurrentVersion(mc.mock, id, 0)
+	}
+	mc.mock.ExpectCommit()
+
+	mc.c.EXPECT().Invalidate(gomock.Eq(caching.IdToKey(id)))
+
+	// when
+	result, err := mc.svc.Update(context.Background(), id, this.noopObjectBinder())
+
+	// then
+	assert.Nil(t, err, "update should be successfull")
+	assert.NotNil(t, result, "entity should be returned")
+	assert.Nil(t, mc.mock.ExpectationsWereMet(), "no update should be done")
+}
+
+//////////////////////
+// Tests For Patch
+//////////////////////
+
+// Tests and verifies Patch method of service for a patch that is not applicable to the loaded entity.
+func (this ServiceTest) Patch_Conflict_Error(t *testing.T, tc *TestContext, patch patching.Patch) {
+	// given
+	ctrl := gomock.NewController(t)
+	mc := this.NewWriterTestContext(t, ctrl)
+
+	id := uint64(1)
+	mc.mock.ExpectBegin()
+	_, rows := this.MockFindOneWithRows(id, mc.mock)
+	tc.rowMocker.Mock(rows)
+	mc.mock.ExpectRollback()
+
+	// when
+	_, err := mc.svc.Patch(context.Background(), id, patch)
+
+	// then
+	var pe patching.PatchError
+	assert.True(t, errors.As(err, &pe), "should have returned patch error")
+}
+
+// Tests and verifies Patch method of service for success path, where only the changed fields are updated.
+func (this ServiceTest) Patch_Success(t *testing.T, tc *TestContext, patch patching.Patch) {
+	// given
+	ctrl := gomock.NewController(t)
+	mc := this.NewWriterTestContext(t, ctrl)
+
+	mc.vp.EXPECT().ValidateStruct(gomock.Eq(this.name), gomock.Any()).Return(nil)
+
+	id := uint64(1)
+	mc.mock.ExpectBegin()
+	_, rows := this.MockFindOneWithRows(id, mc.mock)
+	tc.rowMocker.Mock(rows)
+
+	exec := this.qm.ExpectUpdate(mc.mock, tc.valueHolder)
+	tc.execMocker.Mock(exec)
+	mc.mock.ExpectCommit()
+
+	mc.c.EXPECT().Invalidate(gomock.Eq(caching.IdToKey(id)))
+
+	// when
+	result, err := mc.svc.Patch(context.Background(), id, patch)
+
+	// then
+	assert.Nil(t, err, "patch should be successfull")
+	tc.asserter.Assert(t, TestResult{result, err})
 }
 
 //////////////////////
@@ -405,6 +531,11 @@ func (this ServiceTest) MockFindAllWithRows(mock sqlmock.Sqlmock) (*sqlmock.Expe
 	return eq, rows
 }
 
+func (this ServiceTest) MockFindAllPagedWithRows(mock sqlmock.Sqlmock, limit uint, offset uint64) (*sqlmock.ExpectedQuery, *sqlmock.Rows) {
+	eq, rows := this.qm.ExpectQueryWithRows(this.qm.ExpectFindAllPaged(mock, limit, offset), this.metaData.Columns)
+	return eq, rows
+}
+
 func (this ServiceTest) NewMockDB(t *testing.T) sqlmock.Sqlmock {
 	mockDB, mock, err := sqlmock.New()
 	assert.Nil(t, err, "could not create mock db")
@@ -30,7 +30,8 @@ func (tc *TestContext) WithRowMock(mockFunc func(rows *sqlmock.Rows)) *TestConte
 
 // WithExecMock provides the function that will set the rows to be returned as mock results.
 // This mock is used to set modifying queries with correct expected arguments .
-func (tc *TestContext) WithExecMock(mockFunc func(exec *sqlmock.ExpectedExec)) *TestContext {
+// Modifying statements return the db generated columns, hence they are mocked as queries.
+func (tc *TestContext) WithExecMock(mockFunc func(exec *sqlmock.ExpectedQuery)) *TestContext {
 	tc.execMocker = ExecMockerFunc(mockFunc)
 	return tc
 }
@@ -72,14 +73,14 @@ func (f RowMockerFunc) Mock(rows *sqlmock.Rows) {
 
 // ExecMocker is used to customize expected db exec.
 type ExecMocker interface {
-	Mock(exec *sqlmock.ExpectedExec)
+	Mock(exec *sqlmock.ExpectedQuery)
 }
 
 // ExecMockerFunc is a wrapper type to use compatible functions as ExecMocker.
-type ExecMockerFunc func(exec *sqlmock.ExpectedExec)
+type ExecMockerFunc func(exec *sqlmock.ExpectedQuery)
 
 // Mock wraps the provided function in order to use it as ExecMocker.
-func (f ExecMockerFunc) Mock(exec *sqlmock.ExpectedExec) {
+func (f ExecMockerFunc) Mock(exec *sqlmock.ExpectedQuery) {
 	f(exec)
 }
 
@@ -2,6 +2,7 @@ package testlib
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -113,6 +114,84 @@ func (this WriterRepositoryTest) Update_Success(t *testing.T, tc *TestContext) {
 	assert.Nil(t, err, "no error expected")
 }
 
+// Tests and verifies that only the changed fields of the entity are updated compared to the given snapshot.
+// The exec mock of the test context is used to verify the columns being updated.
+func (this WriterRepositoryTest) UpdateChanges_Success(t *testing.T, tc *TestContext, snapshot interface{}) {
+	// given
+	repo, mock := this.NewRepoWithMock(t)
+	entity, ok := tc.valueHolder.(domain.Entity)
+	assert.True(t, ok, "value holder is not an entity")
+
+	exec := this.qm.ExpectUpdate(mock, tc.valueHolder)
+	tc.execMocker.Mock(exec)
+
+	// when
+	err := repo.SaveChanges(context.Background(), entity, snapshot)
+
+	// then
+	assert.Nil(t, err, "no error expected")
+	assert.Nil(t, mock.ExpectationsWereMet(), "update is not correct")
+}
+
+// Tests and verifies that an unchanged versioned entity is not updated, but fails if it is modified concurrently.
+func (this WriterRepositoryTest) UpdateChanges_NoChange_Conflict(t *testing.T, tc *TestContext, currentVersion uint32) {
+	// given
+	repo, mock := this.NewRepoWithMock(t)
+	entity, ok := tc.valueHolder.(domain.Entity)
+	assert.True(t, ok, "value holder is not an entity")
+
+	this.qm.ExpectCurrentVersion(mock, entity.GetId(), currentVersion)
+
+	// when
+	err := repo.SaveChanges(context.Background(), entity, domain.Snapshot(entity))
+
+	// then
+	assert.True(t, errors.Is(err, repository.ErrOptimisticLock), "should be an optimistic lock error")
+}
+
+func (this WriterRepositoryTest) Update_StaleVersion_Conflict(t *testing.T, tc *TestContext, currentVersion uint32) {
+	// given
+	repo, mock := this.NewRepoWithMock(t)
+	entity, ok := tc.valueHolder.(domain.Entity)
+	assert.True(t, ok, "value holder is not an entity")
+	versioned, ok := tc.valueHolder.(domain.Versioned)
+	assert.True(t, ok, "value holder is not versioned")
+
+	this.qm.ExpectUpdateNoRows(mock, tc.valueHolder)
+	this.qm.ExpectCurrentVersion(mock, entity.GetId(), currentVersion)
+
+	// when
+	err := repo.Save(context.Background(), entity)
+
+	// then
+	assert.True(t, errors.Is(err, repository.ErrOptimisticLock), "should be an optimistic lock error")
+	expected := repository.OptimisticLockError{
+		Entity:         this.entityDef.Name(),
+		Id:             entity.GetId(),
+		Version:        versioned.GetVersion(),
+		CurrentVersion: currentVersion,
+	}
+	assert.Equal(t, expected, err, "error is not correct")
+}
+
+func (this WriterRepositoryTest) Update_Missing_NotFound(t *testing.T, tc *TestContext) {
+	// given
+	repo, mock := this.NewRepoWithMock(t)
+	entity, ok := tc.valueHolder.(domain.Entity)
+	assert.True(t, ok, "value holder is not an entity")
+
+	this.qm.ExpectUpdateNoRows(mock, tc.valueHolder)
+	if _, versioned := tc.valueHolder.(domain.Versioned); versioned {
+		this.qm.ExpectCurrentVersion(mock, entity.GetId(), nil)
+	}
+
+	// when
+	err := repo.Save(context.Background(), entity)
+
+	// then
+	assert.Equal(t, repository.ErrNotFound, err, "should be a not found error")
+}
+
 //////////////////////
 // Mock Helpers
 //////////////////////
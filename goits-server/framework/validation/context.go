@@ -56,6 +56,10 @@ func (this *ValidationContext) ValidateStruct(entity interface{}) error {
 	}
 
 	this.innerValidate(v)
+	// a nil *ObjectError would not be a nil error
+	if this.valid {
+		return nil
+	}
 	return this.Errors()
 }
 
@@ -72,7 +76,11 @@ func (this *ValidationContext) innerValidate(v reflect.Value) {
 				this.Validate(f.Name, v.Field(i))
 			}
 		case reflect.Ptr:
-			this.innerValidate(v.Elem())
+			if f.Anonymous && !v.Field(i).IsNil() {
+				this.innerValidate(v.Field(i).Elem())
+			} else {
+				this.Validate(f.Name, v.Field(i))
+			}
 		default:
 			this.Validate(f.Name, v.Field(i))
 		}
@@ -111,3 +111,38 @@ func TestValidateStruct_Error(t *testing.T) {
 	assert.True(t, strings.Contains(errStr, "Amount:min"), errStr+" should contain Amount field")
 	assert.True(t, strings.Contains(errStr, "Name:pattern"), errStr+" should contain Name field")
 }
+
+type referencingEntity struct {
+	*baseEntity
+	Parent *baseEntity
+	Note   *string
+}
+
+func TestValidateStruct_PointerFields_Error(t *testing.T) {
+	// given
+	sv := structValidation("test.Test")
+	sv.Field("Name").With(NotBlank())
+
+	vc := GetContextFor(sv)
+
+	// when
+	err := vc.ValidateStruct(&referencingEntity{&baseEntity{uint64(1), ""}, &baseEntity{}, nil})
+
+	// then
+	assert.NotNil(t, err, "validation error expected")
+	assert.True(t, strings.Contains(err.Error(), "Name:notblank"), err.Error()+" should contain Name field")
+}
+
+func TestValidateStruct_Valid_NilError(t *testing.T) {
+	// given
+	sv := structValidation("test.Test")
+	sv.Field("Name").With(NotBlank())
+
+	vc := GetContextFor(sv)
+
+	// when
+	err := vc.ValidateStruct(&referencingEntity{&baseEntity{uint64(1), "Demo"}, nil, nil})
+
+	// then
+	assert.True(t, err == nil, "error should be untyped nil")
+}
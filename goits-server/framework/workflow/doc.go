@@ -0,0 +1,4 @@
+// Package workflow contains the state machines that decide how the state of an entity can change.
+// Workflows are read from <name>.workflow.yaml files under the workflow config dir, and the guards and actions
+// they refer to are registered by the modules that own the entities.
+package workflow
@@ -0,0 +1,194 @@
+package workflow
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cpekyaman/goits/config"
+)
+
+// Guard checks if the subject meets a condition of a transition, with the params given in the workflow.
+type Guard func(subject interface{}, params map[string]string) bool
+
+// Action changes the subject after it is moved to the target state of a transition, with the params given in the workflow.
+type Action func(subject interface{}, params map[string]string) error
+
+var guards map[string]Guard
+var actions map[string]Action
+
+var workflows map[string]*Workflow
+var lock sync.RWMutex
+
+func init() {
+	guards = make(map[string]Guard)
+	actions = make(map[string]Action)
+	workflows = make(map[string]*Workflow)
+}
+
+// RegisterGuard registers the guard by the name workflows refer to it.
+func RegisterGuard(name string, g Guard) {
+	guards[name] = g
+}
+
+// RegisterAction registers the action by the name workflows refer to it.
+func RegisterAction(name string, a Action) {
+	actions[name] = a
+}
+
+// Workflow is a set of states and the transitions allowed between them.
+type Workflow struct {
+	Name        string       `mapstructure:"name" json:"name"`
+	Initial     string       `mapstructure:"initial" json:"initial"`
+	States      []string     `mapstructure:"states" json:"states"`
+	Transitions []Transition `mapstructure:"transitions" json:"transitions"`
+}
+
+// Transition moves the subject from any of its source states to its target state,
+// if all of its guards pass. Its actions are run after the subject is moved.
+type Transition struct {
+	Name    string   `mapstructure:"name" json:"name"`
+	From    []string `mapstructure:"from" json:"from"`
+	To      string   `mapstructure:"to" json:"to"`
+	Guards  []Rule   `mapstructure:"guards" json:"guards,omitempty"`
+	Actions []Rule   `mapstructure:"actions" json:"actions,omitempty"`
+}
+
+// Rule refers to a registered guard or action with the params it is run with.
+type Rule struct {
+	Name   string            `mapstructure:"name" json:"name"`
+	Params map[string]string `mapstructure:"params" json:"params,omitempty"`
+}
+
+// ByName returns the workflow read from its config file, which is read only once.
+func ByName(name string) (*Workflow, error) {
+	lock.RLock()
+	wf, found := workflows[name]
+	lock.RUnlock()
+	if found {
+		return wf, nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if wf, found := workflows[name]; found {
+		return wf, nil
+	}
+
+	wf = &Workflow{}
+	if err := config.ReadConfig("workflow."+name, "workflow", name+".workflow", wf); err != nil {
+		return nil, fmt.Errorf("could not read workflow %s: %s", name, err.Error())
+	}
+	if err := wf.Verify(); err != nil {
+		return nil, err
+	}
+
+	workflows[name] = wf
+	return wf, nil
+}
+
+// Verify checks that the transitions are between the states of the workflow and refer to registered guards and actions.
+func (this Workflow) Verify() error {
+	if !this.hasState(this.Initial) {
+		return fmt.Errorf("workflow %s: initial state %s is not a state", this.Name, this.Initial)
+	}
+
+	names := make(map[string]bool)
+	for _, t := range this.Transitions {
+		if names[t.Name] {
+			return fmt.Errorf("workflow %s: transition %s is defined more than once", this.Name, t.Name)
+		}
+		names[t.Name] = true
+
+		if !this.hasState(t.To) {
+			return fmt.Errorf("workflow %s: target %s of transition %s is not a state", this.Name, t.To, t.Name)
+		}
+		for _, s := range t.From {
+			if !this.hasState(s) {
+				return fmt.Errorf("workflow %s: source %s of transition %s is not a state", this.Name, s, t.Name)
+			}
+		}
+		for _, g := range t.Guards {
+			if _, found := guards[g.Name]; !found {
+				return fmt.Errorf("workflow %s: guard %s of transition %s is not registered", this.Name, g.Name, t.Name)
+			}
+		}
+		for _, a := range t.Actions {
+			if _, found := actions[a.Name]; !found {
+				return fmt.Errorf("workflow %s: action %s of transition %s is not registered", this.Name, a.Name, t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (this Workflow) hasState(state string) bool {
+	for _, s := range this.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Available returns the transitions that can be taken from the given state.
+func (this Workflow) Available(state string) []Transition {
+	available := make([]Transition, 0)
+	for _, t := range this.Transitions {
+		if t.allowedFrom(state) {
+			available = append(available, t)
+		}
+	}
+	return available
+}
+
+// Transition finds the transition by its name, which must be available from the given state.
+func (this Workflow) Transition(state string, name string) (Transition, error) {
+	for _, t := range this.Transitions {
+		if t.Name != name {
+			continue
+		}
+		if !t.allowedFrom(state) {
+			return t, fmt.Errorf("conflict: transition %s is not allowed from %s", name, state)
+		}
+		return t, nil
+	}
+	return Transition{}, fmt.Errorf("notfound: workflow %s has no transition %s", this.Name, name)
+}
+
+func (this Transition) allowedFrom(state string) bool {
+	for _, s := range this.From {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Check runs the guards of the transition on the subject, failing with the first guard that does not pass.
+func (this Transition) Check(subject interface{}) error {
+	for _, g := range this.Guards {
+		guard, found := guards[g.Name]
+		if !found {
+			return fmt.Errorf("guard %s of transition %s is not registered", g.Name, this.Name)
+		}
+		if !guard(subject, g.Params) {
+			return fmt.Errorf("validation: %s is not met for transition %s", g.Name, this.Name)
+		}
+	}
+	return nil
+}
+
+// Apply runs the actions of the transition on the subject in the order they are defined.
+func (this Transition) Apply(subject interface{}) error {
+	for _, a := range this.Actions {
+		action, found := actions[a.Name]
+		if !found {
+			return fmt.Errorf("action %s of transition %s is not registered", a.Name, this.Name)
+		}
+		if err := action(subject, a.Params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cpekyaman/goits/framework/commons"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type subject struct {
+	Owner string
+	Note  string
+}
+
+func init() {
+	RegisterGuard("ownerRequired", func(s interface{}, params map[string]string) bool {
+		return s.(*subject).Owner != ""
+	})
+	RegisterAction("setNote", func(s interface{}, params map[string]string) error {
+		s.(*subject).Note = params["note"]
+		return nil
+	})
+}
+
+func testWorkflow() Workflow {
+	return Workflow{
+		Name:    "test",
+		Initial: "New",
+		States:  []string{"New", "Active", "Done"},
+		Transitions: []Transition{
+			{Name: "activate", From: []string{"New"}, To: "Active", Guards: []Rule{{Name: "ownerRequired"}}},
+			{Name: "finish", From: []string{"New", "Active"}, To: "Done", Actions: []Rule{{Name: "setNote", Params: map[string]string{"note": "done"}}}},
+		},
+	}
+}
+
+func TestVerify_Valid(t *testing.T) {
+	// when
+	err := testWorkflow().Verify()
+
+	// then
+	assert.Nil(t, err, "workflow should be valid")
+}
+
+func TestVerify_UnknownState_Error(t *testing.T) {
+	// given
+	wf := testWorkflow()
+	wf.Transitions[0].To = "Unknown"
+
+	// when
+	err := wf.Verify()
+
+	// then
+	assert.NotNil(t, err, "target should be a state")
+}
+
+func TestVerify_UnknownGuard_Error(t *testing.T) {
+	// given
+	wf := testWorkflow()
+	wf.Transitions[0].Guards = []Rule{{Name: "unknown"}}
+
+	// when
+	err := wf.Verify()
+
+	// then
+	assert.NotNil(t, err, "guard should be registered")
+}
+
+func TestAvailable(t *testing.T) {
+	// when
+	available := testWorkflow().Available("Active")
+
+	// then
+	assert.Equal(t, 1, len(available), "only finish should be available")
+	assert.Equal(t, "finish", available[0].Name, "only finish should be available")
+}
+
+func TestTransition_NotAllowed_Conflict(t *testing.T) {
+	// when
+	_, err := testWorkflow().Transition("Done", "finish")
+
+	// then
+	require.Error(t, err, "transition should not be allowed")
+	assert.True(t, strings.HasPrefix(err.Error(), "conflict:"), "should be a conflict error")
+}
+
+func TestTransition_Unknown_NotFound(t *testing.T) {
+	// when
+	_, err := testWorkflow().Transition("New", "unknown")
+
+	// then
+	require.Error(t, err, "transition should not be found")
+	assert.True(t, strings.HasPrefix(err.Error(), "notfound:"), "should be a not found error")
+}
+
+func TestCheck_GuardFails_Error(t *testing.T) {
+	// given
+	tr, err := testWorkflow().Transition("New", "activate")
+	require.NoError(t, err, "transition should be allowed")
+
+	// when
+	err = tr.Check(&subject{})
+
+	// then
+	require.Error(t, err, "guard should fail")
+	assert.True(t, strings.HasPrefix(err.Error(), "validation:"), "should be a validation error")
+}
+
+func TestApply_RunsActions(t *testing.T) {
+	// given
+	tr, err := testWorkflow().Transition("Active", "finish")
+	require.NoError(t, err, "transition should be allowed")
+	s := &subject{Owner: "someone"}
+
+	// when
+	err = tr.Apply(s)
+
+	// then
+	assert.Nil(t, err, "actions should be run")
+	assert.Equal(t, "done", s.Note, "action should use its params")
+}
+
+func TestByName_Unreadable_InternalError(t *testing.T) {
+	// when
+	_, err := ByName("missing")
+
+	// then
+	require.Error(t, err, "missing workflow should not be read")
+	assert.Equal(t, commons.ErrInternal, commons.DetermineErrorType(err), "config read failure should be an internal error")
+}
+
+func TestByName_DefaultWorkflow(t *testing.T) {
+	// given
+	RegisterGuard("assigneeRequired", func(s interface{}, params map[string]string) bool { return true })
+	RegisterAction("setResolution", func(s interface{}, params map[string]string) error { return nil })
+	RegisterAction("clearResolution", func(s interface{}, params map[string]string) error { return nil })
+
+	// when
+	wf, err := ByName("default")
+
+	// then
+	require.NoError(t, err, "default workflow should be read")
+	assert.Equal(t, "Open", wf.Initial, "initial state is not correct")
+
+	tr, err := wf.Transition("Open", "resolve")
+	require.NoError(t, err, "resolve should be allowed from open")
+	require.NotEmpty(t, tr.Actions, "resolve should have actions")
+	assert.Equal(t, "Fixed", tr.Actions[0].Params["resolution"], "action params should be read")
+}
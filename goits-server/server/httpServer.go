@@ -11,9 +11,14 @@ import (
 
 	"github.com/cpekyaman/goits/config"
 	"github.com/cpekyaman/goits/framework/monitoring"
+	"github.com/cpekyaman/goits/framework/orm/schema"
 	"github.com/cpekyaman/goits/framework/routing"
+	"github.com/cpekyaman/goits/framework/validation"
 
+	"github.com/cpekyaman/goits/application/comment"
+	"github.com/cpekyaman/goits/application/issue"
 	"github.com/cpekyaman/goits/application/project"
+	"github.com/cpekyaman/goits/application/user"
 
 	"net/http"
 
@@ -25,6 +30,7 @@ type httpConfig struct {
 	Port         int           `mapstructure:"port"`
 	ReadTimeout  time.Duration `mapstructure:"readTimeout"`
 	WriteTimeout time.Duration `mapstructure:"writeTimeout"`
+	CursorKey    string        `mapstructure:"cursorKey"`
 }
 
 var conf httpConfig
@@ -35,10 +41,20 @@ func Start() {
 	routing.InitRouting()
 	routing.Engine().RegisterPath("/metrics", promhttp.Handler())
 
+	// services of the routers validate through the default provider
+	validation.InitValidation()
+
 	// individual routers
 	project.InitProject()
+	issue.InitIssue()
+	comment.InitComment()
+	user.InitUser()
+
+	// entities are verified after all of them are registered
+	schema.VerifyOnStart()
 
 	config.ReadInto("http", &conf)
+	routing.SetCursorKey(conf.CursorKey)
 	svc := createServer(routing.Engine().Router())
 
 	startServer(svc)
@@ -69,7 +85,7 @@ func startServer(svc *http.Server) {
 
 // handleGracefulShutdown registers necessary signal handlers to handle graceful shutdown with term / kill.
 func handleGracefulShutdown(svc *http.Server) {
-	ch := make(chan os.Signal)
+	ch := make(chan os.Signal, 1)
 
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
 	<-ch
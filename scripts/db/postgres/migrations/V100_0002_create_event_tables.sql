@@ -4,8 +4,8 @@
 create sequence config.audit_event_type_seq;
 create table config.audit_event_type (
     id integer not null default nextval('config.audit_event_type_seq'),
-    name varchar(25) not null,
-)
+    name varchar(25) not null
+);
 create unique index audit_event_type_name_unq on config.audit_event_type(name);
 create unique index audit_event_type_id_pk on config.audit_event_type(id);
 ALTER TABLE config.audit_event_type
@@ -36,8 +36,8 @@ ALTER TABLE event.audit_event
 create sequence config.domain_event_type_seq;
 create table config.domain_event_type (
     id integer not null default nextval('config.domain_event_type_seq'),
-    name varchar(25) not null,
-)
+    name varchar(25) not null
+);
 create unique index domain_event_type_name_unq on config.domain_event_type(name);
 create unique index domain_event_type_id_pk on config.domain_event_type(id);
 ALTER TABLE config.domain_event_type
@@ -60,7 +60,7 @@ create index domain_event_target_idx on event.domain_event(target_type, target_i
 create index domain_event_reference_idx on event.domain_event(reference);
 ALTER TABLE event.domain_event
     add constraint domain_event_pk primary key using INDEX domain_event_id_pk,
-    add constraint domain_event_type_fk foreign key (event_type) references event.domain_event_type(id)
+    add constraint domain_event_type_fk foreign key (event_type) references config.domain_event_type(id)
 ;
 
 -- +migrate Down
@@ -46,7 +46,7 @@ create unique index project_name_unq on data.project(name);
 create unique index project_id_pk on data.project(id);
 ALTER TABLE data.project
     add constraint project_pk primary key using INDEX project_id_pk,
-    add constraint project_status_fk foreign key (status) references config.project_status(id)
+    add constraint project_status_fk foreign key (status) references config.project_status(id),
     add constraint project_type_fk foreign key (type) references config.project_type(id)
 ;
 
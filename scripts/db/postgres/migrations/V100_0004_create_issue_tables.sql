@@ -0,0 +1,118 @@
+-- +migrate Up
+
+-- project key, existing projects get keys from their ids which can be changed later
+alter table data.project
+    add column project_key text null
+;
+update data.project set project_key = 'P' || id;
+alter table data.project
+    alter column project_key set not null
+;
+create unique index project_project_key_unq on data.project(project_key);
+
+-- issue.IssueType
+create sequence config.issue_type_seq;
+create table config.issue_type (
+    id                  bigint not null default nextval('config.issue_type_seq'),
+    version             integer not null default 1,
+    name                text not null,
+    description         text not null
+);
+create unique index issue_type_name_unq on config.issue_type(name);
+create unique index issue_type_id_pk on config.issue_type(id);
+alter table config.issue_type
+    add constraint issue_type_pk primary key using index issue_type_id_pk
+;
+
+-- issue.IssuePriority
+create sequence config.issue_priority_seq;
+create table config.issue_priority (
+    id                  bigint not null default nextval('config.issue_priority_seq'),
+    version             integer not null default 1,
+    name                text not null,
+    description         text not null
+);
+create unique index issue_priority_name_unq on config.issue_priority(name);
+create unique index issue_priority_id_pk on config.issue_priority(id);
+alter table config.issue_priority
+    add constraint issue_priority_pk primary key using index issue_priority_id_pk
+;
+
+-- issue.IssueStatus
+create sequence config.issue_status_seq;
+create table config.issue_status (
+    id                  bigint not null default nextval('config.issue_status_seq'),
+    version             integer not null default 1,
+    name                text not null,
+    description         text not null
+);
+create unique index issue_status_name_unq on config.issue_status(name);
+create unique index issue_status_id_pk on config.issue_status(id);
+alter table config.issue_status
+    add constraint issue_status_pk primary key using index issue_status_id_pk
+;
+
+-- issue.Issue
+create sequence data.issue_seq;
+create table data.issue (
+    id                  bigint not null default nextval('data.issue_seq'),
+    version             integer not null default 1,
+    create_time         timestamp with time zone not null default now(),
+    last_modified_time  timestamp with time zone not null default now(),
+    issue_key           text not null,
+    project             bigint not null,
+    number              bigint not null,
+    summary             text not null,
+    description         text not null,
+    type                bigint not null,
+    priority            bigint not null,
+    status              bigint not null,
+    reporter            text not null,
+    assignee            text null,
+    due_date            timestamp with time zone null,
+    deleted             boolean not null default false,
+    deleted_time        timestamp with time zone null,
+    deleted_by          text null
+);
+create unique index issue_issue_key_unq on data.issue(issue_key);
+create unique index issue_id_pk on data.issue(id);
+alter table data.issue
+    add constraint issue_pk primary key using index issue_id_pk,
+    add constraint issue_project_fk foreign key (project) references data.project(id),
+    add constraint issue_type_fk foreign key (type) references config.issue_type(id),
+    add constraint issue_priority_fk foreign key (priority) references config.issue_priority(id),
+    add constraint issue_status_fk foreign key (status) references config.issue_status(id)
+;
+
+-- last issue number of each project, which issue keys are numbered from
+create table data.issue_counter (
+    project             bigint not null,
+    last_number         bigint not null
+);
+create unique index issue_counter_project_pk on data.issue_counter(project);
+alter table data.issue_counter
+    add constraint issue_counter_pk primary key using index issue_counter_project_pk,
+    add constraint issue_counter_project_fk foreign key (project) references data.project(id)
+;
+create unique index issue_project_number_unq on data.issue(project, number);
+
+-- +migrate Down
+
+drop table data.issue_counter;
+
+drop table data.issue;
+drop sequence data.issue_seq;
+
+drop table config.issue_status;
+drop sequence config.issue_status_seq;
+
+drop table config.issue_priority;
+drop sequence config.issue_priority_seq;
+
+drop table config.issue_type;
+drop sequence config.issue_type_seq;
+
+drop index data.project_project_key_unq;
+alter table data.project
+    drop column project_key
+;
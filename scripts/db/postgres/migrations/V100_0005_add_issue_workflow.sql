@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- workflow the issues of the projects of a type follow
+alter table config.project_type
+    add column workflow text not null default 'default'
+;
+
+-- resolution set by the transitions of the workflow
+alter table data.issue
+    add column resolution text null
+;
+
+-- +migrate Down
+
+alter table data.issue
+    drop column resolution
+;
+
+alter table config.project_type
+    drop column workflow
+;
@@ -0,0 +1,47 @@
+-- +migrate Up
+
+-- comment.Comment
+create sequence data.comment_seq;
+create table data.comment (
+    id                  bigint not null default nextval('data.comment_seq'),
+    version             integer not null default 1,
+    create_time         timestamp with time zone not null default now(),
+    last_modified_time  timestamp with time zone not null default now(),
+    issue               bigint not null,
+    reply_to            bigint null,
+    author              text not null,
+    body                text not null,
+    removed             boolean not null default false
+);
+create unique index comment_id_pk on data.comment(id);
+create index comment_issue_idx on data.comment(issue);
+alter table data.comment
+    add constraint comment_pk primary key using index comment_id_pk,
+    add constraint comment_issue_fk foreign key (issue) references data.issue(id),
+    add constraint comment_reply_to_fk foreign key (reply_to) references data.comment(id)
+;
+
+-- comment.CommentRevision, the previous bodies of edited comments
+create sequence data.comment_revision_seq;
+create table data.comment_revision (
+    id                  bigint not null default nextval('data.comment_revision_seq'),
+    create_time         timestamp with time zone not null default now(),
+    last_modified_time  timestamp with time zone not null default now(),
+    comment             bigint not null,
+    body                text not null,
+    editor              text null
+);
+create unique index comment_revision_id_pk on data.comment_revision(id);
+create index comment_revision_comment_idx on data.comment_revision(comment);
+alter table data.comment_revision
+    add constraint comment_revision_pk primary key using index comment_revision_id_pk,
+    add constraint comment_revision_comment_fk foreign key (comment) references data.comment(id)
+;
+
+-- +migrate Down
+
+drop table data.comment_revision;
+drop sequence data.comment_revision_seq;
+
+drop table data.comment;
+drop sequence data.comment_seq;
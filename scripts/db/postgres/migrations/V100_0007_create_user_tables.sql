@@ -0,0 +1,49 @@
+-- +migrate Up
+
+-- user.User
+create sequence data.user_account_seq;
+create table data.user_account (
+    id                  bigint not null default nextval('data.user_account_seq'),
+    version             integer not null default 1,
+    create_time         timestamp with time zone not null default now(),
+    last_modified_time  timestamp with time zone not null default now(),
+    username            text not null,
+    email               text not null,
+    full_name           text not null,
+    active              boolean not null default true,
+    admin               boolean not null default false,
+    password_hash       text not null,
+    failed_logins       integer not null default 0,
+    locked_until        timestamp with time zone null
+);
+create unique index user_account_username_unq on data.user_account(username);
+create unique index user_account_email_unq on data.user_account(email);
+create unique index user_account_id_pk on data.user_account(id);
+alter table data.user_account
+    add constraint user_account_pk primary key using index user_account_id_pk
+;
+
+-- user.Session, only the hashes of session tokens are kept
+create sequence data.user_session_seq;
+create table data.user_session (
+    id                  bigint not null default nextval('data.user_session_seq'),
+    create_time         timestamp with time zone not null default now(),
+    last_modified_time  timestamp with time zone not null default now(),
+    user_id             bigint not null,
+    token_hash          text not null,
+    expires_at          timestamp with time zone not null
+);
+create unique index user_session_token_hash_unq on data.user_session(token_hash);
+create unique index user_session_id_pk on data.user_session(id);
+alter table data.user_session
+    add constraint user_session_pk primary key using index user_session_id_pk,
+    add constraint user_session_user_fk foreign key (user_id) references data.user_account(id)
+;
+
+-- +migrate Down
+
+drop table data.user_session;
+drop sequence data.user_session_seq;
+
+drop table data.user_account;
+drop sequence data.user_account_seq;
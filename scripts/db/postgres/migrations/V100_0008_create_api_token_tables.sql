@@ -0,0 +1,28 @@
+-- +migrate Up
+
+-- user.ApiToken, only the hashes of api tokens are kept
+create sequence data.user_api_token_seq;
+create table data.user_api_token (
+    id                  bigint not null default nextval('data.user_api_token_seq'),
+    create_time         timestamp with time zone not null default now(),
+    last_modified_time  timestamp with time zone not null default now(),
+    user_id             bigint not null,
+    name                text not null,
+    token_hash          text not null,
+    scopes              text not null,
+    expires_at          timestamp with time zone null,
+    revoked_at          timestamp with time zone null,
+    last_used_at        timestamp with time zone null
+);
+create unique index user_api_token_token_hash_unq on data.user_api_token(token_hash);
+create index user_api_token_user_idx on data.user_api_token(user_id);
+create unique index user_api_token_id_pk on data.user_api_token(id);
+alter table data.user_api_token
+    add constraint user_api_token_pk primary key using index user_api_token_id_pk,
+    add constraint user_api_token_user_fk foreign key (user_id) references data.user_account(id)
+;
+
+-- +migrate Down
+
+drop table data.user_api_token;
+drop sequence data.user_api_token_seq;
@@ -0,0 +1,50 @@
+-- +migrate Up
+-- sqlite has no schemas, tables are created by name only
+
+-- audit event type
+create table audit_event_type (
+    id integer primary key,
+    name varchar(25) not null
+);
+create unique index audit_event_type_name_unq on audit_event_type(name);
+
+-- audit event
+create table audit_event (
+    id integer primary key,
+    reference text not null,
+    event_type integer not null references audit_event_type(id),
+    target_type varchar(50) not null,
+    target_id bigint not null,
+    event_data text not null,
+    event_time timestamp not null default current_timestamp,
+    description varchar(200) null
+);
+create index audit_event_reference_idx on audit_event(reference);
+create index audit_event_target_idx on audit_event(target_type, target_id);
+
+-- domain event type
+create table domain_event_type (
+    id integer primary key,
+    name varchar(25) not null
+);
+create unique index domain_event_type_name_unq on domain_event_type(name);
+
+-- domain event
+create table domain_event (
+    id integer primary key,
+    reference text not null,
+    event_type integer not null references domain_event_type(id),
+    target_type varchar(50) not null,
+    target_id bigint not null,
+    event_data text not null,
+    event_time timestamp not null default current_timestamp
+);
+create index domain_event_target_idx on domain_event(target_type, target_id);
+create index domain_event_reference_idx on domain_event(reference);
+
+-- +migrate Down
+drop table audit_event;
+drop table audit_event_type;
+
+drop table domain_event;
+drop table domain_event_type;
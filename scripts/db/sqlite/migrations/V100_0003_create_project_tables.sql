@@ -0,0 +1,37 @@
+-- +migrate Up
+
+-- project status
+create table project_status (
+    id              integer primary key,
+    name            varchar(25) not null,
+    description     varchar(250) not null,
+    version         integer not null default 1
+);
+create unique index project_status_name_unq on project_status(name);
+
+-- project type
+create table project_type (
+    id              integer primary key,
+    name            varchar(25) not null,
+    description     varchar(250) not null,
+    version         integer not null default 1
+);
+create unique index project_type_name_unq on project_type(name);
+
+-- project
+create table project (
+    id                  integer primary key,
+    name                varchar(50) not null,
+    description         varchar(250) not null,
+    type                integer not null references project_type(id),
+    status              integer not null references project_status(id),
+    version             integer not null default 1,
+    create_time         timestamp not null default current_timestamp,
+    last_modified_time  timestamp not null default current_timestamp
+);
+create unique index project_name_unq on project(name);
+
+-- +migrate Down
+drop table project;
+drop table project_type;
+drop table project_status;
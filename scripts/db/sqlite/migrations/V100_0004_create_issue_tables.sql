@@ -0,0 +1,73 @@
+-- +migrate Up
+
+-- project key, existing projects get keys from their ids which can be changed later
+alter table project add column project_key text not null default '';
+update project set project_key = 'P' || id;
+create unique index project_project_key_unq on project(project_key);
+
+-- issue type
+create table issue_type (
+    id                  integer primary key,
+    version             integer not null default 1,
+    name                text not null,
+    description         text not null
+);
+create unique index issue_type_name_unq on issue_type(name);
+
+-- issue priority
+create table issue_priority (
+    id                  integer primary key,
+    version             integer not null default 1,
+    name                text not null,
+    description         text not null
+);
+create unique index issue_priority_name_unq on issue_priority(name);
+
+-- issue status
+create table issue_status (
+    id                  integer primary key,
+    version             integer not null default 1,
+    name                text not null,
+    description         text not null
+);
+create unique index issue_status_name_unq on issue_status(name);
+
+-- issue
+create table issue (
+    id                  integer primary key,
+    version             integer not null default 1,
+    create_time         timestamp not null default current_timestamp,
+    last_modified_time  timestamp not null default current_timestamp,
+    issue_key           text not null,
+    project             integer not null references project(id),
+    number              integer not null,
+    summary             text not null,
+    description         text not null,
+    type                integer not null references issue_type(id),
+    priority            integer not null references issue_priority(id),
+    status              integer not null references issue_status(id),
+    reporter            text not null,
+    assignee            text null,
+    due_date            timestamp null,
+    deleted             boolean not null default false,
+    deleted_time        timestamp null,
+    deleted_by          text null
+);
+create unique index issue_issue_key_unq on issue(issue_key);
+create unique index issue_project_number_unq on issue(project, number);
+
+-- last issue number of each project, which issue keys are numbered from
+create table issue_counter (
+    project             integer primary key references project(id),
+    last_number         integer not null
+);
+
+-- +migrate Down
+drop table issue_counter;
+drop table issue;
+drop table issue_status;
+drop table issue_priority;
+drop table issue_type;
+
+drop index project_project_key_unq;
+alter table project drop column project_key;
@@ -0,0 +1,11 @@
+-- +migrate Up
+
+-- workflow the issues of the projects of a type follow
+alter table project_type add column workflow text not null default 'default';
+
+-- resolution set by the transitions of the workflow
+alter table issue add column resolution text null;
+
+-- +migrate Down
+alter table issue drop column resolution;
+alter table project_type drop column workflow;
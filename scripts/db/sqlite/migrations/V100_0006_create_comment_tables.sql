@@ -0,0 +1,30 @@
+-- +migrate Up
+
+-- comment.Comment
+create table comment (
+    id                  integer primary key,
+    version             integer not null default 1,
+    create_time         timestamp not null default current_timestamp,
+    last_modified_time  timestamp not null default current_timestamp,
+    issue               integer not null references issue(id),
+    reply_to            integer null references comment(id),
+    author              text not null,
+    body                text not null,
+    removed             boolean not null default false
+);
+create index comment_issue_idx on comment(issue);
+
+-- comment.CommentRevision, the previous bodies of edited comments
+create table comment_revision (
+    id                  integer primary key,
+    create_time         timestamp not null default current_timestamp,
+    last_modified_time  timestamp not null default current_timestamp,
+    comment             integer not null references comment(id),
+    body                text not null,
+    editor              text null
+);
+create index comment_revision_comment_idx on comment_revision(comment);
+
+-- +migrate Down
+drop table comment_revision;
+drop table comment;
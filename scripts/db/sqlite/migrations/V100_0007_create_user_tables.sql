@@ -0,0 +1,34 @@
+-- +migrate Up
+
+-- user.User
+create table user_account (
+    id                  integer primary key,
+    version             integer not null default 1,
+    create_time         timestamp not null default current_timestamp,
+    last_modified_time  timestamp not null default current_timestamp,
+    username            text not null,
+    email               text not null,
+    full_name           text not null,
+    active              boolean not null default true,
+    admin               boolean not null default false,
+    password_hash       text not null,
+    failed_logins       integer not null default 0,
+    locked_until        timestamp null
+);
+create unique index user_account_username_unq on user_account(username);
+create unique index user_account_email_unq on user_account(email);
+
+-- user.Session, only the hashes of session tokens are kept
+create table user_session (
+    id                  integer primary key,
+    create_time         timestamp not null default current_timestamp,
+    last_modified_time  timestamp not null default current_timestamp,
+    user_id             integer not null references user_account(id),
+    token_hash          text not null,
+    expires_at          timestamp not null
+);
+create unique index user_session_token_hash_unq on user_session(token_hash);
+
+-- +migrate Down
+drop table user_session;
+drop table user_account;
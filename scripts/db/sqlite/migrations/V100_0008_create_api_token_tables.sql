@@ -0,0 +1,20 @@
+-- +migrate Up
+
+-- user.ApiToken, only the hashes of api tokens are kept
+create table user_api_token (
+    id                  integer primary key,
+    create_time         timestamp not null default current_timestamp,
+    last_modified_time  timestamp not null default current_timestamp,
+    user_id             integer not null references user_account(id),
+    name                text not null,
+    token_hash          text not null,
+    scopes              text not null,
+    expires_at          timestamp null,
+    revoked_at          timestamp null,
+    last_used_at        timestamp null
+);
+create unique index user_api_token_token_hash_unq on user_api_token(token_hash);
+create index user_api_token_user_idx on user_api_token(user_id);
+
+-- +migrate Down
+drop table user_api_token;